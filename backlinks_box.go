@@ -0,0 +1,77 @@
+package nve
+
+import (
+	"log"
+
+	"github.com/rivo/tview"
+)
+
+// BacklinksBox lists the notes that contain a [[link]] to the current note.
+type BacklinksBox struct {
+	*tview.List
+	notes    *Notes
+	refs     []*FileRef
+	openFunc func(f *FileRef)
+}
+
+func NewBacklinksBox(notes *Notes) *BacklinksBox {
+	box := BacklinksBox{
+		List:  tview.NewList(),
+		notes: notes,
+	}
+
+	box.ShowSecondaryText(false).
+		SetWrapAround(false).
+		SetHighlightFullLine(true).
+		SetSelectedFocusOnly(true).
+		SetSelectedStyle(Colors.SelectedStyle())
+
+	box.SetBorder(true).
+		SetTitle("Backlinks").
+		SetTitleColor(Colors.PaneTitle).
+		SetBorderStyle(Colors.BorderStyle()).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		if box.openFunc != nil && index < len(box.refs) {
+			box.openFunc(box.refs[index])
+		}
+	})
+
+	return &box
+}
+
+// SetOpenFunc sets a handler called when a backlink is selected.
+func (b *BacklinksBox) SetOpenFunc(handler func(f *FileRef)) *BacklinksBox {
+	b.openFunc = handler
+	return b
+}
+
+// SetFile lists the backlinks of the given file. A nil file clears the list.
+func (b *BacklinksBox) SetFile(f *FileRef) {
+	b.Clear()
+	b.refs = nil
+
+	if f == nil {
+		return
+	}
+
+	refs, err := b.notes.Backlinks(f)
+
+	if err != nil {
+		log.Printf("[ERROR] BacklinksBox: %v", err)
+		return
+	}
+
+	b.refs = refs
+
+	for _, ref := range refs {
+		b.AddItem(tview.Escape(ref.DisplayName()), "", 0, nil)
+	}
+}
+
+// HasBacklinks returns true if the current file has any backlinks.
+func (b *BacklinksBox) HasBacklinks() bool {
+	return len(b.refs) > 0
+}
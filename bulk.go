@@ -0,0 +1,338 @@
+package nve
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// trashDirName is the folder of the per-vault config directory holding
+// notes moved to the trash.
+const trashDirName = "trash"
+
+// DeleteNotes removes notes from disk and from the index, then refreshes
+// the last search.
+func (n *Notes) DeleteNotes(refs []*FileRef) error {
+	for _, ref := range refs {
+		if err := os.Remove(ref.Filename); err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := n.db.PruneFileRefs(refs); err != nil {
+		return err
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return err
+}
+
+// TrashNotes moves notes to the trash ('.nve/trash'), keeping the folders
+// they were in, then refreshes the last search. Notes in the trash are not
+// indexed, and can be restored by moving them back.
+func (n *Notes) TrashNotes(refs []*FileRef) error {
+	if _, err := n.trash(refs); err != nil {
+		return err
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return err
+}
+
+// trash moves notes to the trash, returning the files they were moved to.
+func (n *Notes) trash(refs []*FileRef) ([]string, error) {
+	var dests []string
+
+	for _, ref := range refs {
+		name, err := filepath.Rel(n.config.Filepath, ref.Filename)
+
+		if err != nil {
+			return dests, errors.WithStack(err)
+		}
+
+		dest := freePath(filepath.Join(n.config.Filepath, configDirName, trashDirName, name))
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return dests, errors.WithStack(err)
+		}
+
+		if err := os.Rename(ref.Filename, dest); err != nil {
+			return dests, errors.WithStack(err)
+		}
+
+		dests = append(dests, dest)
+	}
+
+	return dests, n.db.PruneFileRefs(refs)
+}
+
+// freePath returns path if no file exists there, or else the first path
+// numbered like 'name 2.md' that is free.
+func freePath(path string) string {
+	var (
+		ext  = filepath.Ext(path)
+		base = strings.TrimSuffix(path, ext)
+	)
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+
+		path = fmt.Sprintf("%s %d%s", base, i, ext)
+	}
+}
+
+// MoveNotes moves notes into a folder of the notes directory (the notes
+// directory itself if blank), keeping their names, then refreshes the last
+// search. No note is moved if any of them would replace another note, or
+// two of them would have the same name. Returns the moved notes.
+func (n *Notes) MoveNotes(refs []*FileRef, folder string) ([]*FileRef, error) {
+	// the folder is resolved as that of a note within it
+	p, _, err := n.notePath(path.Join(filepath.ToSlash(folder), "note"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dir   = filepath.Dir(p)
+		dests = make([]string, len(refs))
+		taken = map[string]bool{}
+		moved []*FileRef
+	)
+
+	for i, ref := range refs {
+		dests[i] = filepath.Join(dir, filepath.Base(ref.Filename))
+		name := n.RelativeName(&FileRef{Filename: dests[i]})
+
+		if taken[dests[i]] {
+			return nil, errors.Errorf("notes would have the same name: %s", name)
+		}
+
+		taken[dests[i]] = true
+
+		if _, err := os.Stat(dests[i]); err == nil && dests[i] != ref.Filename {
+			return nil, errors.Errorf("note already exists: %s", name)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for i, ref := range refs {
+		ref := *ref
+
+		if dests[i] != ref.Filename {
+			if err := moveFile(ref.Filename, dests[i]); os.IsExist(errors.Cause(err)) {
+				return nil, errors.Errorf("note already exists: %s", n.RelativeName(&FileRef{Filename: dests[i]}))
+			} else if err != nil {
+				return nil, err
+			}
+
+			if err := n.db.Rename(&ref, dests[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		moved = append(moved, &ref)
+	}
+
+	if _, err := n.Search(n.LastQuery); err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+// moveFile moves a file without replacing another one created meanwhile,
+// linking it to its new path before removing the old one.
+func moveFile(from, to string) error {
+	if err := os.Link(from, to); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Remove(from))
+}
+
+// TagNotes adds a tag to the front matter of notes which do not have it, or
+// removes it from notes, both from their front matter and as #tag tokens,
+// then refreshes the last search. Returns the number of notes changed.
+func (n *Notes) TagNotes(refs []*FileRef, tag string, add bool) (int, error) {
+	var changed int
+
+	if tag = normalizeTag(tag); tag == "" {
+		return 0, errors.New("not a tag")
+	}
+
+	for _, ref := range refs {
+		var (
+			content = GetContent(ref.Filename)
+			updated string
+		)
+
+		if add {
+			updated = addTag(content, tag)
+		} else {
+			updated = removeTag(content, tag)
+		}
+
+		if updated == content {
+			continue
+		}
+
+		if err := SaveContent(ref.Filename, updated); err != nil {
+			return changed, errors.WithStack(err)
+		}
+
+		if err := n.indexFile(ref.Filename); err != nil {
+			return changed, err
+		}
+
+		changed++
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return changed, err
+}
+
+// TogglePins pins notes, or unpins them if all of them are pinned, then
+// refreshes the last search.
+func (n *Notes) TogglePins(refs []*FileRef) error {
+	pinned := true
+
+	for _, ref := range refs {
+		isPinned, err := n.db.IsPinned(ref)
+
+		if err != nil {
+			return err
+		}
+
+		pinned = pinned && isPinned
+	}
+
+	for _, ref := range refs {
+		if err := n.db.SetPinned(ref, !pinned); err != nil {
+			return err
+		}
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return err
+}
+
+// MergeNotes appends the text of notes to the first of them, each under a
+// heading naming it and without its front matter, then moves the others to
+// the trash and redirects their [[links]] to the merged note. Refreshes the
+// last search, and returns the merged note. The merge can be undone.
+func (n *Notes) MergeNotes(refs []*FileRef) (*FileRef, error) {
+	if len(refs) < 2 {
+		return nil, errors.New("select at least two notes to merge")
+	}
+
+	var (
+		target   = refs[0]
+		sources  = refs[1:]
+		sections = []string{strings.TrimRight(GetContent(target.Filename), "\n")}
+		linking  = make(map[string][]*FileRef) // notes linking to each source
+		c        = n.newChange("merge")
+	)
+
+	c.record(target.Filename)
+
+	for _, ref := range sources {
+		fm, body := parseFrontMatter(GetContent(ref.Filename))
+
+		title := fm.Title
+		if title == "" {
+			title = ref.DisplayName()
+		}
+
+		sections = append(sections, "# "+title+"\n\n"+strings.Trim(body, "\n"))
+
+		// links are found while the sources are still indexed
+		backlinks, err := n.Backlinks(ref)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.record(ref.Filename)
+
+		for _, backlink := range backlinks {
+			if !containsRef(refs, backlink) {
+				linking[ref.Filename] = append(linking[ref.Filename], backlink)
+				c.record(backlink.Filename)
+			}
+		}
+	}
+
+	content := strings.TrimLeft(strings.Join(sections, "\n\n"), "\n") + "\n"
+
+	// links between the merged notes now point within the merged note
+	for _, ref := range sources {
+		content = rewriteLinks(content, ref.DisplayName(), target.DisplayName())
+	}
+
+	if err := SaveContent(target.Filename, content); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := n.indexFile(target.Filename); err != nil {
+		return nil, err
+	}
+
+	dests, err := n.trash(sources)
+	c.created(dests...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range sources {
+		if err := n.redirectLinks(linking[ref.Filename], ref.DisplayName(), target); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := n.Search(n.LastQuery); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// redirectLinks rewrites the [[links]] to name in notes into links to
+// another note.
+func (n *Notes) redirectLinks(refs []*FileRef, name string, target *FileRef) error {
+	for _, ref := range refs {
+		content := GetContent(ref.Filename)
+
+		if updated := rewriteLinks(content, name, target.DisplayName()); updated != content {
+			if err := SaveContent(ref.Filename, updated); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if err := n.indexFile(ref.Filename); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// containsRef returns true if refs include the note of ref.
+func containsRef(refs []*FileRef, ref *FileRef) bool {
+	for _, r := range refs {
+		if r.Filename == ref.Filename {
+			return true
+		}
+	}
+	return false
+}
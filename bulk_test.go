@@ -0,0 +1,200 @@
+package nve
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkActions(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	texts := map[string]string{
+		"apple.md":       "---\ntitle: Apple\n---\nRed and crunchy.\n",
+		"banana.md":      "Yellow #fruit\n",
+		"cherry.md":      "Small and red.\n",
+		"work/cherry.md": "Cherry at work.\n",
+	}
+
+	for name, text := range texts {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(text), 0644))
+	}
+
+	_, err := n.Refresh()
+	require.NoError(t, err)
+
+	refs := func(names ...string) []*FileRef {
+		var refs []*FileRef
+		for _, name := range names {
+			ref, err := n.db.FindByName(name)
+			require.NoError(t, err)
+			refs = append(refs, ref)
+		}
+		return refs
+	}
+
+	names := func() []string {
+		var names []string
+		for _, result := range n.LastSearchResults {
+			names = append(names, n.RelativeName(result.FileRef))
+		}
+		return names
+	}
+
+	t.Run("exports notes", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		count, err := n.ExportNotes(&buf, refs("apple", "banana"))
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		var files []string
+		for _, f := range archive.File {
+			files = append(files, f.Name)
+		}
+		assert.ElementsMatch(t, []string{"apple.md", "banana.md"}, files)
+	})
+
+	t.Run("tags notes", func(t *testing.T) {
+		count, err := n.TagNotes(refs("apple", "banana"), "#Fruit", true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "banana has the tag already")
+
+		n.Search("tag:fruit")
+		assert.ElementsMatch(t, []string{"apple", "banana"}, names())
+
+		count, err = n.TagNotes(refs("apple", "banana"), "fruit", false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		assert.Equal(t, "---\ntitle: Apple\n---\nRed and crunchy.\n", GetContent(filepath.Join(dir, "apple.md")))
+		assert.Equal(t, "Yellow\n", GetContent(filepath.Join(dir, "banana.md")))
+
+		_, err = n.TagNotes(refs("apple"), "#", true)
+		assert.Error(t, err)
+	})
+
+	t.Run("pins notes", func(t *testing.T) {
+		n.Search("")
+		require.NoError(t, n.TogglePins(refs("apple", "banana")))
+
+		pinned, err := n.db.IsPinned(refs("banana")[0])
+		require.NoError(t, err)
+		assert.True(t, pinned)
+
+		require.NoError(t, n.TogglePins(refs("apple", "banana")))
+
+		pinned, err = n.db.IsPinned(refs("banana")[0])
+		require.NoError(t, err)
+		assert.False(t, pinned, "all pinned notes are unpinned")
+	})
+
+	t.Run("does not move over another note", func(t *testing.T) {
+		_, err := n.MoveNotes(refs("apple", "cherry"), "work")
+		assert.EqualError(t, err, "note already exists: work/cherry")
+		assert.FileExists(t, filepath.Join(dir, "apple.md"))
+	})
+
+	t.Run("does not move notes onto each other", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "home"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "home", "cherry.md"), []byte("Cherry at home.\n"), 0644))
+		_, err := n.Refresh()
+		require.NoError(t, err)
+
+		_, err = n.MoveNotes(refs("home/cherry", "work/cherry"), "kitchen")
+		assert.EqualError(t, err, "notes would have the same name: kitchen/cherry")
+		assert.NoDirExists(t, filepath.Join(dir, "kitchen"))
+
+		// nor over a note created since it was checked
+		err = moveFile(filepath.Join(dir, "home", "cherry.md"), filepath.Join(dir, "work", "cherry.md"))
+		assert.True(t, os.IsExist(errors.Cause(err)))
+		assert.Equal(t, "Cherry at work.\n", GetContent(filepath.Join(dir, "work", "cherry.md")))
+		assert.FileExists(t, filepath.Join(dir, "home", "cherry.md"))
+
+		require.NoError(t, os.Remove(filepath.Join(dir, "home", "cherry.md")))
+		_, err = n.Refresh()
+		require.NoError(t, err)
+	})
+
+	t.Run("moves notes", func(t *testing.T) {
+		moved, err := n.MoveNotes(refs("apple", "banana"), "fruit/")
+		require.NoError(t, err)
+		require.Len(t, moved, 2)
+
+		assert.Equal(t, filepath.Join(dir, "fruit", "apple.md"), moved[0].Filename)
+		assert.FileExists(t, moved[1].Filename)
+		assert.NoFileExists(t, filepath.Join(dir, "apple.md"))
+
+		n.Search("crunchy")
+		assert.Equal(t, []string{"fruit/apple"}, names())
+
+		_, err = n.MoveNotes(moved, "../outside")
+		assert.NoError(t, err, "folders stay within the notes")
+		assert.FileExists(t, filepath.Join(dir, "outside", "apple.md"))
+	})
+
+	t.Run("merges notes", func(t *testing.T) {
+		_, err := n.MergeNotes(refs("apple"))
+		assert.Error(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "links.md"), []byte("See [[apple]] and [[Banana|bananas]]."), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cherry.md"), []byte("Small and red, unlike [[banana]].\n"), 0644))
+		_, err = n.Refresh()
+		require.NoError(t, err)
+
+		merged, err := n.MergeNotes(refs("cherry", "apple", "banana"))
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			"Small and red, unlike [[cherry]].\n\n# Apple\n\nRed and crunchy.\n\n# banana\n\nYellow\n",
+			GetContent(merged.Filename))
+
+		assert.Equal(t, "See [[cherry]] and [[cherry|bananas]].", GetContent(filepath.Join(dir, "links.md")))
+
+		assert.FileExists(t, filepath.Join(dir, configDirName, trashDirName, "outside", "apple.md"))
+
+		n.Search("crunchy")
+		assert.Equal(t, []string{"cherry"}, names())
+	})
+
+	t.Run("undoes merge", func(t *testing.T) {
+		label, err := n.UndoChange()
+		require.NoError(t, err)
+		assert.Equal(t, "merge", label)
+
+		assert.Equal(t, "Small and red, unlike [[banana]].\n", GetContent(filepath.Join(dir, "cherry.md")))
+		assert.Equal(t, "See [[apple]] and [[Banana|bananas]].", GetContent(filepath.Join(dir, "links.md")))
+		assert.FileExists(t, filepath.Join(dir, "outside", "apple.md"))
+		assert.NoFileExists(t, filepath.Join(dir, configDirName, trashDirName, "outside", "apple.md"))
+
+		n.Search("crunchy")
+		assert.Equal(t, []string{"outside/apple"}, names())
+
+		// merged again, for the notes trashed below
+		_, err = n.MergeNotes(refs("cherry", "apple", "banana"))
+		require.NoError(t, err)
+	})
+
+	t.Run("trashes notes", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, configDirName, trashDirName, "work"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, trashDirName, "work", "cherry.md"), nil, 0644))
+
+		require.NoError(t, n.TrashNotes(refs("work/cherry")))
+
+		assert.NoFileExists(t, filepath.Join(dir, "work", "cherry.md"))
+		assert.Equal(t, "Cherry at work.\n", GetContent(filepath.Join(dir, configDirName, trashDirName, "work", "cherry 2.md")))
+
+		n.Search("")
+		assert.ElementsMatch(t, []string{"cherry", "links"}, names())
+	})
+}
@@ -0,0 +1,117 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// maxChanges is the number of changes to notes which can be undone.
+const maxChanges = 20
+
+// change records the files of notes as they were before notes were merged
+// or split, so that the change can be undone.
+type change struct {
+	label string
+	files []fileState
+}
+
+// fileState is the content of a file, unless there was no such file.
+type fileState struct {
+	filename string
+	content  []byte
+	exists   bool
+}
+
+// record keeps the current content of files, unless it was kept already.
+func (c *change) record(filenames ...string) {
+	for _, filename := range filenames {
+		if c.recorded(filename) {
+			continue
+		}
+
+		content, err := os.ReadFile(filename)
+
+		c.files = append(c.files, fileState{filename: filename, content: content, exists: err == nil})
+	}
+}
+
+// created records files which did not exist before the change.
+func (c *change) created(filenames ...string) {
+	for _, filename := range filenames {
+		if !c.recorded(filename) {
+			c.files = append(c.files, fileState{filename: filename})
+		}
+	}
+}
+
+func (c *change) recorded(filename string) bool {
+	for _, file := range c.files {
+		if file.filename == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// undo restores the files as they were, removing those created since.
+func (c *change) undo() error {
+	for i := len(c.files) - 1; i >= 0; i-- {
+		file := c.files[i]
+
+		if !file.exists {
+			if err := os.Remove(file.filename); err != nil && !os.IsNotExist(err) {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file.filename), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := os.WriteFile(file.filename, file.content, 0644); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// newChange starts recording a change to notes, which can be undone from
+// then on, even if it fails halfway.
+func (n *Notes) newChange(label string) *change {
+	c := &change{label: label}
+
+	n.changes = append(n.changes, c)
+
+	if len(n.changes) > maxChanges {
+		n.changes = n.changes[len(n.changes)-maxChanges:]
+	}
+
+	return c
+}
+
+// UndoChange restores the notes changed by the last merge or split, then
+// refreshes the index and the last search. Returns what was undone (e.g.
+// 'merge'), or nothing if there is nothing left to undo.
+func (n *Notes) UndoChange() (string, error) {
+	if len(n.changes) == 0 {
+		return "", nil
+	}
+
+	c := n.changes[len(n.changes)-1]
+	n.changes = n.changes[:len(n.changes)-1]
+
+	if err := c.undo(); err != nil {
+		return "", err
+	}
+
+	if _, err := n.Refresh(); err != nil {
+		return "", err
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return c.label, err
+}
@@ -0,0 +1,96 @@
+package nve
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Clipboard copies text to the system clipboard through the terminal, with
+// the OSC 52 escape sequence, which also works over SSH and within tmux
+// (given 'set -g set-clipboard on'). Where there is a clipboard command,
+// such as wl-copy or xclip, text is copied with it as well. Pasting needs
+// such a command, as terminals rarely allow reading the clipboard.
+type Clipboard struct {
+	terminal io.Writer
+	copyCmd  []string
+	pasteCmd []string
+}
+
+// clipboardCommands are the commands copying to and pasting from the system
+// clipboard, in order of preference, along with the environment variable
+// naming the display they need, if any.
+var clipboardCommands = []struct {
+	display     string
+	copy, paste []string
+}{
+	{display: "WAYLAND_DISPLAY", copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}},
+	{display: "DISPLAY", copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+	{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+}
+
+// NewClipboard returns a clipboard writing escape sequences to terminal,
+// using the first clipboard command found.
+func NewClipboard(terminal io.Writer) *Clipboard {
+	c := Clipboard{terminal: terminal}
+
+	for _, cmd := range clipboardCommands {
+		if cmd.display != "" && os.Getenv(cmd.display) == "" {
+			continue
+		}
+
+		if _, err := exec.LookPath(cmd.copy[0]); err == nil {
+			c.copyCmd, c.pasteCmd = cmd.copy, cmd.paste
+			break
+		}
+	}
+
+	return &c
+}
+
+// osc52 returns the escape sequence setting the clipboard to text.
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// Copy copies text to the system clipboard.
+func (c *Clipboard) Copy(text string) error {
+	if c.terminal != nil {
+		if _, err := io.WriteString(c.terminal, osc52(text)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if c.copyCmd == nil {
+		return nil
+	}
+
+	cmd := exec.Command(c.copyCmd[0], c.copyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+
+	return errors.Wrapf(cmd.Run(), "could not run %s", c.copyCmd[0])
+}
+
+// Paste returns the text of the system clipboard, or nothing if there is
+// no clipboard command to read it with.
+func (c *Clipboard) Paste() (string, error) {
+	if c.pasteCmd == nil {
+		return "", nil
+	}
+
+	var out bytes.Buffer
+
+	cmd := exec.Command(c.pasteCmd[0], c.pasteCmd[1:]...)
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "could not run %s", c.pasteCmd[0])
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,95 @@
+package nve
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipboard(t *testing.T) {
+	t.Run("copies through the terminal", func(t *testing.T) {
+		var terminal bytes.Buffer
+
+		clipboard := &Clipboard{terminal: &terminal}
+		require.NoError(t, clipboard.Copy("hello"))
+
+		assert.Equal(t, "\x1b]52;c;aGVsbG8=\a", terminal.String())
+
+		text, err := clipboard.Paste()
+		require.NoError(t, err)
+		assert.Empty(t, text, "no command to paste with")
+	})
+
+	t.Run("copies and pastes with commands", func(t *testing.T) {
+		var (
+			terminal bytes.Buffer
+			file     = filepath.Join(t.TempDir(), "clipboard")
+		)
+
+		clipboard := &Clipboard{
+			terminal: &terminal,
+			copyCmd:  []string{"sh", "-c", "cat > " + file},
+			pasteCmd: []string{"cat", file},
+		}
+		require.NoError(t, clipboard.Copy("line\n"))
+
+		text, err := clipboard.Paste()
+		require.NoError(t, err)
+		assert.Equal(t, "line\n", text)
+		assert.NotEmpty(t, terminal.String())
+	})
+
+	t.Run("reports failing commands", func(t *testing.T) {
+		clipboard := &Clipboard{pasteCmd: []string{"false"}}
+
+		_, err := clipboard.Paste()
+		assert.Error(t, err)
+	})
+}
+
+func TestContentBoxClipboard(t *testing.T) {
+	defaultKeys := Keys
+	Keys = ViKeymap()
+	t.Cleanup(func() { Keys = defaultKeys })
+
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+
+	var copied, clipboard string
+
+	box := NewContentBox().
+		SetCopyFunc(func(text string) { copied = text }).
+		SetPasteFunc(func() string { return clipboard })
+	box.SetText("one\ntwo", false)
+	box.SetRect(0, 0, 80, 10)
+	box.Draw(screen)
+	box.Select(0, 0)
+
+	press := func(keys string) {
+		seq, err := ParseKeySequence(keys)
+		require.NoError(t, err)
+
+		for _, key := range seq {
+			box.InputHandler()(tcell.NewEventKey(key.Key, key.Rune, key.Mod), func(p tview.Primitive) {})
+			box.Draw(screen)
+		}
+	}
+
+	press("y y")
+	assert.Equal(t, "one\n", copied)
+
+	press("p")
+	assert.Equal(t, "one\none\ntwo", box.GetText(), "pastes what was copied without a clipboard")
+
+	clipboard = "three\n"
+	press("p")
+	assert.Equal(t, "one\none\nthree\ntwo", box.GetText(), "pastes from the clipboard")
+
+	press("d d")
+	assert.Equal(t, "three\n", copied)
+}
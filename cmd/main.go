@@ -1,15 +1,38 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/ivan3bx/nve"
 	"github.com/rivo/tview"
 )
 
+const usage = `usage: nve [command]
+
+Commands:
+  today    open (or create) today's daily note
+  replace  replace text in all notes (see 'nve replace -h')
+`
+
 func main() {
+	var today bool
+
+	switch {
+	case len(os.Args) == 1:
+	case len(os.Args) == 2 && os.Args[1] == "today":
+		today = true
+	case len(os.Args) >= 2 && os.Args[1] == "replace":
+		os.Exit(replaceCommand(os.Args[2:]))
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
 	// Setup debug logging to file
 	logFile, err := os.OpenFile("nve-debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
@@ -18,19 +41,256 @@ func main() {
 	defer logFile.Close()
 	log.SetOutput(logFile)
 
+	config, err := nve.LoadConfig("./")
+	if err != nil {
+		log.Printf("[ERROR] could not load config, using defaults: %v", err)
+	}
+
+	if nve.Keys, err = config.Keymap(); err != nil {
+		log.Printf("[ERROR] could not load keys, using defaults: %v", err)
+	}
+
+	if nve.Colors, err = config.Colors(); err != nil {
+		log.Printf("[ERROR] could not load theme, using defaults: %v", err)
+	}
+
+	layout, err := nve.LoadLayout("./")
+	if err != nil {
+		log.Printf("[ERROR] could not load layout, using defaults: %v", err)
+	}
+
+	// zen mode needs a note, which only 'today' opens on start
+	layout.Zen = layout.Zen && today
+
+	// widgets take their default colors from the theme when created
+	nve.Colors.Apply()
+
 	var (
 		app   = tview.NewApplication()
 		notes = nve.NewNotes(nve.NotesConfig{
-			Filepath: "./",
+			Filepath:      "./",
+			Extension:     config.Extension,
+			SnippetLength: config.SnippetLength,
 		})
 
 		// View hierarchy
 		contentBox = nve.NewContentBox()
 		listBox    = nve.NewListBox(contentBox, notes)
 		searchBox  = nve.NewSearchBox(listBox, contentBox, notes)
+
+		backlinksBox = nve.NewBacklinksBox(notes)
+		tagsBox      = nve.NewTagsBox()
+		renameBox    = nve.NewRenameBox(notes)
+		templateBox  = nve.NewTemplateBox()
+		journalBox   = nve.NewJournalBox()
+		statusBar    = nve.NewStatusBar(contentBox, notes)
+		helpBox      = nve.NewHelpBox()
+		helpFocus    tview.Primitive
+		paletteBox   = nve.NewPaletteBox()
+		paletteFocus tview.Primitive
+		replaceBox   = nve.NewReplaceBox(notes)
+		replaceFocus tview.Primitive
+		promptBox    = nve.NewPromptBox()
+		promptFocus  tview.Primitive
+		messageBox   = tview.NewModal()
+		commands     = nve.NewCommands()
+		history      = nve.NewHistory(50)
+		clipboard    = nve.NewClipboard(os.Stdout)
+		contentRow   = tview.NewFlex()
+		panes        = nve.NewPanes(searchBox, listBox, contentRow, contentBox, layout)
+		mainRow      = tview.NewFlex()
+		showTags     = false
+		pages        = tview.NewPages()
 	)
 
-	notes.RegisterObservers(listBox)
+	// showTagsPane sizes the tags pane, which zen mode hides along with the
+	// other panes.
+	showTagsPane := func() {
+		if showTags && !panes.Layout().Zen {
+			mainRow.ResizeItem(tagsBox, 24, 0)
+		} else {
+			mainRow.ResizeItem(tagsBox, 0, 0)
+		}
+	}
+
+	// setLayout rearranges the panes, and remembers their arrangement.
+	setLayout := func(layout nve.Layout) {
+		panes.SetLayout(layout)
+		listBox.SetCompact(layout.Mode == nve.LayoutSideBySide)
+		showTagsPane()
+		if err := nve.SaveLayout("./", layout); err != nil {
+			log.Printf("[ERROR] could not save layout: %v", err)
+		}
+	}
+
+	// a list beside the content has no room for timestamps
+	listBox.SetCompact(layout.Mode == nve.LayoutSideBySide)
+
+	// the list is also resized by dragging its border
+	panes.SetResizedFunc(setLayout)
+
+	// leaveZen shows all panes again, e.g. before focusing the search box.
+	leaveZen := func() {
+		if layout := panes.Layout(); layout.Zen {
+			layout.Zen = false
+			setLayout(layout)
+		}
+	}
+
+	// openNote displays a note, syncing the search box and list with it.
+	openNote := func(ref *nve.FileRef) {
+		searchBox.SetTextFromList(ref.DisplayName())
+		notes.Search(ref.DisplayName())
+		contentBox.SetFile(ref)
+		history.Visit(ref)
+	}
+
+	// openJournal displays the daily note for a day, creating it if needed.
+	openJournal := func(day time.Time) {
+		ref, cursor, err := notes.JournalEntry(day)
+		if err != nil {
+			log.Printf("[ERROR] could not open journal for %s: %v", day.Format("2006-01-02"), err)
+			return
+		}
+		openNote(ref)
+		contentBox.Select(cursor, cursor)
+		app.SetFocus(contentBox)
+	}
+
+	// openAdjacentJournal displays the daily note before or after the current
+	// one. Returns false if no note is open.
+	openAdjacentJournal := func(offset int) bool {
+		ref := contentBox.CurrentFile()
+		if ref == nil || searchBox.HasFocus() {
+			return false
+		}
+		if adjacent, err := notes.AdjacentJournalEntry(ref, offset); err != nil {
+			log.Printf("[ERROR] could not navigate journal: %v", err)
+		} else if adjacent != nil {
+			openNote(adjacent)
+		}
+		return true
+	}
+
+	contentBox.SetLinkFunc(func(name string) {
+		ref, err := notes.FollowLink(name)
+		if err != nil {
+			log.Printf("[ERROR] could not follow link '%s': %v", name, err)
+			return
+		}
+		openNote(ref)
+	})
+
+	contentBox.SetCompletionFunc(func(query string) []string {
+		titles, err := notes.Titles(query)
+		if err != nil {
+			log.Printf("[ERROR] could not complete link '%s': %v", query, err)
+		}
+		return titles
+	})
+
+	// notes are recently opened once edited, rather than when browsing the list
+	contentBox.SetOpenedFunc(history.Visit)
+
+	// only show backlinks pane when the current note has backlinks
+	contentBox.SetFileChangedFunc(func(ref *nve.FileRef) {
+		backlinksBox.SetFile(ref)
+		if backlinksBox.HasBacklinks() {
+			contentRow.ResizeItem(backlinksBox, 0, 1)
+		} else {
+			contentRow.ResizeItem(backlinksBox, 0, 0)
+		}
+	})
+
+	backlinksBox.SetOpenFunc(func(ref *nve.FileRef) {
+		openNote(ref)
+		app.SetFocus(contentBox)
+	})
+
+	tagsBox.SetSelectFunc(func(tag string) {
+		searchBox.SetText("tag:" + tag + " ")
+		notes.Search(searchBox.GetText())
+		app.SetFocus(searchBox)
+	})
+
+	templateBox.SetPickedFunc(func(template string, picked bool) {
+		pages.RemovePage("templates")
+		if !picked {
+			app.SetFocus(searchBox)
+			return
+		}
+		searchBox.CreateNote(template, searchBox.GetText())
+		app.SetFocus(contentBox)
+	})
+
+	journalBox.SetPickedFunc(func(day time.Time, picked bool) {
+		pages.RemovePage("journal")
+		if picked {
+			openJournal(day)
+		} else if panes.Layout().Zen {
+			app.SetFocus(contentBox)
+		} else {
+			app.SetFocus(searchBox)
+		}
+	})
+
+	helpBox.SetClosedFunc(func() {
+		pages.RemovePage("help")
+		app.SetFocus(helpFocus)
+	})
+
+	paletteBox.SetDoneFunc(func() {
+		pages.RemovePage("palette")
+		app.SetFocus(paletteFocus)
+	})
+
+	replaceBox.SetDoneFunc(func(refs []*nve.FileRef) {
+		pages.RemovePage("replace")
+		// reload the current note before the editor is focused again
+		for _, ref := range refs {
+			if current := contentBox.CurrentFile(); current != nil && current.Filename == ref.Filename {
+				contentBox.SetFile(current)
+			}
+		}
+		app.SetFocus(replaceFocus)
+	})
+
+	promptBox.SetClosedFunc(func() {
+		pages.RemovePage("prompt")
+		app.SetFocus(promptFocus)
+	})
+
+	renameBox.SetDoneFunc(func(ref *nve.FileRef) {
+		pages.RemovePage("rename")
+		if ref != nil {
+			openNote(ref)
+		}
+		app.SetFocus(contentBox)
+	})
+
+	// saves are reported in the status bar
+	contentBox.
+		SetModifiedFunc(notes.MarkModified).
+		SetSaveFunc(notes.Save)
+
+	// text copied or cut goes to the system clipboard, which is pasted from
+	contentBox.
+		SetCopyFunc(func(text string) {
+			if err := clipboard.Copy(text); err != nil {
+				log.Printf("[ERROR] could not copy to clipboard: %v", err)
+			}
+		}).
+		SetPasteFunc(func() string {
+			text, err := clipboard.Paste()
+			if err != nil {
+				log.Printf("[ERROR] could not paste from clipboard: %v", err)
+			}
+			return text
+		})
+
+	notes.RegisterObservers(listBox, tagsBox, statusBar)
+	notes.SetDrawFunc(func(f func()) { app.QueueUpdateDraw(f) })
+	defer notes.SetDrawFunc(nil)
 	notes.Notify()
 
 	if err := notes.StartWatching(func(f func()) { app.QueueUpdateDraw(f) }); err != nil {
@@ -38,37 +298,608 @@ func main() {
 	}
 	defer notes.StopWatching()
 
-	// global input events
-	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyTab:
-			if searchBox.HasFocus() {
+	// showMessage displays a message until it is dismissed.
+	showMessage := func(text string) {
+		focus := app.GetFocus()
+		messageBox.SetText(tview.Escape(text)).
+			ClearButtons().
+			AddButtons([]string{"OK"}).
+			SetDoneFunc(func(int, string) {
+				pages.RemovePage("message")
+				app.SetFocus(focus)
+			})
+		pages.AddPage("message", nve.Overlay(messageBox), true, true)
+		app.SetFocus(messageBox)
+	}
+
+	// confirm asks before doing something that cannot be undone.
+	confirm := func(text, button string, confirmed func()) {
+		focus := app.GetFocus()
+		messageBox.SetText(tview.Escape(text)).
+			ClearButtons().
+			AddButtons([]string{button, "Cancel"}).
+			SetDoneFunc(func(index int, label string) {
+				pages.RemovePage("message")
+				app.SetFocus(focus)
+				if label == button {
+					confirmed()
+				}
+			})
+		pages.AddPage("message", nve.Overlay(messageBox), true, true)
+		app.SetFocus(messageBox)
+	}
+
+	// prompt asks for a line of text, keeping the prompt open while accept
+	// returns an error.
+	prompt := func(title string, accept func(text string) error) {
+		promptFocus = app.GetFocus()
+		promptBox.Show(title, "", accept)
+		pages.AddPage("prompt", nve.Modal(promptBox, 60, 3), true, true)
+		app.SetFocus(promptBox)
+	}
+
+	// selectedNotes returns the notes marked in the list, or else the
+	// current note.
+	selectedNotes := func() []*nve.FileRef {
+		if marked := listBox.Marked(); len(marked) > 0 {
+			return marked
+		}
+		if ref := contentBox.CurrentFile(); ref != nil {
+			return []*nve.FileRef{ref}
+		}
+		return nil
+	}
+
+	// describeNotes names a single note, or else counts the notes.
+	describeNotes := func(refs []*nve.FileRef) string {
+		if len(refs) == 1 {
+			return fmt.Sprintf("'%s'", notes.RelativeName(refs[0]))
+		}
+		return fmt.Sprintf("%d notes", len(refs))
+	}
+
+	// removedNotes forgets notes which were deleted or moved away, clearing
+	// the content box if it showed one of them.
+	removedNotes := func(refs []*nve.FileRef) {
+		for _, ref := range refs {
+			history.Forget(ref)
+			if current := contentBox.CurrentFile(); current != nil && current.Filename == ref.Filename {
+				contentBox.Clear()
+			}
+		}
+		listBox.ClearMarks()
+	}
+
+	// reloadNotes shows the changes made to the current note, if it is one of
+	// the notes.
+	reloadNotes := func(refs []*nve.FileRef) {
+		for _, ref := range refs {
+			if current := contentBox.CurrentFile(); current != nil && current.Filename == ref.Filename {
+				contentBox.SetFile(current)
+			}
+		}
+	}
+
+	// copyText copies text to the system clipboard.
+	copyText := func(text string) {
+		if err := clipboard.Copy(text); err != nil {
+			log.Printf("[ERROR] could not copy to clipboard: %v", err)
+			showMessage("Could not copy: " + err.Error())
+		}
+	}
+
+	commands.
+		Register(nve.ActionCommandPalette, func() bool {
+			paletteFocus = app.GetFocus()
+			paletteBox.ShowCommands(commands, nve.Keys)
+			pages.AddPage("palette", nve.Modal(paletteBox, nve.PaletteBoxWidth, nve.PaletteBoxHeight), true, true)
+			app.SetFocus(paletteBox)
+			return true
+		}).
+		Register(nve.ActionHelp, func() bool {
+			helpFocus = app.GetFocus()
+			helpBox.Show(nve.Keys)
+			pages.AddPage("help", nve.Modal(helpBox, nve.HelpBoxWidth, nve.HelpBoxHeight), true, true)
+			app.SetFocus(helpBox)
+			return true
+		}).
+		Register(nve.ActionNextPane, func() bool {
+			if panes.Layout().Zen {
+				return false
+			} else if searchBox.HasFocus() {
 				app.SetFocus(listBox)
 			} else if listBox.HasFocus() {
 				app.SetFocus(contentBox)
+			} else if contentBox.HasFocus() && backlinksBox.HasBacklinks() {
+				app.SetFocus(backlinksBox)
+			} else if (contentBox.HasFocus() || backlinksBox.HasFocus()) && showTags {
+				app.SetFocus(tagsBox)
 			} else {
-				break
+				return false
 			}
-			return &tcell.EventKey{}
-		case tcell.KeyEscape:
+			return true
+		}).
+		Register(nve.ActionFocusSearch, func() bool {
+			leaveZen()
 			app.SetFocus(searchBox)
 			searchBox.SetText("")
 			notes.Search("")
+			return true
+		}).
+		Register(nve.ActionCreateNote, func() bool {
+			// a 'template: title' prefix picks the template for the new note
+			template, title := notes.SplitTemplatePrefix(searchBox.GetText())
+			if title == "" {
+				leaveZen()
+				app.SetFocus(searchBox)
+				return true
+			}
+			if template == "" {
+				template = nve.DefaultTemplate
+			}
+			searchBox.CreateNote(template, title)
+			app.SetFocus(contentBox)
+			return true
+		}).
+		Register(nve.ActionNewFromTemplate, func() bool {
+			if !searchBox.HasFocus() || searchBox.GetText() == "" {
+				return false
+			}
+			templateBox.Show(notes.Templates())
+			pages.AddPage("templates", nve.Modal(templateBox, 40, 12), true, true)
+			app.SetFocus(templateBox)
+			return true
+		}).
+		Register(nve.ActionRename, func() bool {
+			if ref := contentBox.CurrentFile(); ref != nil && !searchBox.HasFocus() {
+				renameBox.Show(ref)
+				pages.AddPage("rename", nve.Modal(renameBox, 60, 3), true, true)
+				app.SetFocus(renameBox)
+			}
+			return true
+		}).
+		Register(nve.ActionDeleteNote, func() bool {
+			refs := selectedNotes()
+			if len(refs) == 0 {
+				return false
+			}
+			confirm(fmt.Sprintf("Delete %s?", describeNotes(refs)), "Delete", func() {
+				if err := notes.DeleteNotes(refs); err != nil {
+					log.Printf("[ERROR] could not delete %s: %v", describeNotes(refs), err)
+					return
+				}
+				removedNotes(refs)
+				leaveZen()
+				app.SetFocus(searchBox)
+				searchBox.SetText("")
+				notes.Search("")
+			})
+			return true
+		}).
+		Register(nve.ActionTogglePin, func() bool {
+			if listBox.HasFocus() {
+				listBox.TogglePin()
+			} else if refs := selectedNotes(); len(refs) > 0 {
+				if err := notes.TogglePins(refs); err != nil {
+					log.Printf("[ERROR] could not pin %s: %v", describeNotes(refs), err)
+				}
+			} else {
+				return false
+			}
+			return true
+		}).
+		Register(nve.ActionTrashNotes, func() bool {
+			refs := selectedNotes()
+			if len(refs) == 0 {
+				return false
+			}
+			if err := notes.TrashNotes(refs); err != nil {
+				log.Printf("[ERROR] could not trash %s: %v", describeNotes(refs), err)
+				showMessage("Could not move notes to the trash: " + err.Error())
+				return true
+			}
+			removedNotes(refs)
+			return true
+		}).
+		Register(nve.ActionMoveNotes, func() bool {
+			refs := selectedNotes()
+			if len(refs) == 0 {
+				return false
+			}
+			prompt("Move "+describeNotes(refs)+" to folder", func(folder string) error {
+				moved, err := notes.MoveNotes(refs, folder)
+				if err != nil {
+					return err
+				}
+				for i, ref := range refs {
+					history.Forget(ref)
+					if current := contentBox.CurrentFile(); current != nil && current.Filename == ref.Filename {
+						contentBox.SetFile(moved[i])
+					}
+				}
+				listBox.ClearMarks()
+				return nil
+			})
+			return true
+		}).
+		Register(nve.ActionAddTag, func() bool {
+			refs := selectedNotes()
+			if len(refs) == 0 {
+				return false
+			}
+			prompt("Add tag to "+describeNotes(refs), func(tag string) error {
+				if _, err := notes.TagNotes(refs, tag, true); err != nil {
+					return err
+				}
+				reloadNotes(refs)
+				listBox.ClearMarks()
+				return nil
+			})
+			return true
+		}).
+		Register(nve.ActionRemoveTag, func() bool {
+			refs := selectedNotes()
+			if len(refs) == 0 {
+				return false
+			}
+			prompt("Remove tag from "+describeNotes(refs), func(tag string) error {
+				if _, err := notes.TagNotes(refs, tag, false); err != nil {
+					return err
+				}
+				reloadNotes(refs)
+				listBox.ClearMarks()
+				return nil
+			})
+			return true
+		}).
+		Register(nve.ActionMergeNotes, func() bool {
+			refs := listBox.Marked()
+			if len(refs) < 2 {
+				showMessage("Mark at least two notes to merge them")
+				return true
+			}
+			confirm(fmt.Sprintf("Merge %d notes into '%s'?", len(refs), notes.RelativeName(refs[0])), "Merge", func() {
+				if err := contentBox.SaveNow(); err != nil {
+					log.Printf("[ERROR] could not save note: %v", err)
+				}
+				merged, err := notes.MergeNotes(refs)
+				if err != nil {
+					log.Printf("[ERROR] could not merge notes: %v", err)
+					showMessage("Could not merge notes: " + err.Error())
+					return
+				}
+				removedNotes(refs[1:])
+				openNote(merged)
+			})
+			return true
+		}).
+		Register(nve.ActionSplitNote, func() bool {
+			ref := contentBox.CurrentFile()
+			if ref == nil {
+				return false
+			}
+			if err := contentBox.SaveNow(); err != nil {
+				log.Printf("[ERROR] could not save note: %v", err)
+			}
+			if _, err := notes.SplitNote(ref, contentBox.Cursor()); err != nil {
+				log.Printf("[ERROR] could not split '%s': %v", ref.Filename, err)
+				showMessage("Could not split note: " + err.Error())
+				return true
+			}
+			contentBox.SetFile(ref)
+			return true
+		}).
+		Register(nve.ActionSplitHeadings, func() bool {
+			ref := contentBox.CurrentFile()
+			if ref == nil {
+				return false
+			}
+			if err := contentBox.SaveNow(); err != nil {
+				log.Printf("[ERROR] could not save note: %v", err)
+			}
+			created, err := notes.SplitNoteAtHeadings(ref)
+			if err != nil {
+				log.Printf("[ERROR] could not split '%s': %v", ref.Filename, err)
+				showMessage("Could not split note: " + err.Error())
+				return true
+			}
+			contentBox.SetFile(ref)
+			showMessage(fmt.Sprintf("Split into %d notes", len(created)))
+			return true
+		}).
+		Register(nve.ActionUndoChange, func() bool {
+			if err := contentBox.SaveNow(); err != nil {
+				log.Printf("[ERROR] could not save note: %v", err)
+			}
+			label, err := notes.UndoChange()
+			if err != nil {
+				log.Printf("[ERROR] could not undo: %v", err)
+				showMessage("Could not undo: " + err.Error())
+				return true
+			}
+			if label == "" {
+				showMessage("Nothing to undo")
+				return true
+			}
+			// the current note may have been changed, or created by the change
+			if current := contentBox.CurrentFile(); current != nil {
+				if _, err := os.Stat(current.Filename); err != nil {
+					history.Forget(current)
+					contentBox.Clear()
+				} else {
+					contentBox.SetFile(current)
+				}
+			}
+			return true
+		}).
+		Register(nve.ActionCopyNote, func() bool {
+			if contentBox.CurrentFile() == nil {
+				return false
+			}
+			copyText(contentBox.GetText())
+			return true
+		}).
+		Register(nve.ActionCopyPath, func() bool {
+			ref := contentBox.CurrentFile()
+			if ref == nil {
+				return false
+			}
+			path, err := filepath.Abs(ref.Filename)
+			if err != nil {
+				log.Printf("[ERROR] could not find path of '%s': %v", ref.Filename, err)
+				path = ref.Filename
+			}
+			copyText(path)
+			return true
+		}).
+		Register(nve.ActionCopyLink, func() bool {
+			ref := contentBox.CurrentFile()
+			if ref == nil {
+				return false
+			}
+			copyText("[[" + ref.DisplayName() + "]]")
+			return true
+		}).
+		Register(nve.ActionUnmarkNotes, func() bool {
+			if len(listBox.Marked()) == 0 {
+				return false
+			}
+			listBox.ClearMarks()
+			return true
+		}).
+		Register(nve.ActionToggleTags, func() bool {
+			if panes.Layout().Zen {
+				return false
+			}
+			if showTags = !showTags; showTags {
+				app.SetFocus(tagsBox)
+			} else if tagsBox.HasFocus() {
+				app.SetFocus(searchBox)
+			}
+			showTagsPane()
+			return true
+		}).
+		Register(nve.ActionFind, func() bool {
+			if contentBox.CurrentFile() == nil {
+				return false
+			}
+			app.SetFocus(contentBox)
+			contentBox.StartFind()
+			return true
+		}).
+		Register(nve.ActionTogglePreview, func() bool {
+			if contentBox.CurrentFile() == nil {
+				return false
+			}
+			contentBox.TogglePreview()
+			return true
+		}).
+		Register(nve.ActionToggleLayout, func() bool {
+			layout := panes.Layout()
+			layout.Mode = layout.Mode.Next()
+			setLayout(layout)
+			return true
+		}).
+		Register(nve.ActionGrowList, func() bool {
+			setLayout(panes.Layout().Resize(nve.ListSizeStep))
+			return true
+		}).
+		Register(nve.ActionShrinkList, func() bool {
+			setLayout(panes.Layout().Resize(-nve.ListSizeStep))
+			return true
+		}).
+		Register(nve.ActionZenMode, func() bool {
+			layout := panes.Layout()
+			if layout.Zen = !layout.Zen; layout.Zen && contentBox.CurrentFile() == nil {
+				// there is no note to focus on
+				return false
+			}
+			setLayout(layout)
+			if layout.Zen {
+				app.SetFocus(contentBox)
+			}
+			return true
+		}).
+		Register(nve.ActionToggleFolders, func() bool {
+			notes.ShowFolders = !notes.ShowFolders
+			notes.Search(notes.LastQuery)
+			return true
+		}).
+		Register(nve.ActionScopeFolder, func() bool {
+			// scope searches to the folder of the current note, or back to all notes
+			if notes.Scope() != "" {
+				notes.SetScope("")
+				searchBox.SetTitle("Search Box")
+			} else if ref := contentBox.CurrentFile(); ref != nil && notes.Folder(ref) != "" {
+				notes.SetScope(notes.Folder(ref))
+				searchBox.SetTitle("Search Box (in: " + tview.Escape(notes.Scope()) + ")")
+			}
+			notes.Search(searchBox.GetText())
+			return true
+		}).
+		Register(nve.ActionChangeSort, func() bool {
+			if err := notes.SetSortOrder(notes.SortOrder().Next()); err != nil {
+				log.Printf("[ERROR] could not sort notes: %v", err)
+			}
+			if notes.SortOrder() == nve.SortByModified {
+				listBox.SetTitle("List Box")
+			} else {
+				listBox.SetTitle("List Box (by " + notes.SortOrder().String() + ")")
+			}
+			return true
+		}).
+		Register(nve.ActionHistory, func() bool {
+			var items []nve.PaletteItem
+			for _, ref := range history.Notes() {
+				ref := ref
+				if _, err := os.Stat(ref.Filename); err != nil {
+					continue
+				}
+				items = append(items, nve.PaletteItem{
+					Label: notes.RelativeName(ref),
+					Run: func() {
+						openNote(ref)
+						app.SetFocus(contentBox)
+					},
+				})
+			}
+			paletteFocus = app.GetFocus()
+			paletteBox.Show("Recently opened", items)
+			pages.AddPage("palette", nve.Modal(paletteBox, nve.PaletteBoxWidth, nve.PaletteBoxHeight), true, true)
+			app.SetFocus(paletteBox)
+			return true
+		}).
+		Register(nve.ActionOpenInEditor, func() bool {
+			ref := contentBox.CurrentFile()
+			if ref == nil {
+				return false
+			}
+			// edits still to be saved would be written over the editor's
+			if err := contentBox.SaveNow(); err != nil {
+				log.Printf("[ERROR] could not save note: %v", err)
+				showMessage("Could not save note: " + err.Error())
+				return true
+			}
+			app.Suspend(func() {
+				if err := nve.EditorCommand(ref.Filename).Run(); err != nil {
+					log.Printf("[ERROR] could not run editor: %v", err)
+				}
+			})
+			if _, err := notes.Refresh(); err != nil {
+				log.Printf("[ERROR] could not refresh notes: %v", err)
+			}
+			notes.Search(notes.LastQuery)
+			contentBox.SetFile(ref)
+			return true
+		}).
+		Register(nve.ActionExport, func() bool {
+			filename := nve.ExportFilename(time.Now())
+			f, err := os.Create(filename)
+			if err != nil {
+				log.Printf("[ERROR] could not export notes: %v", err)
+				showMessage("Could not export notes: " + err.Error())
+				return true
+			}
+			var count int
+			if marked := listBox.Marked(); len(marked) > 0 {
+				count, err = notes.ExportNotes(f, marked)
+			} else {
+				count, err = notes.Export(f)
+			}
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				// a partial archive is of no use
+				os.Remove(filename)
+				log.Printf("[ERROR] could not export notes: %v", err)
+				showMessage("Could not export notes: " + err.Error())
+				return true
+			}
+			showMessage(fmt.Sprintf("Exported %d notes to %s", count, filename))
+			return true
+		}).
+		Register(nve.ActionReindex, func() bool {
+			if err := notes.Reindex(); err != nil {
+				log.Printf("[ERROR] could not rebuild index: %v", err)
+				showMessage("Could not rebuild the search index: " + err.Error())
+			} else {
+				showMessage("Rebuilt the search index")
+			}
+			return true
+		}).
+		Register(nve.ActionReplaceNotes, func() bool {
+			replaceFocus = app.GetFocus()
+			replaceBox.Show("")
+			pages.AddPage("replace", nve.Modal(replaceBox, nve.ReplaceBoxWidth, nve.ReplaceBoxHeight), true, true)
+			app.SetFocus(replaceBox)
+			return true
+		}).
+		Register(nve.ActionJournalToday, func() bool {
+			openJournal(time.Now())
+			return true
+		}).
+		Register(nve.ActionJournalCalendar, func() bool {
+			days, err := notes.JournalDays()
+			if err != nil {
+				log.Printf("[ERROR] could not list journal: %v", err)
+			}
+			journalBox.Show(time.Now(), days)
+			pages.AddPage("journal", nve.Modal(journalBox, nve.JournalBoxWidth, nve.JournalBoxHeight), true, true)
+			app.SetFocus(journalBox)
+			return true
+		}).
+		Register(nve.ActionJournalPrevious, func() bool {
+			return openAdjacentJournal(-1)
+		}).
+		Register(nve.ActionJournalNext, func() bool {
+			return openAdjacentJournal(1)
+		})
+
+	// global input events
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// overlays handle their own input
+		if front, _ := pages.GetFrontPage(); front != "main" || contentBox.IsCompleting() || searchBox.IsCompleting() {
+			return event
+		}
+
+		// keys bound in the content box, such as Esc in insert mode, come first
+		if contentBox.HasFocus() && contentBox.HandlesKey(event) {
+			return event
+		}
+
+		action, ok := nve.Keys.Lookup(nve.ContextGlobal, event)
+		if !ok && listBox.HasFocus() {
+			// the list has no text input, so it has its own keys for help and pinning
+			action, _ = nve.Keys.Lookup(nve.ContextList, event)
+		}
+
+		if commands.Run(action) {
 			return &tcell.EventKey{}
 		}
 
 		return event
 	})
 
-	flex := tview.NewFlex().
-		AddItem(
-			tview.NewFlex().SetDirection(tview.FlexRow).
-				AddItem(searchBox, 3, 0, true).
-				AddItem(listBox, 0, 1, false).
-				AddItem(contentBox, 0, 3, false), 0, 2, true,
-		)
+	contentRow.
+		AddItem(contentBox, 0, 4, false).
+		AddItem(backlinksBox, 0, 0, false)
+
+	mainRow.
+		AddItem(tagsBox, 0, 0, false).
+		AddItem(panes, 0, 2, true)
+
+	pages.AddPage("main", tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(mainRow, 0, 1, true).
+		AddItem(statusBar, 1, 0, false), true, true)
+
+	app.SetRoot(pages, true).SetFocus(mainRow)
+
+	if today {
+		openJournal(time.Now())
+	}
 
-	if err := app.SetRoot(flex, true).SetFocus(flex).EnableMouse(true).Run(); err != nil {
+	if err := app.EnableMouse(true).Run(); err != nil {
 		panic(err)
 	}
 }
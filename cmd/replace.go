@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ivan3bx/nve"
+)
+
+const replaceUsage = `usage: nve replace [flags] <pattern> <replacement>
+
+Replaces text in all notes of the current directory, printing each line
+changed. Front matter is left as is.
+
+Flags:
+`
+
+// replaceCommand replaces text in all notes, returning the exit status.
+func replaceCommand(args []string) int {
+	var (
+		flags     = flag.NewFlagSet("replace", flag.ContinueOnError)
+		dryRun    = flags.Bool("dry-run", false, "print the lines that would change, without changing them")
+		regex     = flags.Bool("regex", false, "find a regular expression, expanding $1 in the replacement")
+		matchCase = flags.Bool("case", false, "match case")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), replaceUsage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	// errors are reported on stderr, without the debug log of the UI
+	log.SetOutput(io.Discard)
+
+	config, err := nve.LoadConfig("./")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nve: could not load config, using defaults: %v\n", err)
+	}
+
+	notes := nve.NewNotes(nve.NotesConfig{
+		Filepath:  "./",
+		Extension: config.Extension,
+	})
+
+	options := nve.FindOptions{Regex: *regex, CaseSensitive: *matchCase}
+
+	changes, err := notes.PreviewReplace(flags.Arg(0), flags.Arg(1), options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nve: %v\n", err)
+		return 1
+	}
+
+	printChanges(os.Stdout, notes, changes)
+
+	if *dryRun {
+		fmt.Println("dry run: no notes were changed")
+		return 0
+	} else if len(changes) == 0 {
+		return 0
+	}
+
+	if _, err := notes.ApplyReplace(changes); err != nil {
+		fmt.Fprintf(os.Stderr, "nve: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// printChanges prints the lines of each note before and after replacing, as
+// a diff would, followed by how many lines and notes change.
+func printChanges(w io.Writer, notes *nve.Notes, changes []*nve.NoteChange) {
+	var lines int
+
+	for _, change := range changes {
+		fmt.Fprintln(w, notes.RelativePath(change.Ref))
+
+		for _, hunk := range change.Hunks {
+			prefix := fmt.Sprintf("%5d ", hunk.Line)
+			indent := strings.Repeat(" ", len(prefix))
+
+			fmt.Fprintln(w, prefix+"- "+strings.ReplaceAll(hunk.Before, "\n", "\n"+indent+"- "))
+			fmt.Fprintln(w, indent+"+ "+strings.ReplaceAll(hunk.After, "\n", "\n"+indent+"+ "))
+		}
+
+		lines += len(change.Hunks)
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%s in %s\n", count(lines, "line"), count(len(changes), "note"))
+}
+
+// count returns a count followed by a noun, plural unless the count is 1.
+func count(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
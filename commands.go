@@ -0,0 +1,41 @@
+package nve
+
+// Commands is the registry of actions the app can run from anywhere, either
+// by their keys or from the command palette.
+type Commands struct {
+	actions  []Action
+	handlers map[Action]func() bool
+}
+
+func NewCommands() *Commands {
+	return &Commands{
+		handlers: map[Action]func() bool{},
+	}
+}
+
+// Register sets the handler of an action. Handlers return false if the
+// action does not apply, e.g. renaming while no note is open, so that its
+// key is handled as usual.
+func (c *Commands) Register(action Action, handler func() bool) *Commands {
+	if _, ok := c.handlers[action]; !ok {
+		c.actions = append(c.actions, action)
+	}
+
+	c.handlers[action] = handler
+	return c
+}
+
+// Run runs the handler of an action. Returns false if the action has no
+// handler or does not apply.
+func (c *Commands) Run(action Action) bool {
+	if handler, ok := c.handlers[action]; ok {
+		return handler()
+	}
+
+	return false
+}
+
+// Actions returns all registered actions, in the order they were registered.
+func (c *Commands) Actions() []Action {
+	return c.actions
+}
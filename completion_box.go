@@ -0,0 +1,88 @@
+package nve
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/tview"
+)
+
+const maxCompletionItems = 8
+
+// CompletionBox is a small popup list drawn on top of another primitive,
+// anchored at a screen position (typically the cursor).
+type CompletionBox struct {
+	*tview.List
+	items []string
+}
+
+func NewCompletionBox() *CompletionBox {
+	box := CompletionBox{
+		List: tview.NewList(),
+	}
+
+	box.ShowSecondaryText(false).
+		SetWrapAround(true).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(Colors.SelectedStyle())
+
+	box.SetBorder(true).
+		SetBorderPadding(0, 0, 1, 1).
+		SetBackgroundColor(Colors.Background)
+
+	return &box
+}
+
+// SetItems replaces the completion candidates and selects the first one.
+func (c *CompletionBox) SetItems(items []string) {
+	if len(items) > maxCompletionItems {
+		items = items[:maxCompletionItems]
+	}
+
+	c.items = items
+	c.Clear()
+
+	for _, item := range items {
+		c.AddItem(tview.Escape(item), "", 0, nil)
+	}
+}
+
+// Selected returns the currently highlighted candidate.
+func (c *CompletionBox) Selected() (string, bool) {
+	if len(c.items) == 0 {
+		return "", false
+	}
+
+	return c.items[c.GetCurrentItem()], true
+}
+
+// DrawAt draws the popup just below the anchor (x, y), or above it when there
+// is not enough room, keeping it within the given bounds.
+func (c *CompletionBox) DrawAt(screen tcell.Screen, x, y, boundsX, boundsY, boundsWidth, boundsHeight int) {
+	width := 0
+
+	for _, item := range c.items {
+		if w := runewidth.StringWidth(item); w > width {
+			width = w
+		}
+	}
+
+	width += 4 // border and padding
+	height := len(c.items) + 2
+
+	if width > boundsWidth {
+		width = boundsWidth
+	}
+
+	if x+width > boundsX+boundsWidth {
+		x = boundsX + boundsWidth - width
+	}
+
+	top := y + 1
+
+	if top+height > boundsY+boundsHeight && y-height >= boundsY {
+		top = y - height
+	}
+
+	c.SetRect(x, top, width, height)
+	c.Draw(screen)
+}
@@ -0,0 +1,260 @@
+package nve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the name of the settings file within the per-vault
+// config directory.
+const configFileName = "config.yaml"
+
+// DefaultExtension is given to new notes unless configured otherwise.
+const DefaultExtension = ".md"
+
+// DefaultSnippetLength is the number of words of the snippets of search
+// results unless configured otherwise. FTS5 allows up to maxSnippetLength.
+const (
+	DefaultSnippetLength = 10
+	maxSnippetLength     = 64
+)
+
+// Config holds the user settings read from '.nve/config.yaml'. Settings
+// missing from the file keep their default values.
+type Config struct {
+	// Extension is given to new notes whose name has no supported extension.
+	Extension string `yaml:"extension"`
+
+	// SnippetLength is the number of words of the snippets of search results,
+	// and of the passages shown when a result is expanded.
+	SnippetLength int `yaml:"snippet-length"`
+
+	// Keys selects the keybindings, see Config.Keymap.
+	Keys KeysConfig `yaml:"keys"`
+
+	// Theme names the colors of the UI, either a built-in theme or one of
+	// Themes, see Config.Colors.
+	Theme  string                 `yaml:"theme"`
+	Themes map[string]ThemeConfig `yaml:"themes"`
+}
+
+// KeysConfig selects a keymap preset and overrides keys of its actions:
+//
+//	keys:
+//	  preset: vi
+//	  bindings:
+//	    toggle-tags: Ctrl-G
+//	    normal.delete-line: [d d, Ctrl-D]
+//
+// An action without a context is rebound wherever the preset binds it.
+type KeysConfig struct {
+	Preset   string                 `yaml:"preset"`
+	Bindings map[string]interface{} `yaml:"bindings"`
+}
+
+// ThemeConfig defines a theme by the colors it changes from a built-in
+// theme (by default the dark one, or the one of the same name):
+//
+//	theme: solarized
+//	themes:
+//	  solarized:
+//	    base: light
+//	    background: "#fdf6e3"
+//	    pane-title: orange
+type ThemeConfig struct {
+	Base   string            `yaml:"base"`
+	Colors map[string]string `yaml:",inline"`
+}
+
+// DefaultConfig returns the settings used when there is no config file.
+func DefaultConfig() Config {
+	return Config{
+		Extension:     DefaultExtension,
+		SnippetLength: DefaultSnippetLength,
+	}
+}
+
+// LoadConfig reads the config file of the notes in dir. Returns the default
+// settings if there is no config file.
+func LoadConfig(dir string) (Config, error) {
+	config := DefaultConfig()
+
+	bytes, err := os.ReadFile(filepath.Join(dir, configDirName, configFileName))
+
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, errors.WithStack(err)
+	}
+
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return DefaultConfig(), errors.Wrap(err, "invalid config")
+	}
+
+	if !strings.HasPrefix(config.Extension, ".") {
+		config.Extension = "." + config.Extension
+	}
+
+	if !SUPPORTED_FILETYPES[config.Extension] {
+		return DefaultConfig(), errors.Errorf("unsupported extension: %s", config.Extension)
+	}
+
+	if config.SnippetLength < 1 || config.SnippetLength > maxSnippetLength {
+		return DefaultConfig(), errors.Errorf("snippet length must be between 1 and %d words: %d", maxSnippetLength, config.SnippetLength)
+	}
+
+	return config, nil
+}
+
+// Keymap returns the keymap of the configured preset, with its bindings
+// overridden. Returns the default keymap if the keys are not valid.
+func (c Config) Keymap() (*Keymap, error) {
+	km, err := NewKeymap(c.Keys.Preset)
+	if err != nil {
+		return DefaultKeymap(), err
+	}
+
+	names := make([]string, 0, len(c.Keys.Bindings))
+	for name := range c.Keys.Bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := km.override(name, c.Keys.Bindings[name]); err != nil {
+			return DefaultKeymap(), errors.Wrapf(err, "invalid binding '%s'", name)
+		}
+	}
+
+	return km, nil
+}
+
+// Colors returns the configured theme, or a theme without colors if the
+// NO_COLOR environment variable is set. Returns the default theme if the
+// theme is not valid.
+func (c Config) Colors() (*Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return NoColorTheme(), nil
+	}
+
+	tc, ok := c.Themes[c.Theme]
+	if !ok {
+		theme, err := NewTheme(c.Theme)
+		if err != nil {
+			return DarkTheme(), err
+		}
+		return theme, nil
+	}
+
+	base := tc.Base
+	if base == "" {
+		if _, err := NewTheme(c.Theme); err == nil {
+			base = c.Theme
+		}
+	}
+
+	theme, err := NewTheme(base)
+	if err != nil {
+		return DarkTheme(), errors.Wrapf(err, "invalid theme '%s'", c.Theme)
+	}
+
+	names := make([]string, 0, len(tc.Colors))
+	for name := range tc.Colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := theme.SetColor(name, tc.Colors[name]); err != nil {
+			return DarkTheme(), errors.Wrapf(err, "invalid theme '%s'", c.Theme)
+		}
+	}
+
+	return theme, nil
+}
+
+// override binds an action, named either 'action' or 'context.action', to
+// keys given as a single sequence or a list of them. An empty list unbinds
+// the action.
+func (km *Keymap) override(name string, value interface{}) error {
+	contextName, actionName, qualified := strings.Cut(name, ".")
+	if !qualified {
+		actionName = contextName
+	}
+
+	action := Action(actionName)
+	if _, ok := actionDescriptions[action]; !ok {
+		return errors.Errorf("unknown action: %s", actionName)
+	}
+
+	var keys []KeySequence
+
+	for _, text := range keyList(value) {
+		seq, err := ParseKeySequence(text)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, seq)
+	}
+
+	var contexts []KeyContext
+
+	for _, context := range KeyContexts {
+		if qualified && context == KeyContext(contextName) {
+			contexts = append(contexts, context)
+		}
+
+		if !qualified {
+			for _, b := range km.Bindings(context) {
+				if b.Action == action {
+					contexts = append(contexts, context)
+				}
+			}
+		}
+	}
+
+	switch {
+	case qualified && len(contexts) == 0:
+		return errors.Errorf("unknown context: %s", contextName)
+	case len(contexts) == 0:
+		return errors.Errorf("%s is not bound by default, give its context as in 'content.%s'", action, action)
+	}
+
+	// keys given to an action are taken from any other action
+	for _, context := range contexts {
+		for _, seq := range keys {
+			km.Unbind(context, seq)
+		}
+		km.Bind(context, action, keys...)
+	}
+
+	return nil
+}
+
+// keyList reads the keys of a binding, given either as a list or as a
+// single string. Unlike stringList, commas are not separators since they
+// can be keys.
+func keyList(v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var res []string
+		for _, item := range v {
+			res = append(res, fmt.Sprint(item))
+		}
+		return res
+	default:
+		// keys like '0' are read as numbers
+		if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, configDirName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, configFileName), []byte(content), 0644))
+
+	return dir
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("defaults without config file", func(t *testing.T) {
+		config, err := LoadConfig(t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, DefaultConfig(), config)
+	})
+
+	t.Run("reads extension", func(t *testing.T) {
+		config, err := LoadConfig(writeConfig(t, "extension: txt\n"))
+		require.NoError(t, err)
+		assert.Equal(t, ".txt", config.Extension)
+	})
+
+	t.Run("rejects unsupported extension", func(t *testing.T) {
+		config, err := LoadConfig(writeConfig(t, "extension: .docx\n"))
+		assert.Error(t, err)
+		assert.Equal(t, DefaultExtension, config.Extension)
+	})
+
+	t.Run("reads snippet length", func(t *testing.T) {
+		config, err := LoadConfig(writeConfig(t, "snippet-length: 24\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 24, config.SnippetLength)
+	})
+
+	t.Run("rejects snippet length beyond FTS5 limit", func(t *testing.T) {
+		config, err := LoadConfig(writeConfig(t, "snippet-length: 65\n"))
+		assert.Error(t, err)
+		assert.Equal(t, DefaultSnippetLength, config.SnippetLength)
+	})
+
+	t.Run("rejects malformed config", func(t *testing.T) {
+		_, err := LoadConfig(writeConfig(t, "extension: [\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigKeymap(t *testing.T) {
+	load := func(t *testing.T, content string) (*Keymap, error) {
+		config, err := LoadConfig(writeConfig(t, content))
+		require.NoError(t, err)
+		return config.Keymap()
+	}
+
+	lookup := func(km *Keymap, context KeyContext, keys string) Action {
+		action, _, _ := km.LookupSequence(context, mustSeq(keys))
+		return action
+	}
+
+	t.Run("default keys", func(t *testing.T) {
+		km, err := DefaultConfig().Keymap()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultKeymap(), km)
+	})
+
+	t.Run("preset", func(t *testing.T) {
+		km, err := load(t, "keys:\n  preset: vi\n")
+		require.NoError(t, err)
+		assert.True(t, km.IsModal())
+	})
+
+	t.Run("rebinds action wherever it is bound", func(t *testing.T) {
+		km, err := load(t, "keys:\n  bindings:\n    select-next: Ctrl-J\n")
+		require.NoError(t, err)
+		assert.Equal(t, ActionSelectNext, lookup(km, ContextSearch, "Ctrl-J"))
+		assert.Equal(t, ActionSelectNext, lookup(km, ContextList, "Ctrl-J"))
+		assert.Empty(t, lookup(km, ContextList, "Down"))
+	})
+
+	t.Run("rebinds action within context", func(t *testing.T) {
+		km, err := load(t, "keys:\n  preset: vi\n  bindings:\n    normal.delete-line: [d d, X]\n    normal.line-start: 0\n")
+		require.NoError(t, err)
+		assert.Equal(t, ActionDeleteLine, lookup(km, ContextNormal, "X"))
+		assert.Equal(t, ActionDeleteLine, lookup(km, ContextNormal, "d d"))
+		assert.Equal(t, ActionLineStart, lookup(km, ContextNormal, "0"))
+	})
+
+	t.Run("takes keys from other actions", func(t *testing.T) {
+		km, err := load(t, "keys:\n  bindings:\n    content.follow-link: Ctrl-N\n")
+		require.NoError(t, err)
+		assert.Equal(t, ActionFollowLink, lookup(km, ContextContent, "Ctrl-N"))
+		for _, binding := range km.Bindings(ContextContent) {
+			assert.NotEqual(t, ActionCursorDown, binding.Action)
+		}
+	})
+
+	t.Run("binds unbound action within context", func(t *testing.T) {
+		km, err := load(t, "keys:\n  bindings:\n    global.delete-note: F9\n")
+		require.NoError(t, err)
+		assert.Equal(t, ActionDeleteNote, lookup(km, ContextGlobal, "F9"))
+	})
+
+	t.Run("unbinds action", func(t *testing.T) {
+		km, err := load(t, "keys:\n  bindings:\n    toggle-tags: []\n")
+		require.NoError(t, err)
+		assert.Empty(t, lookup(km, ContextGlobal, "Ctrl-T"))
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		for _, content := range []string{
+			"keys:\n  preset: nano\n",
+			"keys:\n  bindings:\n    launch-rocket: Ctrl-L\n",
+			"keys:\n  bindings:\n    sidebar.toggle-tags: Ctrl-L\n",
+			"keys:\n  bindings:\n    toggle-tags: Hyper-L\n",
+			"keys:\n  bindings:\n    kill-line: Ctrl-L\n",
+		} {
+			km, err := load(t, content)
+			assert.Error(t, err, content)
+			assert.Equal(t, DefaultKeymap(), km)
+		}
+	})
+}
+
+func TestConfigColors(t *testing.T) {
+	load := func(t *testing.T, content string) (*Theme, error) {
+		config, err := LoadConfig(writeConfig(t, content))
+		require.NoError(t, err)
+		return config.Colors()
+	}
+
+	t.Setenv("NO_COLOR", "")
+
+	t.Run("default theme", func(t *testing.T) {
+		theme, err := DefaultConfig().Colors()
+		require.NoError(t, err)
+		assert.Equal(t, DarkTheme(), theme)
+	})
+
+	t.Run("built-in theme", func(t *testing.T) {
+		theme, err := load(t, "theme: light\n")
+		require.NoError(t, err)
+		assert.Equal(t, LightTheme(), theme)
+	})
+
+	t.Run("user theme", func(t *testing.T) {
+		theme, err := load(t, "theme: solarized\nthemes:\n  solarized:\n    base: light\n    background: '#fdf6e3'\n")
+		require.NoError(t, err)
+
+		expected := LightTheme()
+		expected.Background = tcell.NewHexColor(0xfdf6e3)
+		assert.Equal(t, expected, theme)
+	})
+
+	t.Run("changes built-in theme", func(t *testing.T) {
+		theme, err := load(t, "theme: high-contrast\nthemes:\n  high-contrast:\n    title: red\n")
+		require.NoError(t, err)
+
+		expected := HighContrastTheme()
+		expected.Title = tcell.ColorRed
+		assert.Equal(t, expected, theme)
+	})
+
+	t.Run("no color", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+
+		theme, err := load(t, "theme: light\n")
+		require.NoError(t, err)
+		assert.Equal(t, NoColorTheme(), theme)
+	})
+
+	t.Run("invalid themes", func(t *testing.T) {
+		for _, content := range []string{
+			"theme: solarized\n",
+			"theme: mine\nthemes:\n  mine:\n    base: solarized\n",
+			"theme: mine\nthemes:\n  mine:\n    title: blurple\n",
+			"theme: mine\nthemes:\n  mine:\n    sidebar: red\n",
+		} {
+			theme, err := load(t, content)
+			assert.Error(t, err, content)
+			assert.Equal(t, DarkTheme(), theme)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package nve
 import (
 	"log"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -12,29 +13,73 @@ import (
 	"github.com/rivo/tview"
 )
 
-const (
-	HighlightBackground = tcell.ColorYellow
-	HighlightForeground = tcell.ColorBlack
-)
-
 type ContentBox struct {
 	*tview.TextArea
 	debounce       func(func())
 	currentFile    *FileRef
 	pendingRefresh bool
 	searchQuery    string
+	matchPos       int
+	linkFunc       func(name string)
+	fileFunc       func(f *FileRef)
+	openFunc       func(f *FileRef)
+	modifiedFunc   func(f *FileRef)
+	saveFunc       func(f *FileRef, content string) error
+	copyFunc       func(text string)
+	pasteFunc      func() string
+	completeFunc   func(query string) []string
+	completer      *CompletionBox
+	completing     bool
+
+	// whether the current file has edits not saved yet, how many edits were
+	// made, and the save of the last of them still to run, guarded as saves
+	// finish in the background; saving allows one save at a time
+	saveLock    sync.Mutex
+	modified    bool
+	edits       int
+	pendingSave func() error
+	saving      sync.Mutex
+
+	// the find bar while finding, which match of it is selected, and where
+	// finding started
+	finder     *FindBar
+	finding    bool
+	findIndex  int
+	findOrigin int
+
+	// the note rendered from Markdown, shown instead of its text while
+	// previewing, along with the text and width it was rendered for
+	preview      *tview.TextView
+	previewing   bool
+	previewText  string
+	previewWidth int
+
+	// editing state of the keymap: the mode of a modal keymap, keys pressed
+	// so far of a sequence, where selecting started, and the text last
+	// copied or cut
+	mode      KeyContext
+	pending   []Key
+	selecting bool
+	mark      int
+	register  string
 }
 
 func NewContentBox() *ContentBox {
 	textArea := ContentBox{
-		TextArea: tview.NewTextArea(),
-		debounce: debounce.New(300 * time.Millisecond),
+		TextArea:  tview.NewTextArea(),
+		debounce:  debounce.New(300 * time.Millisecond),
+		completer: NewCompletionBox(),
+		finder:    NewFindBar(),
+		preview:   tview.NewTextView(),
+		saveFunc: func(f *FileRef, content string) error {
+			return SaveContent(f.Filename, content)
+		},
 	}
 
 	textArea.SetBorder(true).
 		SetTitle("Content").
-		SetTitleColor(tcell.ColorDarkOrange).
-		SetBorderStyle(tcell.StyleDefault.Dim(true)).
+		SetTitleColor(Colors.PaneTitle).
+		SetBorderStyle(Colors.BorderStyle()).
 		SetBorderPadding(1, 0, 1, 1).
 		SetTitleAlign(tview.AlignLeft)
 
@@ -42,23 +87,238 @@ func NewContentBox() *ContentBox {
 		// ignore edits if there is no current file
 		if textArea.currentFile == nil {
 			textArea.Blur()
+		} else if textArea.openFunc != nil {
+			textArea.openFunc(textArea.currentFile)
 		}
 	})
 
 	textArea.SetBlurFunc(func() {
+		textArea.completing = false
+		textArea.finding = false
+		textArea.pending = nil
+		// commands run elsewhere may change the file
+		textArea.saveEdits()
 		textArea.flushRefresh()
 	})
+
+	textArea.SetClipboard(textArea.copy, textArea.clipboard)
+
+	textArea.preview.SetDynamicColors(true).
+		SetWrap(false).
+		SetTextColor(Colors.Text).
+		SetBackgroundColor(Colors.Background)
+
+	textArea.setMode("")
 	return &textArea
 }
 
 func (b *ContentBox) Clear() {
+	b.saveEdits()
 	b.currentFile = nil
+	b.setModified(false)
+	b.matchPos = 0
 	b.SetText("", true)
+	b.preview.ScrollToBeginning()
+	b.resetEditing()
+	b.fileChanged()
 }
 
 func (b *ContentBox) SetFile(f *FileRef) {
+	// the file read again replaces any edits still to be saved
+	if b.currentFile != nil && b.currentFile.Filename == f.Filename {
+		b.discardEdits()
+	} else {
+		b.saveEdits()
+	}
+
 	b.currentFile = f
+	b.setModified(false)
+	b.matchPos = 0
 	b.SetText(GetContent(f.Filename), false)
+	b.preview.ScrollToBeginning()
+	b.resetEditing()
+	b.fileChanged()
+}
+
+// SetResult displays a note found by a search, with the cursor on the first
+// match in its text, scrolled into view.
+func (b *ContentBox) SetResult(r *SearchResult) {
+	b.SetFile(r.FileRef)
+
+	if r.MatchOffset <= 0 {
+		return
+	}
+
+	// offsets of matches are counted after any front matter
+	text := b.GetText()
+	_, body, _ := splitFrontMatter(text)
+
+	// the cursor is placed once the text is laid out, when next drawn
+	if pos := len(text) - len(body) + r.MatchOffset; pos <= len(text) {
+		b.matchPos = pos
+	}
+}
+
+// showMatch places the cursor on the match of a search result, keeping a few
+// lines above it in view.
+func (b *ContentBox) showMatch(screen tcell.Screen) {
+	// TextArea.Select only finds rows already laid out, and drawing lays
+	// out rows as far as the offset. No row is shorter than a byte, so
+	// scrolling to the match's offset lays out all rows up to it.
+	b.SetOffset(b.matchPos, 0)
+	b.TextArea.Draw(screen)
+
+	b.Select(b.matchPos, b.matchPos)
+	b.matchPos = 0
+
+	row, _, _, _ := b.GetCursor()
+	_, _, _, height := b.GetInnerRect()
+
+	if row < height {
+		b.SetOffset(0, 0)
+	} else {
+		b.SetOffset(row-height/3, 0)
+	}
+}
+
+// CurrentFile returns the file being displayed, or nil if there is none.
+func (b *ContentBox) CurrentFile() *FileRef {
+	return b.currentFile
+}
+
+// SetLinkFunc sets a handler called with the target of a [[link]] when the
+// user follows the link under the cursor.
+func (b *ContentBox) SetLinkFunc(handler func(name string)) *ContentBox {
+	b.linkFunc = handler
+	return b
+}
+
+// SetFileChangedFunc sets a handler called whenever a different file (or no
+// file) is loaded into the content box.
+func (b *ContentBox) SetFileChangedFunc(handler func(f *FileRef)) *ContentBox {
+	b.fileFunc = handler
+	return b
+}
+
+// SetOpenedFunc sets a handler called with the current file whenever the
+// content box is focused to edit it.
+func (b *ContentBox) SetOpenedFunc(handler func(f *FileRef)) *ContentBox {
+	b.openFunc = handler
+	return b
+}
+
+// SetModifiedFunc sets a handler called as soon as the current file is
+// edited, before its changes are saved.
+func (b *ContentBox) SetModifiedFunc(handler func(f *FileRef)) *ContentBox {
+	b.modifiedFunc = handler
+	return b
+}
+
+// SetSaveFunc sets a handler writing the changes of a file once editing
+// pauses, replacing writing the file directly. The handler is called from a
+// background goroutine, or from the event loop when saving at once.
+func (b *ContentBox) SetSaveFunc(handler func(f *FileRef, content string) error) *ContentBox {
+	b.saveFunc = handler
+	return b
+}
+
+// SetCopyFunc sets a handler called with text copied or cut, e.g. to copy it
+// to the system clipboard.
+func (b *ContentBox) SetCopyFunc(handler func(text string)) *ContentBox {
+	b.copyFunc = handler
+	return b
+}
+
+// SetPasteFunc sets a handler returning the text to paste, e.g. from the
+// system clipboard. The text last copied or cut is pasted if it returns
+// nothing.
+func (b *ContentBox) SetPasteFunc(handler func() string) *ContentBox {
+	b.pasteFunc = handler
+	return b
+}
+
+// SetCompletionFunc sets a handler returning note names that complete a
+// partially typed [[link]].
+func (b *ContentBox) SetCompletionFunc(handler func(query string) []string) *ContentBox {
+	b.completeFunc = handler
+	return b
+}
+
+// IsCompleting returns true while the [[link]] completion popup is shown.
+func (b *ContentBox) IsCompleting() bool {
+	return b.completing
+}
+
+// updateCompletion shows or hides the completion popup depending on whether
+// the cursor is inside an unfinished [[link]].
+func (b *ContentBox) updateCompletion() {
+	b.completing = false
+
+	if b.completeFunc == nil {
+		return
+	}
+
+	_, pos, _ := b.GetSelection()
+	text := b.GetText()
+
+	start, ok := openLinkAt(text, pos)
+	if !ok {
+		return
+	}
+
+	if items := b.completeFunc(text[start:pos]); len(items) > 0 {
+		b.completer.SetItems(items)
+		b.completing = true
+	}
+}
+
+// insertCompletion replaces the partially typed link with the selected name.
+func (b *ContentBox) insertCompletion() {
+	b.completing = false
+
+	title, ok := b.completer.Selected()
+	if !ok {
+		return
+	}
+
+	_, pos, _ := b.GetSelection()
+	text := b.GetText()
+
+	start, ok := openLinkAt(text, pos)
+	if !ok {
+		return
+	}
+
+	if !strings.HasPrefix(text[pos:], "]]") {
+		title += "]]"
+	}
+
+	b.Replace(start, pos, title)
+}
+
+// resetEditing returns to the initial mode when a different file is loaded.
+func (b *ContentBox) resetEditing() {
+	b.selecting = false
+	b.setMode("")
+}
+
+func (b *ContentBox) fileChanged() {
+	if b.fileFunc != nil {
+		b.fileFunc(b.currentFile)
+	}
+}
+
+// followLink invokes the link handler for the [[link]] under the cursor.
+func (b *ContentBox) followLink() {
+	if b.linkFunc == nil {
+		return
+	}
+
+	_, start, _ := b.GetSelection()
+
+	if target, ok := linkAt(b.GetText(), start); ok {
+		b.linkFunc(target)
+	}
 }
 
 // RefreshFile marks that the file may have changed on disk. The actual
@@ -88,103 +348,1017 @@ func (b *ContentBox) SetSearchQuery(query string) {
 	b.searchQuery = query
 }
 
-// Draw renders the text area and then highlights any occurrences of the search query.
+// TogglePreview switches between editing the note and reading it rendered
+// from Markdown.
+func (b *ContentBox) TogglePreview() {
+	b.previewing = !b.previewing
+	b.completing = false
+	b.pending = nil
+	b.setMode(b.mode)
+}
+
+// IsPreviewing returns true if the note is shown rendered from Markdown.
+func (b *ContentBox) IsPreviewing() bool {
+	return b.previewing
+}
+
+// Draw renders the text area (or its preview) and then highlights any occurrences of the search query,
+// or of the text to find while finding.
 func (b *ContentBox) Draw(screen tcell.Screen) {
-	b.TextArea.Draw(screen)
+	if b.previewing {
+		b.drawPreview(screen)
+	} else {
+		if b.matchPos > 0 {
+			b.showMatch(screen)
+		}
 
-	if b.searchQuery == "" {
-		return
+		b.TextArea.Draw(screen)
+	}
+
+	if b.completing {
+		defer b.drawCompletion(screen)
+	}
+
+	// words matching the search are highlighted as FTS5 matches them, while
+	// the text to find is highlighted literally or as a regular expression
+	var match func(line string) [][]int
+
+	if b.finding {
+		defer b.drawFind(screen)
+
+		// text that cannot be found is not highlighted
+		re, err := findPattern(b.finder.Query(), b.finder.Options())
+		if err != nil || b.finder.Query() == "" {
+			return
+		}
+
+		match = func(line string) [][]int { return re.FindAllStringIndex(line, -1) }
+	} else {
+		terms := searchTerms(b.searchQuery)
+		if len(terms) == 0 {
+			return
+		}
+
+		match = func(line string) [][]int { return matchTerms(line, terms) }
 	}
 
 	x, y, width, height := b.GetInnerRect()
-	query := strings.ToLower(b.searchQuery)
 
 	for row := y; row < y+height; row++ {
-		// Build the visible line from screen cells
-		runes := make([]rune, width)
-		for col := 0; col < width; col++ {
-			mainc, _, _, _ := screen.GetContent(x+col, row)
-			runes[col] = mainc
-		}
-		line := strings.ToLower(string(runes))
-
-		// Find all occurrences of the query in this line
-		offset := 0
-		for {
-			idx := strings.Index(line[offset:], query)
-			if idx < 0 {
-				break
+		line, columns := screenLine(screen, x, row, width)
+
+		for _, m := range match(line) {
+			for pos := m[0]; pos < m[1]; pos++ {
+				// restyle each cell once, from the first byte of its rune
+				if pos > m[0] && columns[pos] == columns[pos-1] {
+					continue
+				}
+
+				mainc, combc, _, _ := screen.GetContent(columns[pos], row)
+				screen.SetContent(columns[pos], row, mainc, combc, Colors.HighlightStyle())
 			}
-			matchStart := offset + idx
-			for i := 0; i < len(query); i++ {
-				cx := x + matchStart + i
-				mainc, combc, style, _ := screen.GetContent(cx, row)
-				screen.SetContent(cx, row, mainc, combc, style.Background(HighlightBackground).Foreground(HighlightForeground).Bold(true))
+		}
+	}
+}
+
+// screenLine returns the text drawn on a row of the screen, along with the
+// column each of its bytes is drawn in. Wide runes take up two columns, and
+// combining characters share the column of the rune they modify.
+func screenLine(screen tcell.Screen, x, y, width int) (string, []int) {
+	var (
+		sb      strings.Builder
+		columns []int
+	)
+
+	for col := x; col < x+width; {
+		mainc, combc, _, cells := screen.GetContent(col, y)
+
+		if mainc == 0 {
+			mainc = ' '
+		}
+
+		for _, r := range append([]rune{mainc}, combc...) {
+			sb.WriteRune(r)
+
+			for i := 0; i < utf8.RuneLen(r); i++ {
+				columns = append(columns, col)
 			}
-			offset = matchStart + len(query)
 		}
+
+		if cells < 1 {
+			cells = 1
+		}
+
+		col += cells
 	}
+
+	return sb.String(), columns
+}
+
+// drawFind draws the find bar over the bottom border.
+func (b *ContentBox) drawFind(screen tcell.Screen) {
+	x, y, width, height := b.GetRect()
+
+	b.finder.DrawAt(screen, x+1, y+height-1, width-2)
 }
 
-// InputHandler overrides default handling to switch focus away from search box when necessary.
+// drawPreview draws the border of the box around the rendered note, which
+// is only rendered again once the text or width changed.
+func (b *ContentBox) drawPreview(screen tcell.Screen) {
+	b.Box.DrawForSubclass(screen, b)
+
+	x, y, width, height := b.GetInnerRect()
+
+	if text := b.GetText(); text != b.previewText || width != b.previewWidth {
+		b.previewText, b.previewWidth = text, width
+		b.preview.SetText(RenderMarkdown(text, width))
+	}
+
+	b.preview.SetRect(x, y, width, height)
+	b.preview.Draw(screen)
+}
+
+// drawCompletion draws the completion popup below the cursor.
+func (b *ContentBox) drawCompletion(screen tcell.Screen) {
+	x, y, width, height := b.GetInnerRect()
+	row, column, _, _ := b.GetCursor()
+	offsetRow, offsetColumn := b.GetOffset()
+
+	b.completer.DrawAt(screen, x+column-offsetColumn, y+row-offsetRow, x, y, width, height)
+}
+
+// InputHandler performs the actions bound to keys in the current mode, and
+// passes other keys on to the text area.
 func (b *ContentBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
-		event = b.mapSpecialKeys(event)
+		// the preview is read-only, and only scrolls
+		if b.previewing {
+			if handler := b.preview.InputHandler(); handler != nil {
+				handler(event, setFocus)
+			}
+			return
+		}
 
 		before := b.GetText()
 
-		if handler := b.TextArea.InputHandler(); handler != nil {
-			handler(event, setFocus)
+		if b.finding {
+			b.handleFindKey(event, setFocus)
+			if after := b.GetText(); before != after {
+				b.queueSave(after)
+			}
+			return
+		}
+
+		if b.completing && b.handleCompletionKey(event, setFocus) {
+			if after := b.GetText(); before != after {
+				b.queueSave(after)
+			}
+			return
 		}
 
+		b.handleKey(event, setFocus)
+
 		if after := b.GetText(); before != after {
 			b.queueSave(after)
+			if b.context() == ContextContent {
+				b.updateCompletion()
+			}
+		} else {
+			b.completing = false
 		}
 	})
 }
 
-func (b *ContentBox) mapSpecialKeys(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	// navigate up
-	case tcell.KeyCtrlP:
-		event = tcell.NewEventKey(tcell.KeyUp, event.Rune(), event.Modifiers())
-
-	// navigate down
-	case tcell.KeyCtrlN:
-		event = tcell.NewEventKey(tcell.KeyDown, event.Rune(), event.Modifiers())
-
-	// navigate forward
-	case tcell.KeyCtrlF:
-		event = tcell.NewEventKey(tcell.KeyRight, event.Rune(), event.Modifiers())
-
-	// delete empty line
-	case tcell.KeyCtrlK:
-		fromRow, fromCol, toRow, toCol := b.GetCursor()
-
-		if fromRow == toRow && fromCol == toCol && fromCol == 0 {
-			if _, start, end := b.GetSelection(); start == end {
-				r, _ := utf8.DecodeRuneInString(b.GetText()[start:])
-				if !unicode.IsLetter(r) {
-					event = tcell.NewEventKey(tcell.KeyDelete, event.Rune(), event.Modifiers())
-				}
+// MouseHandler lets the text area place the cursor, select text and scroll
+// with the wheel, or scrolls the preview while previewing. Clicks on the
+// find bar are ignored.
+func (b *ContentBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return b.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+
+		if !b.InRect(x, y) {
+			return false, nil
+		}
+
+		if b.finding {
+			if _, top, _, height := b.GetRect(); y == top+height-1 {
+				return true, nil
 			}
 		}
 
+		if action == tview.MouseLeftDown {
+			b.completing = false
+			b.pending = nil
+			b.selecting = false
+		}
+
+		handler := b.TextArea.MouseHandler()
+
+		if b.previewing {
+			handler = b.preview.MouseHandler()
+		}
+
+		return keepFocus(b, handler, nil)(action, event, setFocus)
+	})
+}
+
+// handleCompletionKey handles navigation within the completion popup,
+// returning false if the event should be processed by the text area.
+func (b *ContentBox) handleCompletionKey(event *tcell.EventKey, setFocus func(p tview.Primitive)) bool {
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyCtrlP:
+		if handler := b.completer.InputHandler(); handler != nil {
+			handler(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), setFocus)
+		}
+	case tcell.KeyDown, tcell.KeyCtrlN:
+		if handler := b.completer.InputHandler(); handler != nil {
+			handler(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), setFocus)
+		}
+	case tcell.KeyEnter, tcell.KeyTab:
+		b.insertCompletion()
+	case tcell.KeyEscape:
+		b.completing = false
+	default:
+		return false
+	}
+
+	return true
+}
+
+// StartFind shows the find bar, finding the selected text if there is any,
+// or else the text found last.
+func (b *ContentBox) StartFind() {
+	if b.previewing {
+		b.TogglePreview()
+	}
+
+	query := b.finder.Query()
+
+	if selected, _, _ := b.GetSelection(); selected != "" && !strings.Contains(selected, "\n") {
+		query = selected
+	}
+
+	b.selecting = false
+	b.completing = false
+	b.finding = true
+	b.findOrigin = b.Cursor()
+	b.finder.Show(query)
+	b.updateFind()
+}
+
+// IsFinding returns true while the find bar is shown.
+func (b *ContentBox) IsFinding() bool {
+	return b.finding
+}
+
+// handleFindKey performs the actions of the find bar, and types other keys
+// into it. Esc closes it, leaving the current match selected.
+func (b *ContentBox) handleFindKey(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	action, _ := Keys.Lookup(ContextFind, event)
+
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		b.finding = false
+	case event.Key() == tcell.KeyTab, event.Key() == tcell.KeyBacktab:
+		b.finder.SwitchField()
+	case action == ActionFindNext:
+		b.findAdjacent(1)
+	case action == ActionFindPrevious:
+		b.findAdjacent(-1)
+	case action == ActionReplace:
+		b.replaceMatch()
+	case action == ActionReplaceAll:
+		b.replaceAll()
+	case action == ActionToggleRegex:
+		b.finder.ToggleRegex()
+		b.updateFind()
+	case action == ActionToggleCase:
+		b.finder.ToggleCase()
+		b.updateFind()
+	default:
+		query := b.finder.Query()
+
+		if handler := b.finder.InputHandler(); handler != nil {
+			handler(event, setFocus)
+		}
+
+		if b.finder.Query() != query {
+			b.updateFind()
+		}
+	}
+}
+
+// findMatches returns the matches of the find bar's text in the note.
+func (b *ContentBox) findMatches() ([][]int, error) {
+	return FindMatches(b.GetText(), b.finder.Query(), b.finder.Options())
+}
+
+// updateFind selects the first match from where finding started, wrapping
+// around to the start of the note.
+func (b *ContentBox) updateFind() {
+	matches, err := b.findMatches()
+
+	b.findIndex = -1
+
+	for i, match := range matches {
+		if match[0] >= b.findOrigin {
+			b.findIndex = i
+			break
+		}
+	}
+
+	if b.findIndex < 0 && len(matches) > 0 {
+		b.findIndex = 0
+	}
+
+	b.selectMatch(matches, err)
+}
+
+// findAdjacent selects the match after the cursor, or before it if offset is
+// negative, wrapping around at the end of the note.
+func (b *ContentBox) findAdjacent(offset int) {
+	matches, err := b.findMatches()
+
+	if len(matches) == 0 {
+		b.selectMatch(matches, err)
+		return
+	}
+
+	_, start, end := b.GetSelection()
+
+	b.findIndex = -1
+
+	for i, match := range matches {
+		if match[0] == start && match[1] == end {
+			// continue from the selected match
+			b.findIndex = (i + offset + len(matches)) % len(matches)
+			break
+		} else if offset > 0 && match[0] >= start && b.findIndex < 0 {
+			b.findIndex = i
+		} else if offset < 0 && match[1] <= start {
+			b.findIndex = i
+		}
+	}
+
+	if b.findIndex < 0 && offset > 0 {
+		b.findIndex = 0
+	} else if b.findIndex < 0 {
+		b.findIndex = len(matches) - 1
+	}
+
+	b.selectMatch(matches, err)
+}
+
+// selectMatch selects the current match, and shows which one it is.
+func (b *ContentBox) selectMatch(matches [][]int, err error) {
+	if b.findIndex >= 0 && b.findIndex < len(matches) {
+		b.selectRange(matches[b.findIndex][0], matches[b.findIndex][1])
+		b.selecting = false
+	}
+
+	b.finder.SetStatus(b.findIndex, len(matches), err)
+}
+
+// replaceMatch replaces the selected match, and selects the next one. If no
+// match is selected, the next one is selected to be replaced.
+func (b *ContentBox) replaceMatch() {
+	matches, err := b.findMatches()
+	text := b.GetText()
+	_, start, end := b.GetSelection()
+
+	for _, match := range matches {
+		if match[0] != start || match[1] != end {
+			continue
+		}
+
+		replaced, err := Replacement(text, b.finder.Query(), b.finder.ReplaceText(), match, b.finder.Options())
+
+		if err != nil {
+			b.finder.SetStatus(-1, 0, err)
+			return
+		}
+
+		b.replaceRange(start, end, replaced)
+		b.findOrigin = start + len(replaced)
+		b.updateFind()
+		return
+	}
+
+	if err != nil {
+		b.finder.SetStatus(-1, 0, err)
+		return
+	}
+
+	b.findAdjacent(1)
+}
+
+// replaceAll replaces all matches at once, so that a single undo restores
+// them.
+func (b *ContentBox) replaceAll() {
+	matches, err := b.findMatches()
+
+	if err != nil || len(matches) == 0 {
+		b.selectMatch(matches, err)
+		return
+	}
+
+	text := b.GetText()
+	replaced, err := ReplaceMatches(text, b.finder.Query(), b.finder.ReplaceText(), matches, b.finder.Options())
+
+	if err != nil {
+		b.finder.SetStatus(-1, 0, err)
+		return
+	}
+
+	// only the text from the first to the last match changes
+	first, last := matches[0][0], matches[len(matches)-1][1]
+	b.replaceRange(first, last, replaced[first:len(replaced)-(len(text)-last)])
+
+	b.findIndex = -1
+	b.finder.SetReplaced(len(matches))
+}
+
+// HandlesKey returns true if a key press is bound in the current mode, or
+// continues a sequence of keys, so that it takes precedence over global keys.
+// All keys go to the find bar while finding.
+func (b *ContentBox) HandlesKey(event *tcell.EventKey) bool {
+	if b.previewing {
+		return false
+	} else if b.finding || len(b.pending) > 0 {
+		return true
+	}
+
+	_, ok, partial := Keys.LookupSequence(b.context(), []Key{KeyFromEvent(event)})
+	return ok || partial
+}
+
+// context returns the context of the keys bound in the current mode. Unless
+// the keymap is modal, this is always ContextContent.
+func (b *ContentBox) context() KeyContext {
+	switch {
+	case !Keys.IsModal():
+		return ContextContent
+	case b.mode == "":
+		return ContextNormal
+	default:
+		return b.mode
+	}
+}
+
+// setMode switches between the modes of a modal keymap, showing the mode in
+// the title unless previewing.
+func (b *ContentBox) setMode(mode KeyContext) {
+	b.mode = mode
+	b.pending = nil
+
+	if b.previewing {
+		b.SetTitle("Preview")
+		return
+	}
+
+	switch b.context() {
+	case ContextNormal:
+		b.SetTitle("Content (normal)")
+	case ContextVisual:
+		b.SetTitle("Content (visual)")
+	default:
+		if Keys.IsModal() {
+			b.SetTitle("Content (insert)")
+		} else {
+			b.SetTitle("Content")
+		}
+	}
+}
+
+// handleKey performs the action bound to a key press, or to a sequence of
+// keys it ends. Keys not bound are passed on to the text area, except for
+// those typing text in normal and visual mode.
+func (b *ContentBox) handleKey(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	context := b.context()
+	keys := append(b.pending, KeyFromEvent(event))
+
+	action, ok, partial := Keys.LookupSequence(context, keys)
+
+	switch {
+	case ok:
+		b.pending = nil
+		b.perform(action)
+	case partial:
+		b.pending = keys
+	case len(b.pending) > 0:
+		// unknown sequences are ignored
+		b.pending = nil
+	case b.selecting && isMotionKey(event.Key()):
+		b.send(event.Key(), event.Modifiers()|tcell.ModShift)
+	case context == ContextContent:
+		// a selection made with the mark ends, while one made with the
+		// text area's own keys stays, to be copied or cut
+		if b.selecting {
+			b.cancelSelection()
+		}
+		if handler := b.TextArea.InputHandler(); handler != nil {
+			handler(event, setFocus)
+		}
+	case isMotionKey(event.Key()):
+		b.send(event.Key(), event.Modifiers())
+	}
+}
+
+// isMotionKey returns true for keys moving the cursor within the text area.
+func isMotionKey(key tcell.Key) bool {
+	switch key {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight,
+		tcell.KeyHome, tcell.KeyEnd, tcell.KeyPgUp, tcell.KeyPgDn:
+		return true
+	default:
+		return false
+	}
+}
+
+// send passes a key to the text area, performing its built-in action.
+func (b *ContentBox) send(key tcell.Key, mod tcell.ModMask) {
+	if handler := b.TextArea.InputHandler(); handler != nil {
+		handler(tcell.NewEventKey(key, 0, mod), func(p tview.Primitive) {})
+	}
+}
+
+// move moves the cursor with a key, extending the selection if selecting.
+func (b *ContentBox) move(key tcell.Key, mod tcell.ModMask) {
+	if b.selecting {
+		mod |= tcell.ModShift
+	}
+	b.send(key, mod)
+}
+
+// perform performs an action bound to keys of the content box.
+func (b *ContentBox) perform(action Action) {
+	switch action {
+	case ActionFollowLink:
+		b.followLink()
+	case ActionFind:
+		b.StartFind()
+
+	// moving the cursor
+	case ActionCursorUp:
+		b.move(tcell.KeyUp, tcell.ModNone)
+	case ActionCursorDown:
+		b.move(tcell.KeyDown, tcell.ModNone)
+	case ActionCursorLeft:
+		b.move(tcell.KeyLeft, tcell.ModNone)
+	case ActionCursorRight:
+		b.move(tcell.KeyRight, tcell.ModNone)
+	case ActionWordLeft:
+		b.move(tcell.KeyLeft, tcell.ModCtrl)
+	case ActionLineStart:
+		b.move(tcell.KeyHome, tcell.ModNone)
+	case ActionLineEnd:
+		b.move(tcell.KeyEnd, tcell.ModNone)
+	case ActionPageUp:
+		b.move(tcell.KeyPgUp, tcell.ModNone)
+	case ActionPageDown:
+		b.move(tcell.KeyPgDn, tcell.ModNone)
+	case ActionWordRight:
+		b.moveTo(wordEnd(b.GetText(), b.Cursor()))
+	case ActionNextWord:
+		b.moveTo(nextWordStart(b.GetText(), b.Cursor()))
+	case ActionDocumentStart:
+		b.moveTo(0)
+	case ActionDocumentEnd:
+		b.moveTo(b.GetTextLength())
+
+	// editing
+	case ActionDeleteChar:
+		b.send(tcell.KeyDelete, tcell.ModNone)
+	case ActionDeleteBackward:
+		b.send(tcell.KeyBackspace2, tcell.ModNone)
+	case ActionDeleteWordBackward:
+		b.send(tcell.KeyCtrlW, tcell.ModNone)
+	case ActionDeleteEmptyLine:
+		if b.onEmptyLine() {
+			b.send(tcell.KeyDelete, tcell.ModNone)
+		}
+	case ActionKillLine:
+		b.killLine()
+	case ActionDeleteLine:
+		b.deleteLine()
+	case ActionYankLine:
+		text, pos := b.GetText(), b.Cursor()
+		b.copy(text[lineStart(text, pos):lineEnd(text, pos)] + "\n")
+	case ActionSelectAll:
+		b.send(tcell.KeyCtrlL, tcell.ModNone)
+	case ActionSetMark:
+		if b.selecting {
+			b.cancelSelection()
+		} else {
+			b.startSelection()
+		}
+	case ActionCancel:
+		b.cancelSelection()
+	case ActionCopy:
+		b.send(tcell.KeyCtrlQ, tcell.ModNone)
+		b.endSelection()
+	case ActionCut:
+		b.send(tcell.KeyCtrlX, tcell.ModNone)
+		b.endSelection()
+	case ActionPaste:
+		b.paste(true)
+	case ActionPasteBefore:
+		b.paste(false)
+	case ActionUndo:
+		b.send(tcell.KeyCtrlZ, tcell.ModNone)
+	case ActionRedo:
+		b.send(tcell.KeyCtrlY, tcell.ModNone)
+
+	// modes
+	case ActionNormalMode:
+		b.cancelSelection()
+		b.setMode(ContextNormal)
+	case ActionInsertMode:
+		b.setMode(ContextContent)
+	case ActionAppend:
+		if text, pos := b.GetText(), b.Cursor(); pos < lineEnd(text, pos) {
+			b.moveTo(nextRune(text, pos))
+		}
+		b.setMode(ContextContent)
+	case ActionAppendLineEnd:
+		b.moveTo(lineEnd(b.GetText(), b.Cursor()))
+		b.setMode(ContextContent)
+	case ActionInsertLineStart:
+		b.moveTo(firstNonBlank(b.GetText(), b.Cursor()))
+		b.setMode(ContextContent)
+	case ActionOpenLineBelow:
+		end := lineEnd(b.GetText(), b.Cursor())
+		b.Replace(end, end, "\n")
+		b.setMode(ContextContent)
+	case ActionOpenLineAbove:
+		start := lineStart(b.GetText(), b.Cursor())
+		b.Replace(start, start, "\n")
+		b.moveTo(start)
+		b.setMode(ContextContent)
+	case ActionVisualMode:
+		b.setMode(ContextVisual)
+		b.startSelection()
+	}
+}
+
+// Cursor returns the position of the cursor within the text. While
+// selecting, the cursor is the end of the selection that is not the mark.
+func (b *ContentBox) Cursor() int {
+	_, start, end := b.GetSelection()
+
+	if b.selecting && start == b.mark {
+		return end
+	}
+	return start
+}
+
+// moveTo moves the cursor to a position within the text, extending the
+// selection if selecting.
+func (b *ContentBox) moveTo(pos int) {
+	if b.selecting {
+		b.extendTo(b.GetText(), pos)
+		return
+	}
+
+	b.Select(pos, pos)
+
+	// keep the cursor in view
+	row, _, _, _ := b.GetCursor()
+	offsetRow, offsetColumn := b.GetOffset()
+	_, _, _, height := b.GetInnerRect()
+
+	if row < offsetRow {
+		b.SetOffset(row, offsetColumn)
+	} else if row >= offsetRow+height {
+		b.SetOffset(row-height+1, offsetColumn)
+	}
+}
+
+// extendTo extends the selection to a position by moving the cursor with
+// shift held, a line at a time. (TextArea.Select cannot select text
+// spanning more than one row.)
+func (b *ContentBox) extendTo(text string, pos int) {
+	for {
+		var (
+			cursor = b.Cursor()
+			key    tcell.Key
+			step   tcell.Key
+		)
+
+		switch {
+		case cursor < pos && pos > lineEnd(text, cursor):
+			key, step = tcell.KeyEnd, tcell.KeyRight
+		case cursor < pos:
+			key = tcell.KeyRight
+		case cursor > pos && pos < lineStart(text, cursor):
+			key, step = tcell.KeyHome, tcell.KeyLeft
+		case cursor > pos:
+			key = tcell.KeyLeft
+		default:
+			return
+		}
+
+		b.send(key, tcell.ModShift)
+
+		// at the end of a row, step onto the next one
+		if b.Cursor() == cursor && step != 0 {
+			b.send(step, tcell.ModShift)
+		}
+
+		if b.Cursor() == cursor {
+			return
+		}
+	}
+}
+
+// selectRange selects text between two positions.
+func (b *ContentBox) selectRange(start, end int) {
+	b.Select(start, start)
+	b.mark = start
+	b.selecting = true
+	b.extendTo(b.GetText(), end)
+}
+
+// deleteRange deletes the text between two positions.
+func (b *ContentBox) deleteRange(start, end int) {
+	if start == end {
+		return
+	}
+
+	b.selectRange(start, end)
+	b.send(tcell.KeyDelete, tcell.ModNone)
+	b.selecting = false
+}
+
+// replaceRange replaces the text between two positions, as a single change
+// to undo. The text is pasted over the selected range, keeping the register
+// as it was. (TextArea.Replace cannot replace text spanning more than one
+// row.)
+func (b *ContentBox) replaceRange(start, end int, text string) {
+	register, pasteFunc := b.register, b.pasteFunc
+
+	b.selectRange(start, end)
+	b.register, b.pasteFunc = text, nil
+	b.send(tcell.KeyCtrlV, tcell.ModNone)
+	b.selecting = false
+	b.register, b.pasteFunc = register, pasteFunc
+}
+
+// copy keeps text copied or cut to be pasted, and passes it on to the copy
+// handler.
+func (b *ContentBox) copy(text string) {
+	b.register = text
+
+	if b.copyFunc != nil {
+		b.copyFunc(text)
+	}
+}
+
+// clipboard returns the text to paste: the text returned by the paste
+// handler, unless it returns nothing, or else the text last copied or cut.
+func (b *ContentBox) clipboard() string {
+	if b.pasteFunc != nil {
+		if text := b.pasteFunc(); text != "" {
+			b.register = text
+		}
+	}
+
+	return b.register
+}
+
+// startSelection starts selecting text from the cursor.
+func (b *ContentBox) startSelection() {
+	b.mark = b.Cursor()
+	b.selecting = true
+}
+
+// cancelSelection stops selecting text, keeping the cursor where it is.
+func (b *ContentBox) cancelSelection() {
+	if !b.selecting && !b.HasSelection() {
+		return
 	}
 
-	return event
+	pos := b.Cursor()
+	b.selecting = false
+	b.Select(pos, pos)
+}
+
+// endSelection stops selecting after the selection was copied or cut,
+// returning to normal mode from visual mode.
+func (b *ContentBox) endSelection() {
+	b.selecting = false
+
+	if b.mode == ContextVisual {
+		b.setMode(ContextNormal)
+	}
+}
+
+// onEmptyLine returns true if the cursor is at the start of a line without
+// text.
+func (b *ContentBox) onEmptyLine() bool {
+	fromRow, fromCol, toRow, toCol := b.GetCursor()
+
+	if fromRow != toRow || fromCol != toCol || fromCol != 0 {
+		return false
+	}
+
+	_, start, end := b.GetSelection()
+	if start != end {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(b.GetText()[start:])
+	return !unicode.IsLetter(r)
+}
+
+// killLine cuts the text from the cursor to the end of the line. At the end
+// of a line, the newline is cut instead, except in normal mode.
+func (b *ContentBox) killLine() {
+	b.cancelSelection()
+
+	text, pos := b.GetText(), b.Cursor()
+	end := lineEnd(text, pos)
+
+	if end == pos && end < len(text) && b.context() != ContextNormal {
+		end++
+	}
+
+	b.copy(text[pos:end])
+	b.deleteRange(pos, end)
+}
+
+// deleteLine cuts the line containing the cursor, including its newline.
+func (b *ContentBox) deleteLine() {
+	b.cancelSelection()
+
+	text, pos := b.GetText(), b.Cursor()
+	start, end := lineStart(text, pos), lineEnd(text, pos)
+
+	if end < len(text) {
+		end++
+	} else if start > 0 {
+		// the last line takes the newline before it
+		start--
+	}
+
+	line := strings.TrimPrefix(text[start:end], "\n")
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+
+	b.deleteRange(start, end)
+	b.copy(line)
+
+	b.moveTo(firstNonBlank(b.GetText(), start))
+}
+
+// paste inserts the text last copied or cut, after or before the cursor. In
+// normal mode, whole lines are pasted below or above the current line.
+func (b *ContentBox) paste(after bool) {
+	b.cancelSelection()
+	b.clipboard()
+
+	var (
+		text, pos = b.GetText(), b.Cursor()
+		lines     = b.context() == ContextNormal && strings.HasSuffix(b.register, "\n")
+	)
+
+	switch {
+	case lines && after:
+		end := lineEnd(text, pos)
+		if end == len(text) {
+			b.Replace(end, end, "\n"+strings.TrimSuffix(b.register, "\n"))
+		} else {
+			b.Replace(end+1, end+1, b.register)
+		}
+		b.moveTo(end + 1)
+	case lines:
+		start := lineStart(text, pos)
+		b.Replace(start, start, b.register)
+		b.moveTo(start)
+	case after && b.context() == ContextNormal && pos < lineEnd(text, pos):
+		pos = nextRune(text, pos)
+		b.Replace(pos, pos, b.register)
+	default:
+		b.Replace(pos, pos, b.register)
+	}
 }
 
 func (b *ContentBox) queueSave(content string) {
 	if b.currentFile == nil {
 		return
 	}
-	filename := b.currentFile.Filename
-	b.debounce(func() {
-		err := SaveContent(filename, content)
+	file := b.currentFile
 
-		if err != nil {
+	b.saveLock.Lock()
+	b.modified = true
+	b.edits++
+	edits := b.edits
+	b.saveLock.Unlock()
+
+	if b.modifiedFunc != nil {
+		b.modifiedFunc(file)
+	}
+
+	save := func() error {
+		if err := b.saveFunc(file, content); err != nil {
+			return err
+		}
+
+		// edits made meanwhile are still to be saved
+		b.saveLock.Lock()
+		if b.edits == edits {
+			b.modified = false
+		}
+		b.saveLock.Unlock()
+
+		return nil
+	}
+
+	b.saveLock.Lock()
+	b.pendingSave = save
+	b.saveLock.Unlock()
+
+	b.debounce(func() {
+		if err := b.flushSave(); err != nil {
 			log.Println("Error saving content:", err)
 		}
 	})
 }
+
+// saveEdits saves the edits of the current file at once, e.g. before another
+// file is shown, logging a failure.
+func (b *ContentBox) saveEdits() {
+	if err := b.SaveNow(); err != nil {
+		log.Println("Error saving content:", err)
+	}
+}
+
+// discardEdits drops the save still pending, if any.
+func (b *ContentBox) discardEdits() {
+	b.saving.Lock()
+	defer b.saving.Unlock()
+
+	b.saveLock.Lock()
+	b.pendingSave = nil
+	b.saveLock.Unlock()
+}
+
+// flushSave runs the save still pending, if any. The save may be of a file
+// shown before the current one.
+func (b *ContentBox) flushSave() error {
+	b.saving.Lock()
+	defer b.saving.Unlock()
+
+	b.saveLock.Lock()
+	save := b.pendingSave
+	b.pendingSave = nil
+	b.saveLock.Unlock()
+
+	if save == nil {
+		return nil
+	}
+
+	return save()
+}
+
+// setModified records whether the current file has edits not saved yet.
+func (b *ContentBox) setModified(modified bool) {
+	b.saveLock.Lock()
+	defer b.saveLock.Unlock()
+
+	b.modified = modified
+	b.edits++
+}
+
+// isModified returns true if the current file has edits not saved yet.
+func (b *ContentBox) isModified() bool {
+	b.saveLock.Lock()
+	defer b.saveLock.Unlock()
+
+	return b.modified
+}
+
+// SaveNow saves the edits of the current file at once, rather than once
+// typing stops, e.g. before the file is changed by other means.
+func (b *ContentBox) SaveNow() error {
+	// a save still pending would otherwise undo changes made to the file
+	if err := b.flushSave(); err != nil {
+		return err
+	}
+
+	// edits which failed to save before are saved again
+	if b.currentFile == nil || !b.isModified() {
+		return nil
+	}
+
+	b.saving.Lock()
+	defer b.saving.Unlock()
+
+	if err := b.saveFunc(b.currentFile, b.GetText()); err != nil {
+		return err
+	}
+
+	b.setModified(false)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -12,13 +13,59 @@ import (
 
 type DB struct {
 	*sqlx.DB
+
+	// SnippetLength is the number of words of the snippets of search
+	// results, DefaultSnippetLength if not set.
+	SnippetLength int
+
+	// Root is the notes directory, which the filenames of documents are
+	// joined with.
+	Root string
 }
 
+// schemaVersion is bumped whenever indexing derives new data from document
+// text, so that existing databases re-index all documents on open.
+const schemaVersion = 3
+
 type SearchResult struct {
 	*FileRef
 	Snippet string `db:"snippet"`
+	Title   string `db:"title"`
+	Pinned  bool   `db:"pinned"`
+
+	// MatchOffset is the offset of the first match of the search within the
+	// note's text, after any front matter, or 0 if only its name matched.
+	MatchOffset int `db:"match_offset"`
+
+	// Folder is the note's folder relative to the notes directory, set only
+	// when it should be displayed along with its name.
+	Folder string `db:"-"`
 }
 
+// Name returns the note's front matter title if it has one, or its
+// display name otherwise, prefixed by its folder if set.
+func (r *SearchResult) Name() string {
+	name := r.DisplayName()
+
+	if r.Title != "" {
+		name = r.Title
+	}
+
+	if r.Folder != "" {
+		return r.Folder + "/" + name
+	}
+
+	return name
+}
+
+// matchOffsetColumn selects the byte offset of the first match within the
+// text of a document as 'match_offset', found by marking matches with
+// highlight() the way FTS5 matched them.
+const matchOffsetColumn = `MAX(instr(CAST(highlight(content_index, 2, char(1), char(2)) AS BLOB), x'01') - 1, 0) as match_offset`
+
+// titleColumn selects a document's front matter title as 'title'.
+const titleColumn = `COALESCE((SELECT value FROM metadata WHERE document_id = docs.id AND key = 'title' LIMIT 1), '') as title`
+
 func MustOpen(file string) *DB {
 	db := sqlx.MustOpen("sqlite3", fmt.Sprintf("file:%s?_fk=true&loc=auto", file))
 
@@ -28,7 +75,8 @@ func MustOpen(file string) *DB {
 			filename 			varchar(255) NOT NULL UNIQUE,
 			md5 				TEXT,
 			modified_at			DATETIME,
-			last_indexed_at 	DATETIME
+			last_indexed_at 	DATETIME,
+			pinned 				INTEGER NOT NULL DEFAULT 0
 		);
 	`,
 	)
@@ -37,17 +85,106 @@ func MustOpen(file string) *DB {
 		panic(err)
 	}
 
+	if err := addColumn(db, "documents", "pinned", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		panic(err)
+	}
+
+	if err := migrate(db); err != nil {
+		panic(err)
+	}
+
 	_, err = db.Exec(`
 		CREATE VIRTUAL TABLE IF NOT EXISTS content_index USING FTS5 (
-			document_id, filename, text, tokenize = 'porter unicode61'
+			document_id, filename, text, names, tokenize = 'porter unicode61'
+		);
+
+	`)
+
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS links (
+			document_id 		INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			target 				varchar(255) NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS links_target ON links (target COLLATE NOCASE);
+	`)
+
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			document_id 		INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			tag 				varchar(255) NOT NULL
 		);
 
+		CREATE INDEX IF NOT EXISTS tags_tag ON tags (tag);
 	`)
 
 	if err != nil {
 		panic(err)
 	}
-	return &DB{db}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS metadata (
+			document_id 		INTEGER NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			key 				varchar(255) NOT NULL,
+			value 				TEXT NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS metadata_document ON metadata (document_id, key);
+	`)
+
+	if err != nil {
+		panic(err)
+	}
+	return &DB{DB: db}
+}
+
+// migrate rebuilds the full-text index and clears stored checksums when the
+// schema version changes, forcing the next refresh to re-index every document.
+func migrate(db *sqlx.DB) error {
+	var version int
+
+	if err := db.Get(&version, `PRAGMA user_version`); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if version >= schemaVersion {
+		return nil
+	}
+
+	if _, err := db.Exec(`DROP TABLE IF EXISTS content_index`); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := db.Exec(`UPDATE documents SET md5 = ''`); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion))
+	return errors.WithStack(err)
+}
+
+// addColumn adds a column to a table created before the column existed.
+func addColumn(db *sqlx.DB, table, column, definition string) error {
+	var count int
+
+	if err := db.Get(&count, `SELECT count(*) FROM pragma_table_info(?) WHERE name = ?`, table, column); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	_, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return errors.WithStack(err)
 }
 
 func (db *DB) IsUnmodified(fileRef *FileRef) bool {
@@ -132,8 +269,9 @@ func (db *DB) Recent(limit int) ([]*SearchResult, error) {
 
 	err = db.Select(&res, `
 		SELECT
-			docs.id, docs.filename, docs.md5, docs.modified_at,
-			REPLACE(substr(cti.text, 0, 180), char(10), ' ') as snippet
+			docs.id, docs.filename, docs.md5, docs.modified_at, docs.pinned,
+			REPLACE(substr(cti.text, 0, 180), char(10), ' ') as snippet,
+			`+titleColumn+`
 		FROM
 			documents docs
 		INNER JOIN
@@ -141,7 +279,7 @@ func (db *DB) Recent(limit int) ([]*SearchResult, error) {
 		ON
 			cti.document_id = docs.id
 		ORDER BY
-			docs.modified_at desc
+			docs.pinned desc, docs.modified_at desc
 		LIMIT ?
 	`, limit)
 
@@ -154,18 +292,36 @@ func (db *DB) Recent(limit int) ([]*SearchResult, error) {
 }
 
 // Search performs FTS on filename and text using default NEAR semantics
-// and includes snippet text up to 10 'word' tokens in length.
+// and includes snippet text up to SnippetLength 'word' tokens in length,
+// with matches between matchStart and matchEnd. Filters such as 'tag:name'
+// further restrict the results. Pinned documents are listed first, then the
+// best matches.
 func (db *DB) Search(text string) ([]*SearchResult, error) {
 	var (
 		res []*SearchResult
 		err error
 	)
 
-	term := ftsMatchString(text)
+	q := parseQuery(text)
+
+	if q.terms == "" {
+		return db.filter(q)
+	}
+
+	term := ftsMatchString(q.terms)
+	filters, args := q.filterSQL(db.Root)
+
+	length := db.SnippetLength
+	if length == 0 {
+		length = DefaultSnippetLength
+	}
+
 	err = db.Select(&res, `
 		SELECT
-			docs.id, docs.filename, docs.md5, docs.modified_at,
-			REPLACE(snippet(content_index, 2, "**", "**", '...', 10), char(10), ' ') as snippet
+			docs.id, docs.filename, docs.md5, docs.modified_at, docs.pinned,
+			REPLACE(snippet(content_index, 2, char(1), char(2), '...', ?), char(10), ' ') as snippet,
+			`+matchOffsetColumn+`,
+			`+titleColumn+`
 		FROM
 			documents docs
 		INNER JOIN
@@ -174,7 +330,10 @@ func (db *DB) Search(text string) ([]*SearchResult, error) {
 			cti.document_id = docs.id
 		WHERE
 			content_index match (?)
-	`, fmt.Sprintf("filename:NEAR(%s) OR text:NEAR(%s)", term, term))
+	`+filters+`
+		ORDER BY
+			docs.pinned desc, cti.rank
+	`, append([]interface{}{length, fmt.Sprintf("filename:NEAR(%s) OR text:NEAR(%s) OR names:NEAR(%s)", term, term, term)}, args...)...)
 
 	if err != nil {
 		logger.Printf("DB.Search: %v\n", err)
@@ -184,6 +343,39 @@ func (db *DB) Search(text string) ([]*SearchResult, error) {
 	return res, nil
 }
 
+// filter returns all documents matching the query's filters, most
+// recently modified first.
+func (db *DB) filter(q searchQuery) ([]*SearchResult, error) {
+	var res []*SearchResult
+
+	filters, args := q.filterSQL(db.Root)
+
+	err := db.Select(&res, `
+		SELECT
+			docs.id, docs.filename, docs.md5, docs.modified_at, docs.pinned,
+			REPLACE(substr(cti.text, 0, 180), char(10), ' ') as snippet,
+			`+titleColumn+`
+		FROM
+			documents docs
+		INNER JOIN
+			content_index cti
+		ON
+			cti.document_id = docs.id
+		WHERE
+			1 = 1
+	`+filters+`
+		ORDER BY
+			docs.pinned desc, docs.modified_at desc
+	`, args...)
+
+	if err != nil {
+		logger.Printf("DB.filter: %v\n", err)
+		return nil, errors.WithStack(err)
+	}
+
+	return res, nil
+}
+
 func (db *DB) Insert(fileRef *FileRef, data []byte) error {
 	// Insert
 	var (
@@ -213,14 +405,7 @@ func (db *DB) Insert(fileRef *FileRef, data []byte) error {
 
 	fileRef.DocumentID = docId
 
-	_, err = db.Exec(`
-		INSERT INTO content_index
-			(document_id, filename, text)
-		VALUES
-			(?, ?, ?);
-	`, fileRef.DocumentID, fileRef.Filename, string(data))
-
-	return errors.WithStack(err)
+	return db.indexContent(fileRef.DocumentID, fileRef.Filename, data)
 }
 
 func (db *DB) Update(oldRef, newRef *FileRef, data []byte) error {
@@ -244,14 +429,322 @@ func (db *DB) Update(oldRef, newRef *FileRef, data []byte) error {
 		return errors.New("update did not change any rows")
 	}
 
-	_, err = db.Exec(`
-		UPDATE content_index
-		SET
-			text        = ?
+	return db.indexContent(oldRef.DocumentID, oldRef.Filename, data)
+}
+
+// indexContent replaces the full-text index entry of a document, along with
+// the links, tags and front matter metadata found in its text. Front matter
+// is indexed as names and metadata rather than as body text.
+func (db *DB) indexContent(docID int64, filename string, data []byte) error {
+	frontMatter, body := parseFrontMatter(string(data))
+
+	if _, err := db.Exec(`DELETE FROM content_index WHERE document_id = ?`, docID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO content_index
+			(document_id, filename, text, names)
+		VALUES
+			(?, ?, ?, ?);
+	`, docID, filename, body, strings.Join(frontMatter.Names(), "\n"))
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := db.indexMetadata(docID, frontMatter); err != nil {
+		return err
+	}
+
+	return db.indexAttributes(docID, data)
+}
+
+// indexMetadata replaces the front matter metadata recorded for a document.
+func (db *DB) indexMetadata(docID int64, frontMatter FrontMatter) error {
+	if _, err := db.Exec(`DELETE FROM metadata WHERE document_id = ?`, docID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	entries := [][2]string{
+		{"title", frontMatter.Title},
+		{"created", frontMatter.Created},
+	}
+
+	for _, alias := range frontMatter.Aliases {
+		entries = append(entries, [2]string{"alias", alias})
+	}
+
+	for _, entry := range entries {
+		if entry[1] == "" {
+			continue
+		}
+
+		_, err := db.Exec(`
+			INSERT INTO metadata
+				(document_id, key, value)
+			VALUES
+				(?, ?, ?);
+		`, docID, entry[0], entry[1])
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Metadata returns the front matter metadata of a document, keyed by name.
+// Keys with several values (such as 'alias') list them in order.
+func (db *DB) Metadata(docID int64) (map[string][]string, error) {
+	var rows []struct {
+		Key   string `db:"key"`
+		Value string `db:"value"`
+	}
+
+	if err := db.Select(&rows, `SELECT key, value FROM metadata WHERE document_id = ? ORDER BY rowid`, docID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := map[string][]string{}
+
+	for _, row := range rows {
+		res[row.Key] = append(res[row.Key], row.Value)
+	}
+
+	return res, nil
+}
+
+// indexAttributes records the links and tags found in a document's text.
+func (db *DB) indexAttributes(docID int64, data []byte) error {
+	if err := db.indexLinks(docID, data); err != nil {
+		return err
+	}
+
+	return db.indexTags(docID, data)
+}
+
+// indexLinks replaces the set of [[links]] recorded for a document.
+func (db *DB) indexLinks(docID int64, data []byte) error {
+	if _, err := db.Exec(`DELETE FROM links WHERE document_id = ?`, docID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, target := range parseLinks(string(data)) {
+		_, err := db.Exec(`
+			INSERT INTO links
+				(document_id, target)
+			VALUES
+				(?, ?);
+		`, docID, target)
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// indexTags replaces the set of tags recorded for a document.
+func (db *DB) indexTags(docID int64, data []byte) error {
+	if _, err := db.Exec(`DELETE FROM tags WHERE document_id = ?`, docID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, tag := range parseTags(string(data)) {
+		_, err := db.Exec(`
+			INSERT INTO tags
+				(document_id, tag)
+			VALUES
+				(?, ?);
+		`, docID, tag)
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Tags returns every tag in use along with the number of documents carrying it.
+func (db *DB) Tags() ([]*TagCount, error) {
+	var tags []*TagCount
+
+	err := db.Select(&tags, `
+		SELECT
+			tag, count(DISTINCT document_id) as count
+		FROM
+			tags
+		GROUP BY
+			tag
+		ORDER BY
+			tag
+	`)
+
+	if err != nil {
+		logger.Printf("DB.Tags: %v\n", err)
+		return nil, errors.WithStack(err)
+	}
+
+	return tags, nil
+}
+
+// IndexedText is the text of a document as indexed, without front matter.
+type IndexedText struct {
+	FileRef
+	Text string `db:"text"`
+}
+
+// IndexedTexts returns the indexed text of all documents.
+func (db *DB) IndexedTexts() ([]*IndexedText, error) {
+	var texts []*IndexedText
+
+	err := db.Select(&texts, `
+		SELECT
+			docs.id, docs.filename, docs.md5, docs.modified_at, content_index.text
+		FROM
+			documents docs
+		INNER JOIN
+			content_index
+		ON
+			content_index.document_id = docs.id
+		ORDER BY
+			docs.filename
+	`)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return texts, nil
+}
+
+// Backlinks returns all documents containing a [[link]] to the given name.
+func (db *DB) Backlinks(name string) ([]*FileRef, error) {
+	var refs []*FileRef
+
+	err := db.Select(&refs, `
+		SELECT DISTINCT
+			docs.id, docs.filename, docs.md5, docs.modified_at
+		FROM
+			documents docs
+		INNER JOIN
+			links
+		ON
+			links.document_id = docs.id
 		WHERE
-			document_id = ?
-	`, string(data), oldRef.DocumentID)
+			links.target = ? COLLATE NOCASE
+		ORDER BY
+			docs.filename
+	`, name)
+
+	if err != nil {
+		logger.Printf("DB.Backlinks: %v\n", err)
+		return nil, errors.WithStack(err)
+	}
+
+	return refs, nil
+}
+
+// FindByName returns the document whose display name matches name
+// (case-insensitive), or sql.ErrNoRows if there is none. A name containing
+// a folder ('folder/name') must match the document's path within Root.
+func (db *DB) FindByName(name string) (*FileRef, error) {
+	refs, err := db.GetAllFileRefs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	qualified := strings.Contains(name, "/")
+
+	for _, ref := range refs {
+		if !qualified && strings.EqualFold(ref.DisplayName(), name) {
+			return ref, nil
+		}
+
+		if !qualified {
+			continue
+		}
+
+		path, err := filepath.Rel(db.Root, ref.Filename)
+
+		if err != nil {
+			continue
+		}
+
+		if strings.EqualFold(filepath.ToSlash(strings.TrimSuffix(path, filepath.Ext(path))), name) {
+			return ref, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// Titles returns up to limit note names fuzzy-matching the given query. Only
+// notes whose file names contain the query's runes in order are ranked.
+func (db *DB) Titles(query string, limit int) ([]string, error) {
+	var filenames []string
+
+	err := db.Select(&filenames, `SELECT filename FROM documents WHERE filename LIKE ? ESCAPE '\'`, fuzzyLikePattern(query))
+
+	if err != nil {
+		logger.Printf("DB.Titles: %v\n", err)
+		return nil, errors.WithStack(err)
+	}
+
+	var (
+		titles []string
+		seen   = map[string]bool{}
+	)
+
+	for _, filename := range filenames {
+		title := (&FileRef{Filename: filename}).DisplayName()
+
+		if !seen[title] {
+			seen[title] = true
+			titles = append(titles, title)
+		}
+	}
+
+	return fuzzyFilter(query, titles, limit), nil
+}
+
+// Rename updates the filename of an indexed document.
+func (db *DB) Rename(fileRef *FileRef, filename string) error {
+	if _, err := db.Exec(`UPDATE documents SET filename = ? WHERE id = ?`, filename, fileRef.DocumentID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := db.Exec(`UPDATE content_index SET filename = ? WHERE document_id = ?`, filename, fileRef.DocumentID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fileRef.Filename = filename
+	return nil
+}
+
+// SetPinned pins a document to the top of the results, or unpins it.
+func (db *DB) SetPinned(fileRef *FileRef, pinned bool) error {
+	_, err := db.Exec(`UPDATE documents SET pinned = ? WHERE id = ?`, pinned, fileRef.DocumentID)
+	return errors.WithStack(err)
+}
+
+// IsPinned returns true if a document is pinned.
+func (db *DB) IsPinned(fileRef *FileRef) (bool, error) {
+	var pinned bool
+
+	err := db.Get(&pinned, `SELECT pinned FROM documents WHERE id = ?`, fileRef.DocumentID)
+	return pinned, errors.WithStack(err)
+}
 
+// Invalidate marks every document as modified, so that the next refresh
+// indexes it again.
+func (db *DB) Invalidate() error {
+	_, err := db.Exec(`UPDATE documents SET md5 = ''`)
 	return errors.WithStack(err)
 }
 
@@ -324,8 +817,8 @@ func (db *DB) PruneFileRefs(refs []*FileRef) error {
 }
 
 // ftsMatchString converts an expression into a wildcard match.
-// Each term is quoted, so as to accept non-word characters
-// without blowing up SQLite's query parser.
+// Each term is quoted (doubling any embedded quotes), so as to accept
+// non-word characters without blowing up SQLite's query parser.
 //
 // Examples:
 //
@@ -336,7 +829,7 @@ func ftsMatchString(text string) string {
 	sb := []string{}
 
 	for _, part := range strings.Split(text, " ") {
-		sb = append(sb, fmt.Sprintf(`"%s"*`, part))
+		sb = append(sb, fmt.Sprintf(`"%s"*`, strings.ReplaceAll(part, `"`, `""`)))
 	}
 
 	return strings.Join(sb, " ")
@@ -230,3 +230,180 @@ func checkIsUnmodified(t *testing.T, db *DB, fileRef *FileRef) {
 		t.Errorf("expected file '%s' to appear in index", fileRef.Filename)
 	}
 }
+
+func TestPinnedDocuments(t *testing.T) {
+	withNewDB(func(db *DB) {
+		var (
+			modified = time.Now()
+			old      = &FileRef{Filename: "/tmp/old.md", MD5: "a", ModifiedAt: modified.Add(-time.Hour)}
+			recent   = &FileRef{Filename: "/tmp/recent.md", MD5: "b", ModifiedAt: modified}
+		)
+
+		for _, ref := range []*FileRef{old, recent} {
+			if !assert.NoError(t, db.Upsert(ref, []byte("shared text"))) {
+				return
+			}
+		}
+
+		old, _ = db.GetFileRef(old.Filename)
+		assert.NoError(t, db.SetPinned(old, true))
+
+		names := func(results []*SearchResult, err error) []string {
+			assert.NoError(t, err)
+
+			var names []string
+			for _, result := range results {
+				if result.Pinned {
+					names = append(names, "*"+result.DisplayName())
+				} else {
+					names = append(names, result.DisplayName())
+				}
+			}
+			return names
+		}
+
+		assert.Equal(t, []string{"*old", "recent"}, names(db.Recent(20)))
+		assert.Equal(t, []string{"*old", "recent"}, names(db.Search("shared")))
+		assert.Equal(t, []string{"*old"}, names(db.Search("is:pinned")))
+		assert.Equal(t, []string{"*old"}, names(db.Search("is:pinned shared")))
+
+		// pins are kept when renaming
+		assert.NoError(t, db.Rename(old, "/tmp/renamed.md"))
+		assert.Equal(t, []string{"*renamed"}, names(db.Search("is:pinned")))
+
+		assert.NoError(t, db.SetPinned(old, false))
+		assert.Empty(t, names(db.Search("is:pinned")))
+	})
+}
+
+func TestSearchRank(t *testing.T) {
+	withNewDB(func(db *DB) {
+		notes := []struct{ name, text string }{
+			{"weak", "a long note about many things, and once about apples among them"},
+			{"strong", "apples apples apples"},
+			{"pinned", "a pinned note about pears, and apples too"},
+		}
+
+		for _, note := range notes {
+			ref := &FileRef{Filename: "/tmp/" + note.name + ".md", MD5: note.name, ModifiedAt: time.Now()}
+			if !assert.NoError(t, db.Upsert(ref, []byte(note.text))) {
+				return
+			}
+		}
+
+		pinned, _ := db.GetFileRef("/tmp/pinned.md")
+		assert.NoError(t, db.SetPinned(pinned, true))
+
+		results, err := db.Search("apples")
+		assert.NoError(t, err)
+
+		var names []string
+		for _, result := range results {
+			names = append(names, result.DisplayName())
+		}
+
+		// pinned notes come first, then the best matches
+		assert.Equal(t, []string{"pinned", "strong", "weak"}, names)
+	})
+}
+
+func TestTitles(t *testing.T) {
+	withNewDB(func(db *DB) {
+		for _, name := range []string{"Zebra in zoo", "work/Zoo", "home/Zoo", "Écoles", "cats_100%"} {
+			ref := &FileRef{Filename: "/tmp/notes/" + name + ".md", MD5: name, ModifiedAt: time.Now()}
+			if !assert.NoError(t, db.Upsert(ref, []byte("text"))) {
+				return
+			}
+		}
+
+		titles := func(query string) []string {
+			titles, err := db.Titles(query, 10)
+			assert.NoError(t, err)
+			return titles
+		}
+
+		assert.Equal(t, []string{"Zoo", "Zebra in zoo"}, titles("ZOO"))
+		assert.Equal(t, []string{"Écoles"}, titles("éco"))
+		assert.Equal(t, []string{"cats_100%"}, titles("s_%"))
+		assert.Empty(t, titles("work"), "folders are not part of names")
+		assert.Len(t, titles(""), 4)
+	})
+}
+
+func TestPinnedColumnAdded(t *testing.T) {
+	withNewDBPath(func(db *DB, dbPath string) {
+		// simulate a database created before notes could be pinned
+		_, err := db.Exec(`ALTER TABLE documents DROP COLUMN pinned`)
+		if !assert.NoError(t, err) {
+			return
+		}
+		db.Close()
+
+		db = MustOpen(dbPath)
+		defer db.Close()
+
+		ref := &FileRef{Filename: "/tmp/note.md", MD5: "abc", ModifiedAt: time.Now()}
+		assert.NoError(t, db.Upsert(ref, []byte("text")))
+
+		ref, _ = db.GetFileRef(ref.Filename)
+		pinned, err := db.IsPinned(ref)
+		assert.NoError(t, err)
+		assert.False(t, pinned)
+	})
+}
+
+func TestSearchMatchOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		query    string
+		expected int
+	}{
+		{name: "finds first match", data: "intro\nmore\nthe plan is here, plan", query: "plan", expected: 15},
+		{name: "finds stemmed match", data: "one two\nrunning late", query: "run", expected: 8},
+		{name: "counts bytes after front matter", data: "---\ntitle: Plan\n---\ncafé au lait plan", query: "plan", expected: 14},
+		{name: "matches only name", data: "nothing here", query: "apollo", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withNewDB(func(db *DB) {
+				ref := &FileRef{
+					Filename:   "/tmp/notes/apollo.md",
+					MD5:        "b9fe6c5ee4966accc23e32adea6f537d",
+					ModifiedAt: time.Now(),
+				}
+
+				assert.NoError(t, db.Upsert(ref, []byte(tt.data)))
+
+				results, err := db.Search(tt.query)
+				assert.NoError(t, err)
+
+				if assert.Len(t, results, 1) {
+					assert.Equal(t, tt.expected, results[0].MatchOffset)
+				}
+			})
+		})
+	}
+}
+
+func TestSearchSnippetLength(t *testing.T) {
+	withNewDB(func(db *DB) {
+		ref := &FileRef{
+			Filename:   "/tmp/notes/apollo.md",
+			MD5:        "b9fe6c5ee4966accc23e32adea6f537d",
+			ModifiedAt: time.Now(),
+		}
+
+		assert.NoError(t, db.Upsert(ref, []byte("one two three four five six seven eight nine ten eleven plan")))
+
+		db.SnippetLength = 3
+
+		results, err := db.Search("plan")
+		assert.NoError(t, err)
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "...ten eleven "+matchStart+"plan"+matchEnd, results[0].Snippet)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package nve
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineStart returns the position of the start of the line containing pos.
+func lineStart(text string, pos int) int {
+	return strings.LastIndexByte(text[:pos], '\n') + 1
+}
+
+// lineEnd returns the position of the newline ending the line containing
+// pos, or the end of the text for the last line.
+func lineEnd(text string, pos int) int {
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(text)
+}
+
+// firstNonBlank returns the position of the first character of the line
+// containing pos that is not a space.
+func firstNonBlank(text string, pos int) int {
+	start, end := lineStart(text, pos), lineEnd(text, pos)
+
+	if i := strings.IndexFunc(text[start:end], func(r rune) bool { return !unicode.IsSpace(r) }); i >= 0 {
+		return start + i
+	}
+	return end
+}
+
+// nextRune returns the position after the rune at pos.
+func nextRune(text string, pos int) int {
+	if pos >= len(text) {
+		return len(text)
+	}
+	_, size := utf8.DecodeRuneInString(text[pos:])
+	return pos + size
+}
+
+// runeClass groups runes the way vi moves between words: spaces, word
+// characters, and punctuation.
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// nextWordStart returns the position of the start of the word after pos,
+// skipping the rest of the word at pos and any spaces, like vi's 'w'.
+func nextWordStart(text string, pos int) int {
+	if pos >= len(text) {
+		return len(text)
+	}
+
+	r, _ := utf8.DecodeRuneInString(text[pos:])
+
+	for class := runeClass(r); pos < len(text) && class != 0; pos = nextRune(text, pos) {
+		if r, _ = utf8.DecodeRuneInString(text[pos:]); runeClass(r) != class {
+			break
+		}
+	}
+
+	for ; pos < len(text); pos = nextRune(text, pos) {
+		if r, _ = utf8.DecodeRuneInString(text[pos:]); runeClass(r) != 0 {
+			break
+		}
+	}
+
+	return pos
+}
+
+// wordEnd returns the position after the end of the word at or after pos,
+// like emacs' 'forward-word'.
+func wordEnd(text string, pos int) int {
+	inWord := false
+
+	for ; pos < len(text); pos = nextRune(text, pos) {
+		r, _ := utf8.DecodeRuneInString(text[pos:])
+
+		if isWord := runeClass(r) == 1; inWord && !isWord {
+			break
+		} else if isWord {
+			inWord = true
+		}
+	}
+
+	return pos
+}
@@ -0,0 +1,31 @@
+package nve
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DefaultEditor is the editor used when neither $VISUAL nor $EDITOR is set.
+const DefaultEditor = "vi"
+
+// EditorCommand returns the command editing a file in the user's editor,
+// as set by $VISUAL or $EDITOR. The editor may include arguments, such as
+// 'code --wait'.
+func EditorCommand(filename string) *exec.Cmd {
+	editor := DefaultEditor
+
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			editor = value
+			break
+		}
+	}
+
+	args := append(strings.Fields(editor), filename)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return cmd
+}
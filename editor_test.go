@@ -0,0 +1,19 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{DefaultEditor, "note.md"}, EditorCommand("note.md").Args)
+
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, []string{"nano", "note.md"}, EditorCommand("note.md").Args)
+
+	t.Setenv("VISUAL", "code --wait")
+	assert.Equal(t, []string{"code", "--wait", "note.md"}, EditorCommand("note.md").Args)
+}
@@ -0,0 +1,76 @@
+package nve
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExportFilename returns the name of a zip file exporting notes at the given
+// time, e.g. 'nve-export-20240701-150405.zip'.
+func ExportFilename(t time.Time) string {
+	return "nve-export-" + t.Format("20060102-150405") + ".zip"
+}
+
+// Export writes every note to a zip archive, keeping the folders notes are
+// in. Returns the number of notes written.
+func (n *Notes) Export(w io.Writer) (int, error) {
+	refs, err := n.db.GetAllFileRefs()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n.ExportNotes(w, refs)
+}
+
+// ExportNotes writes the given notes to a zip archive, the way Export does.
+func (n *Notes) ExportNotes(w io.Writer, refs []*FileRef) (int, error) {
+	archive := zip.NewWriter(w)
+
+	for _, ref := range refs {
+		if err := n.exportFile(archive, ref); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return len(refs), nil
+}
+
+func (n *Notes) exportFile(archive *zip.Writer, ref *FileRef) error {
+	name, err := filepath.Rel(n.config.Filepath, ref.Filename)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	f, err := os.Open(ref.Filename)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+
+	header := &zip.FileHeader{
+		Name:     filepath.ToSlash(name),
+		Method:   zip.Deflate,
+		Modified: ref.ModifiedAt,
+	}
+
+	entry, err := archive.CreateHeader(header)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = io.Copy(entry, f)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,139 @@
+package nve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// maxFilenameLength is the longest file name (in bytes, without extension)
+// given to new notes, leaving room for an extension and collision suffix
+// within the usual 255 byte limit.
+const maxFilenameLength = 200
+
+var (
+	// unsafeFilenameChars are not allowed in file names by at least one
+	// common platform or file syncing service.
+	unsafeFilenameChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f\x7f]`)
+
+	// reservedFilenames are device names which cannot be used as file names
+	// on Windows, with or without an extension.
+	reservedFilenames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])(\..*)?$`)
+)
+
+// sanitizeFilename turns a note title into a name which is safe to use as
+// a file name on all platforms. Returns an empty string if nothing is left.
+func sanitizeFilename(name string) string {
+	name = unsafeFilenameChars.ReplaceAllString(name, "-")
+
+	// leading dots hide files, trailing dots and spaces are dropped by Windows
+	name = strings.TrimLeft(name, ". ")
+	name = strings.TrimRight(name, ". ")
+
+	if reservedFilenames.MatchString(name) {
+		name = "_" + name
+	}
+
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = strings.TrimRight(name[:len(name)-size], ". ")
+	}
+
+	return name
+}
+
+// notePath resolves the name of a new note (e.g. 'folder/My Note.txt') into
+// the path of its file, sanitizing every part of it. Names without a
+// supported extension are given the configured default one. Returns the
+// note's title, which is the name as typed without folders or extension.
+func (n *Notes) notePath(name string) (string, string, error) {
+	var (
+		parts = strings.Split(filepath.ToSlash(name), "/")
+		title = strings.TrimSpace(parts[len(parts)-1])
+		ext   = n.config.Extension
+		dirs  = []string{n.config.Filepath}
+	)
+
+	if e := filepath.Ext(title); SUPPORTED_FILETYPES[strings.ToLower(e)] {
+		ext = e
+		title = strings.TrimSuffix(title, e)
+	}
+
+	for _, dir := range parts[:len(parts)-1] {
+		// empty, '.' and '..' parts are dropped, keeping notes within the
+		// notes directory
+		if dir = sanitizeFilename(strings.TrimSpace(dir)); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	base := sanitizeFilename(title)
+
+	if base == "" {
+		return "", "", errors.Errorf("note has no name: %s", name)
+	}
+
+	return filepath.Join(append(dirs, base+ext)...), title, nil
+}
+
+// uniquePath returns the path of the note titled title, given the path its
+// title was sanitized into. As different titles may be sanitized into the
+// same file name, an existing file with another front matter title gets a
+// numbered path instead (e.g. 'a-b 2.md').
+func uniquePath(path, title string) string {
+	var (
+		ext  = filepath.Ext(path)
+		base = strings.TrimSuffix(path, ext)
+	)
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+
+		if fm, _ := parseFrontMatter(GetContent(path)); fm.Title == title {
+			return path
+		}
+
+		path = fmt.Sprintf("%s %d%s", base, i, ext)
+	}
+}
+
+// withTitle adds a title to the front matter of content, creating the front
+// matter if needed, unless it already has one. The given cursor offset is
+// returned adjusted to the new content.
+func withTitle(content string, cursor int, title string) (string, int) {
+	var (
+		_, _, delim = splitFrontMatter(content)
+		fm, _       = parseFrontMatter(content)
+		insert      string
+		at          int
+	)
+
+	switch delim {
+	case "":
+		insert = fmt.Sprintf("---\ntitle: %s\n---\n", strconv.QuoteToGraphic(title))
+	case "+++":
+		insert = fmt.Sprintf("title = %s\n", strconv.QuoteToGraphic(title))
+		at = strings.Index(content, "\n") + 1
+	default:
+		insert = fmt.Sprintf("title: %s\n", strconv.QuoteToGraphic(title))
+		at = strings.Index(content, "\n") + 1
+	}
+
+	if fm.Title != "" {
+		return content, cursor
+	}
+
+	if cursor >= at {
+		cursor += len(insert)
+	}
+
+	return content[:at] + insert + content[at:], cursor
+}
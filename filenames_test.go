@@ -0,0 +1,157 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "keeps safe names", input: "Meeting notes", expected: "Meeting notes"},
+		{name: "keeps unicode", input: "Café ☕", expected: "Café ☕"},
+		{name: "replaces unsafe characters", input: `a:b/c\d*e?f"g<h>i|j`, expected: "a-b-c-d-e-f-g-h-i-j"},
+		{name: "replaces control characters", input: "tab\there", expected: "tab-here"},
+		{name: "drops leading dots", input: "..hidden", expected: "hidden"},
+		{name: "drops trailing dots and spaces", input: "etc. ", expected: "etc"},
+		{name: "escapes reserved names", input: "con", expected: "_con"},
+		{name: "escapes reserved names with extension", input: "LPT1.backup", expected: "_LPT1.backup"},
+		{name: "keeps names containing reserved names", input: "console", expected: "console"},
+		{name: "nothing left", input: "...", expected: ""},
+		{name: "truncates long names", input: strings.Repeat("é", 150), expected: strings.Repeat("é", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeFilename(tt.input))
+		})
+	}
+}
+
+func TestNotePath(t *testing.T) {
+	n := &Notes{config: NotesConfig{Filepath: "notes", Extension: ".md"}}
+
+	tests := []struct {
+		name          string
+		input         string
+		expectedPath  string
+		expectedTitle string
+	}{
+		{name: "default extension", input: "todo", expectedPath: "notes/todo.md", expectedTitle: "todo"},
+		{name: "explicit extension", input: "todo.txt", expectedPath: "notes/todo.txt", expectedTitle: "todo"},
+		{name: "unsupported extension", input: "v1.2", expectedPath: "notes/v1.2.md", expectedTitle: "v1.2"},
+		{name: "folders", input: "work/todo", expectedPath: "notes/work/todo.md", expectedTitle: "todo"},
+		{name: "sanitized title", input: "Q3: plans?", expectedPath: "notes/Q3- plans-.md", expectedTitle: "Q3: plans?"},
+		{name: "stays within notes", input: "../../etc/passwd", expectedPath: "notes/etc/passwd.md", expectedTitle: "passwd"},
+		{name: "absolute names", input: "/tmp/x", expectedPath: "notes/tmp/x.md", expectedTitle: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, title, err := n.notePath(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expectedPath), path)
+			assert.Equal(t, tt.expectedTitle, title)
+		})
+	}
+
+	for _, input := range []string{"", "work/", ".md", "..."} {
+		_, _, err := n.notePath(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestWithTitle(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		cursor         int
+		expected       string
+		expectedCursor int
+	}{
+		{
+			name:           "adds front matter",
+			content:        "body",
+			cursor:         4,
+			expected:       "---\ntitle: \"a:b\"\n---\nbody",
+			expectedCursor: 25,
+		},
+		{
+			name:           "adds title to YAML front matter",
+			content:        "---\ntags: x\n---\nbody",
+			cursor:         16,
+			expected:       "---\ntitle: \"a:b\"\ntags: x\n---\nbody",
+			expectedCursor: 29,
+		},
+		{
+			name:           "adds title to TOML front matter",
+			content:        "+++\ntags = \"x\"\n+++\n",
+			cursor:         0,
+			expected:       "+++\ntitle = \"a:b\"\ntags = \"x\"\n+++\n",
+			expectedCursor: 0,
+		},
+		{
+			name:           "keeps existing title",
+			content:        "---\ntitle: mine\n---\n",
+			cursor:         3,
+			expected:       "---\ntitle: mine\n---\n",
+			expectedCursor: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, cursor := withTitle(tt.content, tt.cursor, "a:b")
+			assert.Equal(t, tt.expected, content)
+			assert.Equal(t, tt.expectedCursor, cursor)
+
+			fm, _ := parseFrontMatter(content)
+			assert.NotEmpty(t, fm.Title)
+		})
+	}
+}
+
+func TestCreateNoteSanitized(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	ref, err := n.CreateNote("a:b")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "a-b.md"), ref.Filename)
+	assert.Equal(t, "---\ntitle: \"a:b\"\n---\n", GetContent(ref.Filename))
+
+	// the same title opens the same note
+	again, err := n.CreateNote("a:b")
+	require.NoError(t, err)
+	assert.Equal(t, ref.DocumentID, again.DocumentID)
+
+	// another title sanitized into the same name gets its own note
+	other, err := n.CreateNote("a?b")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "a-b 2.md"), other.Filename)
+
+	// both are listed by their titles
+	results, err := n.db.Search("a:b")
+	require.NoError(t, err)
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Name())
+	}
+	assert.ElementsMatch(t, []string{"a:b", "a?b"}, names)
+
+	// explicit extensions are kept
+	txt, err := n.CreateNote("plain.txt")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "plain.txt"), txt.Filename)
+
+	_, err = os.Stat(filepath.Join(dir, "plain.txt.md"))
+	assert.True(t, os.IsNotExist(err))
+}
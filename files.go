@@ -52,6 +52,11 @@ func scanDirectory(dirname string) ([]string, error) {
 			return err
 		}
 
+		// skip nve's own config directory (templates, etc.)
+		if info.IsDir() && info.Name() == configDirName {
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() && SUPPORTED_FILETYPES[filepath.Ext(path)] {
 			files = append(files, path)
 		}
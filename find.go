@@ -0,0 +1,94 @@
+package nve
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FindOptions are how text is found within a note: as a regular expression
+// rather than literally, and matching case or not.
+type FindOptions struct {
+	Regex         bool
+	CaseSensitive bool
+}
+
+// findPattern returns the regular expression matching query.
+func findPattern(query string, options FindOptions) (*regexp.Regexp, error) {
+	if !options.Regex {
+		query = regexp.QuoteMeta(query)
+	}
+
+	if !options.CaseSensitive {
+		query = "(?i)" + query
+	}
+
+	re, err := regexp.Compile(query)
+	return re, errors.Wrap(err, "invalid regular expression")
+}
+
+// FindMatches returns the start and end offsets of the matches of query in
+// text, along with those of any groups of a regular expression. Empty
+// matches are left out, as there is nothing to select or replace.
+func FindMatches(text, query string, options FindOptions) ([][]int, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	re, err := findPattern(query, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var matches [][]int
+
+	for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+		if match[1] > match[0] {
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
+
+// Replacement returns the text replacing a match. For regular expressions,
+// $1 or ${name} in replacement are expanded to the groups of the match.
+func Replacement(text, query, replacement string, match []int, options FindOptions) (string, error) {
+	if !options.Regex {
+		return replacement, nil
+	}
+
+	re, err := findPattern(query, options)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(re.ExpandString(nil, replacement, text, match)), nil
+}
+
+// ReplaceMatches returns text with all matches replaced.
+func ReplaceMatches(text, query, replacement string, matches [][]int, options FindOptions) (string, error) {
+	var (
+		sb   strings.Builder
+		last int
+	)
+
+	for _, match := range matches {
+		replaced, err := Replacement(text, query, replacement, match, options)
+
+		if err != nil {
+			return text, err
+		}
+
+		sb.WriteString(text[last:match[0]])
+		sb.WriteString(replaced)
+		last = match[1]
+	}
+
+	sb.WriteString(text[last:])
+
+	return sb.String(), nil
+}
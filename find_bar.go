@@ -0,0 +1,156 @@
+package nve
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// findStatusWidth is the width of the options and match count shown right
+// of the fields.
+const findStatusWidth = 22
+
+// FindBar is a single line with the text to find within a note and its
+// replacement, drawn on top of the content box while finding.
+type FindBar struct {
+	*tview.Flex
+	find      *tview.InputField
+	replace   *tview.InputField
+	status    *tview.TextView
+	options   FindOptions
+	replacing bool
+}
+
+func NewFindBar() *FindBar {
+	bar := FindBar{
+		Flex:    tview.NewFlex(),
+		find:    tview.NewInputField(),
+		replace: tview.NewInputField(),
+		status:  tview.NewTextView(),
+	}
+
+	for _, field := range []*tview.InputField{bar.find, bar.replace} {
+		field.SetFieldBackgroundColor(Colors.SelectedBackground).
+			SetFieldTextColor(Colors.SelectedText).
+			SetLabelColor(Colors.Title).
+			SetBackgroundColor(Colors.Background)
+	}
+
+	bar.find.SetLabel(" Find: ")
+	bar.replace.SetLabel(" Replace: ")
+
+	bar.status.SetDynamicColors(true).
+		SetTextAlign(tview.AlignRight).
+		SetBackgroundColor(Colors.Background)
+
+	bar.AddItem(bar.find, 0, 1, true).
+		AddItem(bar.replace, 0, 1, false).
+		AddItem(bar.status, findStatusWidth, 0, false)
+
+	return &bar
+}
+
+// Show starts finding text, which may be empty.
+func (b *FindBar) Show(query string) {
+	b.find.SetText(query)
+	b.replacing = false
+}
+
+// Query returns the text to find.
+func (b *FindBar) Query() string {
+	return b.find.GetText()
+}
+
+// ReplaceText returns the text replacing matches.
+func (b *FindBar) ReplaceText() string {
+	return b.replace.GetText()
+}
+
+// Options returns how text is found.
+func (b *FindBar) Options() FindOptions {
+	return b.options
+}
+
+// ToggleRegex switches between finding text literally and as a regular
+// expression.
+func (b *FindBar) ToggleRegex() {
+	b.options.Regex = !b.options.Regex
+}
+
+// ToggleCase switches between matching case and ignoring it.
+func (b *FindBar) ToggleCase() {
+	b.options.CaseSensitive = !b.options.CaseSensitive
+}
+
+// SwitchField moves between the text to find and its replacement.
+func (b *FindBar) SwitchField() {
+	b.replacing = !b.replacing
+}
+
+// SetStatus shows the options, along with which match (counting from 0, or
+// -1 for none) of all matches is selected, or why the text cannot be found.
+func (b *FindBar) SetStatus(current, total int, err error) {
+	var status string
+
+	switch {
+	case err != nil:
+		status = colorTag(Colors.Error, "") + "invalid regex" + "[-]"
+	case b.Query() == "":
+	case total == 0:
+		status = "no matches"
+	case current < 0:
+		status = fmt.Sprintf("%d found", total)
+	default:
+		status = fmt.Sprintf("%d of %d", current+1, total)
+	}
+
+	b.setStatus(status)
+}
+
+// SetReplaced shows how many matches were replaced.
+func (b *FindBar) SetReplaced(count int) {
+	b.setStatus(fmt.Sprintf("%d replaced", count))
+}
+
+// setStatus shows a status after the options, which are highlighted when
+// turned on.
+func (b *FindBar) setStatus(status string) {
+	option := func(label string, on bool) string {
+		if on {
+			return colorTag(Colors.Accent, "b") + label + "[-::-]"
+		}
+		return colorTag(Colors.Muted, "") + label + "[-]"
+	}
+
+	b.status.SetText(option(".*", b.options.Regex) + " " + option("Aa", b.options.CaseSensitive) + " " + status + " ")
+}
+
+// DrawAt draws the bar on one line, with the cursor in the field being
+// edited.
+func (b *FindBar) DrawAt(screen tcell.Screen, x, y, width int) {
+	active, inactive := b.find, b.replace
+	if b.replacing {
+		active, inactive = b.replace, b.find
+	}
+
+	inactive.Blur()
+	active.Focus(nil)
+
+	b.SetRect(x, y, width, 1)
+	b.Draw(screen)
+}
+
+// InputHandler types into the field being edited.
+func (b *FindBar) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		field := b.find
+		if b.replacing {
+			field = b.replace
+		}
+
+		if handler := field.InputHandler(); handler != nil {
+			handler(event, setFocus)
+		}
+	})
+}
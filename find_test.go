@@ -0,0 +1,118 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		options  FindOptions
+		expected [][]int
+		err      bool
+	}{
+		{name: "ignores case", text: "One one", query: "one", expected: [][]int{{0, 3}, {4, 7}}},
+		{name: "matches case", text: "One one", query: "one", options: FindOptions{CaseSensitive: true}, expected: [][]int{{4, 7}}},
+		{name: "finds text literally", text: "a.c abc", query: "a.c", expected: [][]int{{0, 3}}},
+		{name: "finds regular expression", text: "a.c abc", query: "a.c", options: FindOptions{Regex: true}, expected: [][]int{{0, 3}, {4, 7}}},
+		{name: "skips empty matches", text: "ab", query: "x*", options: FindOptions{Regex: true}},
+		{name: "finds nothing without query", text: "ab", query: ""},
+		{name: "rejects invalid regular expression", text: "ab", query: "(", options: FindOptions{Regex: true}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := FindMatches(tt.text, tt.query, tt.options)
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var actual [][]int
+			for _, match := range matches {
+				actual = append(actual, match[:2])
+			}
+
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestReplaceMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		query       string
+		replacement string
+		options     FindOptions
+		expected    string
+	}{
+		{name: "replaces text", text: "one two One", query: "one", replacement: "1", expected: "1 two 1"},
+		{name: "keeps dollar signs of text", text: "cost: x", query: "x", replacement: "$1", expected: "cost: $1"},
+		{name: "expands groups", text: "a-b c-d", query: `(\w)-(\w)`, replacement: "$2-$1", options: FindOptions{Regex: true}, expected: "b-a d-c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := FindMatches(tt.text, tt.query, tt.options)
+			require.NoError(t, err)
+
+			actual, err := ReplaceMatches(tt.text, tt.query, tt.replacement, matches, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestContentBoxFind(t *testing.T) {
+	const text = "one two one two"
+
+	tests := []struct {
+		name      string
+		text      string
+		keys      string
+		expected  string
+		selection []int
+		finding   bool
+	}{
+		{name: "selects first match after cursor", keys: "Ctrl-F t w o", selection: []int{4, 7}, finding: true},
+		{name: "selects next match, wrapping around", keys: "Ctrl-F t w o Enter Enter", selection: []int{4, 7}, finding: true},
+		{name: "selects previous match, wrapping around", keys: "Ctrl-F t w o Up", selection: []int{12, 15}, finding: true},
+		{name: "matches case", text: "One one", keys: "Ctrl-F o n e Alt-c", selection: []int{4, 7}, finding: true},
+		{name: "finds regular expression", keys: "Ctrl-F t . o Alt-r", selection: []int{4, 7}, finding: true},
+		{name: "finds selected text", keys: "Ctrl-F t w o Esc Ctrl-F Enter", selection: []int{12, 15}, finding: true},
+		{name: "closes leaving match selected", keys: "Ctrl-F t w o Esc", selection: []int{4, 7}},
+		{name: "replaces match and selects next", keys: "Ctrl-F o n e Tab 1 Ctrl-R", expected: "1 two one two", selection: []int{6, 9}, finding: true},
+		{name: "replaces all matches", keys: "Ctrl-F o n e Tab 1 Alt-a", expected: "1 two 1 two", selection: []int{7, 7}, finding: true},
+		{name: "selects match on a later line", text: "cats and\ndogs and cats", keys: "Ctrl-F c a t s Enter", selection: []int{18, 22}, finding: true},
+		{name: "replaces all matches across lines", text: "cats and\ndogs and cats", keys: "Ctrl-F c a t s Tab b i r d s Alt-a", expected: "birds and\ndogs and birds", selection: []int{24, 24}, finding: true},
+		{name: "undoes replacing all at once", keys: "Ctrl-F o n e Tab 1 Alt-a Esc Ctrl-Z", expected: text, selection: []int{11, 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.text == "" {
+				tt.text = text
+			}
+			if tt.expected == "" {
+				tt.expected = tt.text
+			}
+
+			box := pressKeys(t, DefaultKeymap(), tt.text, 0, tt.keys)
+
+			_, start, end := box.GetSelection()
+
+			assert.Equal(t, tt.expected, box.GetText())
+			assert.Equal(t, tt.selection, []int{start, end})
+			assert.Equal(t, tt.finding, box.IsFinding())
+		})
+	}
+}
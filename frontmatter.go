@@ -0,0 +1,196 @@
+package nve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the structured metadata at the start of a note, written
+// either as YAML (delimited by '---') or TOML (delimited by '+++').
+type FrontMatter struct {
+	Title   string
+	Created string
+	Tags    []string
+	Aliases []string
+}
+
+// Names returns the title and aliases a note may be referred to by.
+func (fm FrontMatter) Names() []string {
+	var names []string
+
+	if fm.Title != "" {
+		names = append(names, fm.Title)
+	}
+
+	return append(names, fm.Aliases...)
+}
+
+// parseFrontMatter parses any front matter of text, returning it along with
+// the remaining body. Malformed front matter is treated as empty.
+func parseFrontMatter(text string) (FrontMatter, string) {
+	var (
+		fm  FrontMatter
+		raw map[string]interface{}
+	)
+
+	block, body, delim := splitFrontMatter(text)
+
+	switch delim {
+	case "---":
+		if err := yaml.Unmarshal([]byte(block), &raw); err != nil {
+			return fm, body
+		}
+	case "+++":
+		raw = parseTOML(block)
+	default:
+		return fm, body
+	}
+
+	fm.Title = scalarString(raw["title"])
+	fm.Created = scalarString(raw["created"])
+	fm.Aliases = stringList(raw["aliases"])
+
+	// tags may also be separated by spaces
+	for _, tag := range stringList(raw["tags"]) {
+		fm.Tags = append(fm.Tags, strings.Fields(tag)...)
+	}
+
+	return fm, body
+}
+
+// splitFrontMatter separates a leading front matter block, delimited by
+// '---' (YAML) or '+++' (TOML) lines, from the rest of the text. The
+// returned delimiter is empty if there is no front matter.
+func splitFrontMatter(text string) (string, string, string) {
+	var delim string
+
+	for _, d := range []string{"---", "+++"} {
+		if strings.HasPrefix(text, d+"\n") || strings.HasPrefix(text, d+"\r\n") {
+			delim = d
+		}
+	}
+
+	if delim == "" {
+		return "", text, ""
+	}
+
+	rest := text[strings.Index(text, "\n")+1:]
+
+	for offset := 0; offset < len(rest); {
+		end := strings.Index(rest[offset:], "\n")
+		if end < 0 {
+			end = len(rest) - offset
+		}
+
+		if line := strings.TrimRight(rest[offset:offset+end], "\r"); line == delim {
+			body := ""
+			if offset+end < len(rest) {
+				body = rest[offset+end+1:]
+			}
+			return rest[:offset], body, delim
+		}
+
+		offset += end + 1
+	}
+
+	return "", text, ""
+}
+
+// parseTOML reads the subset of TOML used by front matter: one 'key = value'
+// pair per line, where value is a string, bare scalar or single-line array.
+func parseTOML(block string) map[string]interface{} {
+	res := map[string]interface{}{}
+
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			var items []interface{}
+
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquoteTOML(item); item != "" {
+					items = append(items, item)
+				}
+			}
+
+			res[key] = items
+		} else {
+			res[key] = unquoteTOML(value)
+		}
+	}
+
+	return res
+}
+
+func unquoteTOML(value string) string {
+	value = strings.TrimSpace(value)
+
+	// basic strings may contain escapes, much like Go's
+	if strings.HasPrefix(value, `"`) {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+// scalarString formats a single front matter value as a string.
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// stringList reads a front matter value given either as a list or as a
+// comma separated string.
+func stringList(v interface{}) []string {
+	var res []string
+
+	switch v := v.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s := scalarString(item); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+
+	return res
+}
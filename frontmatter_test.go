@@ -0,0 +1,162 @@
+package nve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		frontMatter string
+		body        string
+		delim       string
+	}{
+		{
+			name:  "no front matter",
+			input: "just text",
+			body:  "just text",
+		},
+		{
+			name:        "yaml front matter and body",
+			input:       "---\ntitle: x\n---\nbody",
+			frontMatter: "title: x\n",
+			body:        "body",
+			delim:       "---",
+		},
+		{
+			name:        "toml front matter and body",
+			input:       "+++\ntitle = 'x'\n+++\r\nbody",
+			frontMatter: "title = 'x'\n",
+			body:        "body",
+			delim:       "+++",
+		},
+		{
+			name:        "front matter without body",
+			input:       "---\ntitle: x\n---",
+			frontMatter: "title: x\n",
+			delim:       "---",
+		},
+		{
+			name:  "unterminated front matter",
+			input: "---\ntitle: x\n",
+			body:  "---\ntitle: x\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frontMatter, body, delim := splitFrontMatter(tc.input)
+			assert.Equal(t, tc.frontMatter, frontMatter)
+			assert.Equal(t, tc.body, body)
+			assert.Equal(t, tc.delim, delim)
+		})
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected FrontMatter
+		body     string
+	}{
+		{
+			name: "yaml",
+			input: "---\ntitle: Q3 Planning\ncreated: 2024-07-01\ntags: [work, planning]\n" +
+				"aliases:\n  - Q3\n  - Quarterly plan\n---\nbody",
+			expected: FrontMatter{
+				Title:   "Q3 Planning",
+				Created: "2024-07-01",
+				Tags:    []string{"work", "planning"},
+				Aliases: []string{"Q3", "Quarterly plan"},
+			},
+			body: "body",
+		},
+		{
+			name: "toml",
+			input: "+++\ntitle = \"Q3 Planning\"\ncreated = 2024-07-01\ntags = [\"work\", 'planning']\n" +
+				"aliases = \"Q3, Quarterly plan\"\n+++\nbody",
+			expected: FrontMatter{
+				Title:   "Q3 Planning",
+				Created: "2024-07-01",
+				Tags:    []string{"work", "planning"},
+				Aliases: []string{"Q3", "Quarterly plan"},
+			},
+			body: "body",
+		},
+		{
+			name:  "malformed yaml is ignored",
+			input: "---\ntitle: [unclosed\n---\nbody",
+			body:  "body",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frontMatter, body := parseFrontMatter(tc.input)
+			assert.Equal(t, tc.expected, frontMatter)
+			assert.Equal(t, tc.body, body)
+		})
+	}
+}
+
+func TestFrontMatterIndexing(t *testing.T) {
+	withNewDB(func(db *DB) {
+		ref := &FileRef{Filename: "/tmp/2024-07-01.md", MD5: "abc", ModifiedAt: time.Now()}
+		text := "---\ntitle: Q3 Planning\naliases: [roadmap]\n---\nagenda items"
+		require.NoError(t, db.Upsert(ref, []byte(text)))
+
+		// title and aliases are searchable as names
+		for _, query := range []string{"planning", "roadmap"} {
+			results, err := db.Search(query)
+			require.NoError(t, err)
+
+			if assert.Len(t, results, 1, query) {
+				assert.Equal(t, "Q3 Planning", results[0].Name())
+			}
+		}
+
+		// front matter is not indexed as body text, nor used as a snippet
+		results, err := db.Search("aliases")
+		require.NoError(t, err)
+		assert.Len(t, results, 0)
+
+		results, err = db.Recent(10)
+		require.NoError(t, err)
+
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "agenda items", results[0].Snippet)
+		}
+
+		meta, err := db.Metadata(ref.DocumentID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"title": {"Q3 Planning"}, "alias": {"roadmap"}}, meta)
+	})
+}
+
+func TestMigrationRebuildsIndex(t *testing.T) {
+	withNewDBPath(func(db *DB, dbPath string) {
+		ref := &FileRef{Filename: "/tmp/note.md", MD5: "abc", ModifiedAt: time.Now()}
+		require.NoError(t, db.Upsert(ref, []byte("some text")))
+
+		// simulate a database written by an older version
+		_, err := db.Exec(`PRAGMA user_version = 1`)
+		require.NoError(t, err)
+		db.Close()
+
+		db = MustOpen(dbPath)
+		defer db.Close()
+
+		assert.False(t, db.IsUnmodified(ref), "document should be re-indexed")
+		require.NoError(t, db.Upsert(ref, []byte("some text")))
+
+		results, err := db.Search("some")
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+}
@@ -0,0 +1,126 @@
+package nve
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fuzzyScore reports whether all runes of pattern appear in text, in order
+// and case-insensitively. Higher scores are better: consecutive runes and
+// runes at the start of a word are rewarded.
+func fuzzyScore(pattern, text string) (int, bool) {
+	var (
+		p     = []rune(strings.ToLower(pattern))
+		t     = []rune(strings.ToLower(text))
+		best  = 0
+		found = false
+	)
+
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	// try every occurrence of the first rune, keeping the best match
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+
+		if score, ok := fuzzyScoreFrom(p, t, start); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+
+	return best, found
+}
+
+func fuzzyScoreFrom(p, t []rune, start int) (int, bool) {
+	var (
+		score = 0
+		pi    = 0
+		prev  = -2
+	)
+
+	for ti := start; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+
+		score++
+
+		if ti == prev+1 {
+			score += 2
+		}
+
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3
+		}
+
+		prev = ti
+		pi++
+	}
+
+	return score, pi == len(p)
+}
+
+// fuzzyLikePattern returns a LIKE pattern matching at least the texts which
+// fuzzyScore matches pattern in, e.g. '%a%b%' for 'ab'. LIKE ignores the case
+// of ASCII letters only, so any other rune matches any one character.
+func fuzzyLikePattern(pattern string) string {
+	var sb strings.Builder
+
+	sb.WriteString("%")
+
+	for _, r := range pattern {
+		if r < utf8.RuneSelf {
+			sb.WriteString(escapeLike(string(r)))
+		} else {
+			sb.WriteString("_")
+		}
+		sb.WriteString("%")
+	}
+
+	return sb.String()
+}
+
+// fuzzyFilter returns up to limit candidates matching pattern, best first.
+// Ties are broken by shorter, then alphabetically earlier, candidates.
+func fuzzyFilter(pattern string, candidates []string, limit int) []string {
+	type scored struct {
+		text  string
+		score int
+	}
+
+	var matches []scored
+
+	for _, c := range candidates {
+		if score, ok := fuzzyScore(pattern, c); ok {
+			matches = append(matches, scored{c, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if len(a.text) != len(b.text) {
+			return len(a.text) < len(b.text)
+		}
+		return strings.ToLower(a.text) < strings.ToLower(b.text)
+	})
+
+	res := make([]string, 0, limit)
+
+	for _, m := range matches {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+		res = append(res, m.text)
+	}
+
+	return res
+}
@@ -0,0 +1,69 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []string{"apples in zoo", "bananas_in_zoo", "cats", "zebra in zoo"}
+
+	testCases := []struct {
+		name     string
+		pattern  string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "empty pattern matches everything",
+			pattern:  "",
+			expected: []string{"cats", "zebra in zoo", "apples in zoo", "bananas_in_zoo"},
+		},
+		{
+			name:     "matches subsequences case-insensitively",
+			pattern:  "ZBZ",
+			expected: []string{"zebra in zoo"},
+		},
+		{
+			name:     "prefers word starts and consecutive runes",
+			pattern:  "zoo",
+			expected: []string{"zebra in zoo", "apples in zoo", "bananas_in_zoo"},
+		},
+		{
+			name:     "respects limit",
+			pattern:  "a",
+			limit:    2,
+			expected: []string{"apples in zoo", "cats"},
+		},
+		{
+			name:     "no match",
+			pattern:  "xyz",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fuzzyFilter(tc.pattern, candidates, tc.limit))
+		})
+	}
+}
+
+func TestFuzzyLikePattern(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "", expected: "%"},
+		{pattern: "ZbZ", expected: "%Z%b%Z%"},
+		{pattern: `a_%\`, expected: `%a%\_%\%%\\%`},
+		{pattern: "café", expected: "%c%a%f%_%"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fuzzyLikePattern(tc.pattern))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package nve
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// HelpBoxWidth and HelpBoxHeight are the size of the help overlay, which
+	// scrolls if the keymap does not fit.
+	HelpBoxWidth  = 72
+	HelpBoxHeight = 24
+)
+
+// HelpBox is a modal overlay listing all keybindings of a keymap.
+type HelpBox struct {
+	*tview.TextView
+	doneFunc func()
+}
+
+func NewHelpBox() *HelpBox {
+	box := HelpBox{
+		TextView: tview.NewTextView(),
+	}
+
+	box.SetDynamicColors(true).
+		SetWrap(false).
+		SetBackgroundColor(Colors.Background)
+
+	box.SetBorder(true).
+		SetTitle("Keys (Esc to close)").
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.SetDoneFunc(func(key tcell.Key) {
+		box.finish()
+	})
+
+	return &box
+}
+
+// SetClosedFunc sets a handler called when the overlay is closed.
+func (b *HelpBox) SetClosedFunc(handler func()) *HelpBox {
+	b.doneFunc = handler
+	return b
+}
+
+// Show fills the overlay with the bindings of a keymap, grouped by context.
+func (b *HelpBox) Show(km *Keymap) {
+	b.SetText(formatKeymap(km))
+	b.ScrollToBeginning()
+}
+
+// formatKeymap lists the bindings of a keymap, one per line, under a header
+// for each context.
+func formatKeymap(km *Keymap) string {
+	var sb strings.Builder
+
+	for _, context := range KeyContexts {
+		bindings := km.Bindings(context)
+
+		// contexts of modal keymaps are not used by others
+		if len(bindings) == 0 {
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+
+		fmt.Fprintf(&sb, "%s%s[-::-]\n", colorTag(Colors.Title, "b"), context.Title())
+
+		for _, binding := range bindings {
+			fmt.Fprintf(&sb, "  %s%-18s[-] %s\n", colorTag(Colors.Accent, ""), tview.Escape(formatKeys(binding.Keys)), tview.Escape(binding.Description))
+		}
+	}
+
+	// the key other editors show all commands with cannot be told apart
+	// from Ctrl-P by most terminals
+	if keys := km.KeysOf(ActionCommandPalette); len(keys) > 0 {
+		fmt.Fprintf(&sb, "\nCtrl-Shift-P is not sent by most terminals: %s show all commands.\n", tview.Escape(formatKeys(keys)))
+	}
+
+	return sb.String()
+}
+
+// formatKeys joins the names of key sequences, e.g. 'Down, Ctrl-N'.
+func formatKeys(keys []KeySequence) string {
+	names := make([]string, 0, len(keys))
+
+	for _, seq := range keys {
+		names = append(names, seq.String())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// InputHandler closes the overlay on the keys showing it, and otherwise
+// scrolls the list.
+func (b *HelpBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if action, _ := Keys.Lookup(ContextGlobal, event); action == ActionHelp {
+			b.finish()
+			return
+		}
+
+		if action, _ := Keys.Lookup(ContextList, event); action == ActionHelp || event.Rune() == 'q' {
+			b.finish()
+			return
+		}
+
+		if handler := b.TextView.InputHandler(); handler != nil {
+			handler(event, setFocus)
+		}
+	})
+}
+
+// MouseHandler scrolls the list, keeping the keys closing the overlay.
+func (b *HelpBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return keepFocus(b, b.TextView.MouseHandler(), nil)
+}
+
+func (b *HelpBox) finish() {
+	if b.doneFunc != nil {
+		b.doneFunc()
+	}
+}
@@ -0,0 +1,275 @@
+package nve
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// matchStart and matchEnd surround the words of snippets and passages
+// matching a search (char(1) and char(2) in SQL).
+const (
+	matchStart = "\x01"
+	matchEnd   = "\x02"
+)
+
+// token is a word of text as split by the 'unicode61' tokenizer of FTS5,
+// along with its stem.
+type token struct {
+	start, end int
+	stem       string
+}
+
+// searchTerm is a term of a search as FTS5 matches it (see ftsMatchString):
+// a phrase of stemmed tokens, the last of which matches as a prefix.
+type searchTerm []string
+
+// searchTerms returns the terms of the full-text part of a search, leaving
+// out filters.
+func searchTerms(query string) []searchTerm {
+	var terms []searchTerm
+
+	for _, part := range strings.Split(parseQuery(query).terms, " ") {
+		var term searchTerm
+
+		for _, t := range tokenize(part) {
+			term = append(term, t.stem)
+		}
+
+		if len(term) > 0 {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// tokenize splits text into words of letters and numbers, as indexed.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		start  = -1
+	)
+
+	for i, r := range text {
+		switch {
+		case isTokenRune(r) && start < 0:
+			start = i
+		case !isTokenRune(r) && start >= 0:
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+
+	return tokens
+}
+
+func newToken(text string, start, end int) token {
+	return token{start: start, end: end, stem: porterStem(foldToken(text[start:end]))}
+}
+
+// isTokenRune returns true if r is part of a word. Combining marks are kept
+// with the letters they modify.
+func isTokenRune(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.N, unicode.Co, unicode.Mn)
+}
+
+// foldToken lower-cases a word and removes its diacritics, so that 'Café'
+// matches 'cafe' as it does when searching.
+func foldToken(word string) string {
+	var sb strings.Builder
+
+	for _, r := range norm.NFD.String(word) {
+		if !unicode.Is(unicode.Mn, r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return sb.String()
+}
+
+// matchTerms returns the start and end offsets of the words of text
+// matching any of the terms of a search, e.g. 'running' for 'run'. Matches
+// of different terms may overlap.
+func matchTerms(text string, terms []searchTerm) [][]int {
+	var (
+		tokens  = tokenize(text)
+		matches [][]int
+	)
+
+	for i := range tokens {
+		for _, term := range terms {
+			if matchesTerm(tokens[i:], term) {
+				matches = append(matches, []int{tokens[i].start, tokens[i+len(term)-1].end})
+			}
+		}
+	}
+
+	return matches
+}
+
+// matchesTerm returns true if tokens start with the phrase of a term.
+func matchesTerm(tokens []token, term searchTerm) bool {
+	if len(tokens) < len(term) {
+		return false
+	}
+
+	last := len(term) - 1
+
+	for i, stem := range term[:last] {
+		if tokens[i].stem != stem {
+			return false
+		}
+	}
+
+	return strings.HasPrefix(tokens[last].stem, term[last])
+}
+
+// passage is a run of words of a text, along with the matches within it.
+type passage struct {
+	first, last int // indices of its first and last token
+	matches     [][]int
+	terms       int // number of different terms matched
+}
+
+// passages returns up to count passages of length words of text, around
+// the words matching the terms of a search, marked by matchStart and
+// matchEnd. Passages matching more of the terms come first, then those
+// matching more words, then those found first, the way FTS5 picks the best
+// snippet of a note. Passages do not overlap.
+func passages(text string, terms []searchTerm, count, length int) []string {
+	var (
+		tokens     = tokenize(text)
+		candidates []passage
+		res        []string
+	)
+
+	if len(tokens) == 0 || length <= 0 {
+		return nil
+	}
+
+	for _, match := range matchTerms(text, terms) {
+		// a passage starts a few words before its first match
+		first := sort.Search(len(tokens), func(i int) bool { return tokens[i].start >= match[0] }) - length/4
+
+		if first > len(tokens)-length {
+			first = len(tokens) - length
+		}
+		if first < 0 {
+			first = 0
+		}
+
+		last := first + length - 1
+		if last >= len(tokens) {
+			last = len(tokens) - 1
+		}
+
+		candidates = append(candidates, newPassage(text, tokens, terms, first, last))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+
+		if a.terms != b.terms {
+			return a.terms > b.terms
+		}
+		if len(a.matches) != len(b.matches) {
+			return len(a.matches) > len(b.matches)
+		}
+		return a.first < b.first
+	})
+
+	var picked []passage
+
+	for _, p := range candidates {
+		if len(picked) == count {
+			break
+		}
+
+		if !overlapsAny(p, picked) {
+			picked = append(picked, p)
+		}
+	}
+
+	for _, p := range picked {
+		res = append(res, p.text(text, tokens))
+	}
+
+	return res
+}
+
+func newPassage(text string, tokens []token, terms []searchTerm, first, last int) passage {
+	p := passage{first: first, last: last}
+	start, end := tokens[first].start, tokens[last].end
+
+	for _, term := range terms {
+		found := false
+
+		for _, match := range matchTerms(text[start:end], []searchTerm{term}) {
+			p.matches = append(p.matches, []int{start + match[0], start + match[1]})
+			found = true
+		}
+
+		if found {
+			p.terms++
+		}
+	}
+
+	sort.Slice(p.matches, func(i, j int) bool { return p.matches[i][0] < p.matches[j][0] })
+	return p
+}
+
+func overlapsAny(p passage, others []passage) bool {
+	for _, other := range others {
+		if p.first <= other.last && other.first <= p.last {
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the words of the passage on a single line, with its matches
+// marked, and '...' where the text goes on.
+func (p passage) text(text string, tokens []token) string {
+	var (
+		sb  strings.Builder
+		pos = tokens[p.first].start
+		end = tokens[p.last].end
+	)
+
+	// the whole text is shown up to its start and end, such as a last period
+	if p.first == 0 {
+		pos = 0
+	} else {
+		sb.WriteString("...")
+	}
+
+	if p.last == len(tokens)-1 {
+		end = len(text)
+	}
+
+	for _, match := range p.matches {
+		if match[0] < pos {
+			// matches of different terms may overlap
+			continue
+		}
+
+		sb.WriteString(text[pos:match[0]])
+		sb.WriteString(matchStart + text[match[0]:match[1]] + matchEnd)
+		pos = match[1]
+	}
+
+	sb.WriteString(text[pos:end])
+
+	if p.last < len(tokens)-1 {
+		sb.WriteString("...")
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
@@ -0,0 +1,270 @@
+package nve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorterStem(t *testing.T) {
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"running":        "run",
+		"electrical":     "electr",
+		"adjustable":     "adjust",
+		"controlling":    "control",
+		"roll":           "roll",
+		"at":             "at",
+	}
+
+	for word, stem := range tests {
+		assert.Equal(t, stem, porterStem(word), word)
+	}
+}
+
+func TestMatchTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		text     string
+		expected []string
+	}{
+		{name: "highlights each word", query: "foo bar", text: "bar then foo", expected: []string{"bar", "foo"}},
+		{name: "highlights words by prefix", query: "fo", text: "The food fight", expected: []string{"food"}},
+		{name: "highlights stemmed words", query: "run", text: "Running, he ran the runs", expected: []string{"Running", "runs"}},
+		{name: "stems the query", query: "connections", text: "connected", expected: []string{"connected"}},
+		{name: "highlights phrases", query: "foo-bar", text: "foo bar, foo-bar, foo baz", expected: []string{"foo bar", "foo-bar"}},
+		{name: "ignores case and diacritics", query: "cafe", text: "Café CAFE", expected: []string{"Café", "CAFE"}},
+		{name: "ignores filters", query: "tag:work in:notes plan", text: "work notes plan", expected: []string{"plan"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matched []string
+
+			for _, match := range matchTerms(tt.text, searchTerms(tt.query)) {
+				matched = append(matched, tt.text[match[0]:match[1]])
+			}
+
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+func TestPassages(t *testing.T) {
+	mark := func(word string) string { return matchStart + word + matchEnd }
+
+	text := "The plan starts here. Nothing to see for a while, then more words follow. " +
+		"A second plan with the budget attached. Filler text goes on and on. " +
+		"The budget closes out the note."
+
+	tests := []struct {
+		name     string
+		query    string
+		count    int
+		length   int
+		expected []string
+	}{
+		{
+			name:   "best passages first",
+			query:  "plan budget",
+			count:  3,
+			length: 6,
+			expected: []string{
+				"...second " + mark("plan") + " with the " + mark("budget") + " attached...",
+				"The " + mark("plan") + " starts here. Nothing to...",
+				"...The " + mark("budget") + " closes out the note.",
+			},
+		},
+		{
+			name:     "at most count passages",
+			query:    "plan",
+			count:    1,
+			length:   4,
+			expected: []string{"The " + mark("plan") + " starts here..."},
+		},
+		{
+			name:     "whole text when shorter than length",
+			query:    "note",
+			count:    3,
+			length:   100,
+			expected: []string{strings.Replace(text, "note", mark("note"), 1)},
+		},
+		{
+			name:  "no passages without a match",
+			query: "apollo",
+			count: 3, length: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, passages(text, searchTerms(tt.query), tt.count, tt.length))
+		})
+	}
+}
+
+// TestMatchTermsLikeSearch checks that the notes found by a search are
+// those in which its words are highlighted.
+func TestMatchTermsLikeSearch(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	texts := map[string]string{
+		"runner":  "The runner kept running",
+		"cafe":    "Meet at the Café tomorrow",
+		"phrase":  "a foo-bar b",
+		"apart":   "foo then bar",
+		"connect": "connections were connected",
+		"unicode": "日本語のノート about sushi",
+	}
+
+	for name, text := range texts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name+".md"), []byte(text), 0644))
+	}
+
+	_, err := n.Refresh()
+	require.NoError(t, err)
+
+	for _, query := range []string{"run", "runn", "runners", "cafe", "foo bar", "foo-bar", "connect", "connecting", "sush", "日本語"} {
+		t.Run(query, func(t *testing.T) {
+			results, err := n.db.Search(query)
+			require.NoError(t, err)
+
+			var found, highlighted []string
+
+			for _, result := range results {
+				found = append(found, result.DisplayName())
+			}
+
+			for name, text := range texts {
+				if matchesAllTerms(text, searchTerms(query)) {
+					highlighted = append(highlighted, name)
+				}
+			}
+
+			sort.Strings(found)
+			sort.Strings(highlighted)
+
+			assert.Equal(t, found, highlighted)
+		})
+	}
+}
+
+// matchesAllTerms returns true if every term of a search matches text, as
+// FTS5 requires.
+func matchesAllTerms(text string, terms []searchTerm) bool {
+	for _, term := range terms {
+		if len(matchTerms(text, []searchTerm{term})) == 0 {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+func TestContentBoxHighlights(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	require.NoError(t, screen.Init())
+	screen.SetSize(40, 5)
+
+	filename := filepath.Join(t.TempDir(), "note.md")
+	require.NoError(t, os.WriteFile(filename, []byte("日本語 running\nnaïve runs"), 0644))
+
+	box := NewContentBox()
+	box.SetFile(&FileRef{Filename: filename})
+	box.SetSearchQuery("run naive")
+	box.SetRect(0, 0, 40, 5)
+	box.Draw(screen)
+
+	// highlighted returns the text of the highlighted cells of a row
+	highlighted := func(row int) string {
+		var text string
+		for col := 0; col < 40; col++ {
+			mainc, _, style, _ := screen.GetContent(col, row)
+			if style == Colors.HighlightStyle() {
+				text += string(mainc)
+			}
+		}
+		return text
+	}
+
+	assert.Equal(t, "running", highlighted(2), "wide runes take up two columns")
+	assert.Equal(t, "naïveruns", highlighted(3))
+}
+
+func TestContentBoxSetResult(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	require.NoError(t, screen.Init())
+
+	var lines []string
+	for i := 1; i <= 50; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines[39] = "the plan is here"
+
+	text := "---\ntitle: Plans\n---\n" + strings.Join(lines, "\n")
+	filename := filepath.Join(t.TempDir(), "plans.md")
+	require.NoError(t, os.WriteFile(filename, []byte(text), 0644))
+
+	box := NewContentBox()
+	box.SetRect(0, 0, 80, 12)
+	box.Draw(screen)
+
+	t.Run("moves cursor to first match", func(t *testing.T) {
+		_, body, _ := splitFrontMatter(text)
+
+		box.SetResult(&SearchResult{FileRef: &FileRef{Filename: filename}, MatchOffset: strings.Index(body, "plan")})
+		box.Draw(screen)
+
+		_, start, _ := box.GetSelection()
+		assert.Equal(t, strings.Index(text, "plan"), start)
+
+		row, _, _, _ := box.GetCursor()
+		offset, _ := box.GetOffset()
+		assert.Equal(t, 42, row)
+		assert.True(t, offset <= row && row < offset+10, "match is scrolled into view")
+	})
+
+	t.Run("does not scroll to a match in view", func(t *testing.T) {
+		_, body, _ := splitFrontMatter(text)
+
+		box.SetResult(&SearchResult{FileRef: &FileRef{Filename: filename}, MatchOffset: strings.Index(body, "line 3")})
+		box.Draw(screen)
+
+		row, _, _, _ := box.GetCursor()
+		offset, _ := box.GetOffset()
+		assert.Equal(t, 5, row)
+		assert.Equal(t, 0, offset)
+	})
+
+	t.Run("stays at top without a match in the text", func(t *testing.T) {
+		box.SetResult(&SearchResult{FileRef: &FileRef{Filename: filename}})
+		box.Draw(screen)
+
+		_, start, _ := box.GetSelection()
+		offset, _ := box.GetOffset()
+		assert.Equal(t, 0, start)
+		assert.Equal(t, 0, offset)
+	})
+}
@@ -0,0 +1,40 @@
+package nve
+
+// History lists the notes opened most recently, most recent first.
+type History struct {
+	refs  []*FileRef
+	limit int
+}
+
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Visit moves a note to the front of the history, dropping the oldest note
+// once the history is full.
+func (h *History) Visit(ref *FileRef) {
+	h.Forget(ref)
+	h.refs = append([]*FileRef{ref}, h.refs...)
+
+	if len(h.refs) > h.limit {
+		h.refs = h.refs[:h.limit]
+	}
+}
+
+// Forget removes a note from the history, e.g. once it was deleted.
+func (h *History) Forget(ref *FileRef) {
+	kept := h.refs[:0]
+
+	for _, visited := range h.refs {
+		if visited.Filename != ref.Filename {
+			kept = append(kept, visited)
+		}
+	}
+
+	h.refs = kept
+}
+
+// Notes returns the notes in the history, most recently opened first.
+func (h *History) Notes() []*FileRef {
+	return h.refs
+}
@@ -0,0 +1,40 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	var (
+		history = NewHistory(3)
+		refs    = map[string]*FileRef{}
+	)
+
+	visit := func(names ...string) {
+		for _, name := range names {
+			if refs[name] == nil {
+				refs[name] = &FileRef{Filename: name + ".md"}
+			}
+			history.Visit(refs[name])
+		}
+	}
+
+	names := func() []string {
+		var names []string
+		for _, ref := range history.Notes() {
+			names = append(names, ref.DisplayName())
+		}
+		return names
+	}
+
+	visit("a", "b", "a")
+	assert.Equal(t, []string{"a", "b"}, names())
+
+	visit("c", "d")
+	assert.Equal(t, []string{"d", "c", "a"}, names(), "drops the oldest note")
+
+	history.Forget(&FileRef{Filename: "c.md"})
+	assert.Equal(t, []string{"d", "a"}, names())
+}
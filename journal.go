@@ -0,0 +1,111 @@
+package nve
+
+import (
+	"path/filepath"
+	"time"
+)
+
+const (
+	// JournalDir is the notes subdirectory holding daily notes.
+	JournalDir = "journal"
+
+	// JournalTemplate is applied to new daily notes, falling back to the
+	// default template if it does not exist.
+	JournalTemplate = "journal"
+
+	journalDateFormat = "2006-01-02"
+)
+
+// JournalEntry opens the daily note for the given day, creating it from the
+// journal template if it does not exist yet. Returns the offset at which the
+// cursor should be placed.
+func (n *Notes) JournalEntry(day time.Time) (*FileRef, int, error) {
+	template := JournalTemplate
+
+	if _, ok := n.templateContent(template); !ok {
+		template = DefaultTemplate
+	}
+
+	return n.CreateNoteFromTemplate(filepath.Join(JournalDir, day.Format(journalDateFormat)), template)
+}
+
+// JournalDays returns the days having a daily note, oldest first.
+func (n *Notes) JournalDays() ([]time.Time, error) {
+	refs, err := n.journalEntries()
+
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]time.Time, 0, len(refs))
+
+	for _, ref := range refs {
+		day, _ := n.journalDate(ref)
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// AdjacentJournalEntry returns the daily note before (offset < 0) or after
+// (offset > 0) the given one, skipping days without notes. Returns nil if
+// ref is not a daily note or there is no such entry.
+func (n *Notes) AdjacentJournalEntry(ref *FileRef, offset int) (*FileRef, error) {
+	if _, ok := n.journalDate(ref); !ok || offset == 0 {
+		return nil, nil
+	}
+
+	refs, err := n.journalEntries()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range refs {
+		if entry.Filename != ref.Filename {
+			continue
+		}
+
+		if j := i + offset; j >= 0 && j < len(refs) {
+			return refs[j], nil
+		}
+
+		break
+	}
+
+	return nil, nil
+}
+
+// journalEntries returns all indexed daily notes, oldest first (as
+// 'YYYY-MM-DD' names sort chronologically).
+func (n *Notes) journalEntries() ([]*FileRef, error) {
+	refs, err := n.db.GetAllFileRefs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*FileRef
+
+	for _, ref := range refs {
+		if _, ok := n.journalDate(ref); ok {
+			entries = append(entries, ref)
+		}
+	}
+
+	return entries, nil
+}
+
+// journalDate returns the day of a daily note, i.e. a note named
+// 'YYYY-MM-DD' in the journal directory.
+func (n *Notes) journalDate(ref *FileRef) (time.Time, bool) {
+	dir, err := filepath.Rel(n.config.Filepath, filepath.Dir(ref.Filename))
+
+	if err != nil || dir != JournalDir {
+		return time.Time{}, false
+	}
+
+	day, err := time.ParseInLocation(journalDateFormat, ref.DisplayName(), time.Local)
+
+	return day, err == nil
+}
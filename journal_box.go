@@ -0,0 +1,187 @@
+package nve
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// JournalBoxWidth and JournalBoxHeight fit a month of days, one week per row.
+	JournalBoxWidth  = 7*3 + 3
+	JournalBoxHeight = 6 + 4
+)
+
+// JournalBox is a modal calendar highlighting the days that have daily notes.
+type JournalBox struct {
+	*tview.Box
+	selected   time.Time
+	days       map[string]bool
+	pickedFunc func(day time.Time, picked bool)
+}
+
+func NewJournalBox() *JournalBox {
+	box := JournalBox{
+		Box:  tview.NewBox(),
+		days: map[string]bool{},
+	}
+
+	box.SetBorder(true).
+		SetTitle("Journal").
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	return &box
+}
+
+// SetPickedFunc sets a handler called once a day is picked, or picking was
+// cancelled.
+func (b *JournalBox) SetPickedFunc(handler func(day time.Time, picked bool)) *JournalBox {
+	b.pickedFunc = handler
+	return b
+}
+
+// Show displays the month of the selected day, highlighting the given days.
+func (b *JournalBox) Show(selected time.Time, days []time.Time) {
+	b.selected = selected
+	b.days = map[string]bool{}
+
+	for _, day := range days {
+		b.days[day.Format(journalDateFormat)] = true
+	}
+}
+
+// calendarWeeks lays out the days of a month in weeks starting on Monday,
+// with zero for cells outside the month.
+func calendarWeeks(month time.Time) [][7]int {
+	var (
+		first  = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+		last   = first.AddDate(0, 1, -1).Day()
+		offset = (int(first.Weekday()) + 6) % 7
+		weeks  [][7]int
+	)
+
+	for day := 1; day <= last; day++ {
+		cell := offset + day - 1
+
+		if cell/7 >= len(weeks) {
+			weeks = append(weeks, [7]int{})
+		}
+
+		weeks[cell/7][cell%7] = day
+	}
+
+	return weeks
+}
+
+func (b *JournalBox) Draw(screen tcell.Screen) {
+	b.Box.DrawForSubclass(screen, b)
+	x, y, width, _ := b.GetInnerRect()
+
+	header := b.selected.Format("January 2006")
+	tview.Print(screen, header, x, y, width, tview.AlignCenter, Colors.Title)
+	tview.Print(screen, "Mo Tu We Th Fr Sa Su", x, y+1, width, tview.AlignLeft, Colors.Muted)
+
+	today := now().Format(journalDateFormat)
+
+	for row, week := range calendarWeeks(b.selected) {
+		for col, day := range week {
+			if day == 0 {
+				continue
+			}
+
+			date := time.Date(b.selected.Year(), b.selected.Month(), day, 0, 0, 0, 0, time.Local)
+			key := date.Format(journalDateFormat)
+			style := tcell.StyleDefault.Background(Colors.Background).Foreground(Colors.Muted)
+
+			if b.days[key] {
+				style = style.Foreground(Colors.Accent).Bold(true)
+			}
+			if key == today {
+				style = style.Underline(true)
+			}
+			if day == b.selected.Day() {
+				style = Colors.selected(style)
+			}
+
+			for i, r := range fmt.Sprintf("%2d", day) {
+				screen.SetContent(x+col*3+i, y+2+row, r, nil, style)
+			}
+		}
+	}
+}
+
+func (b *JournalBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			b.selected = b.selected.AddDate(0, 0, -1)
+		case tcell.KeyRight:
+			b.selected = b.selected.AddDate(0, 0, 1)
+		case tcell.KeyUp:
+			b.selected = b.selected.AddDate(0, 0, -7)
+		case tcell.KeyDown:
+			b.selected = b.selected.AddDate(0, 0, 7)
+		case tcell.KeyPgUp:
+			b.selected = b.selected.AddDate(0, -1, 0)
+		case tcell.KeyPgDn:
+			b.selected = b.selected.AddDate(0, 1, 0)
+		case tcell.KeyHome:
+			b.selected = now()
+		case tcell.KeyEnter:
+			b.finish(true)
+		case tcell.KeyEscape:
+			b.finish(false)
+		}
+	})
+}
+
+// MouseHandler selects the day clicked, and picks it on a double click. The
+// wheel moves a month back or forward.
+func (b *JournalBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return b.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		if !b.InRect(event.Position()) {
+			return false, nil
+		}
+
+		switch action {
+		case tview.MouseLeftClick, tview.MouseLeftDoubleClick:
+			if day := b.dayAt(event.Position()); day > 0 {
+				b.selected = time.Date(b.selected.Year(), b.selected.Month(), day, 0, 0, 0, 0, time.Local)
+
+				if action == tview.MouseLeftDoubleClick {
+					b.finish(true)
+				}
+			}
+		case tview.MouseScrollUp:
+			b.selected = b.selected.AddDate(0, -1, 0)
+		case tview.MouseScrollDown:
+			b.selected = b.selected.AddDate(0, 1, 0)
+		}
+
+		return true, nil
+	})
+}
+
+// dayAt returns the day of the month drawn at a position, or 0 if none is.
+func (b *JournalBox) dayAt(x, y int) int {
+	innerX, innerY, _, _ := b.GetInnerRect()
+	weeks := calendarWeeks(b.selected)
+	row, col := y-innerY-2, (x-innerX)/3
+
+	if x < innerX || row < 0 || row >= len(weeks) || col >= len(weeks[row]) {
+		return 0
+	}
+
+	return weeks[row][col]
+}
+
+func (b *JournalBox) finish(picked bool) {
+	if b.pickedFunc != nil {
+		b.pickedFunc(b.selected, picked)
+	}
+}
@@ -0,0 +1,98 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalEntry(t *testing.T) {
+	n, dir := setupTemplates(t, map[string]string{
+		"journal.md": "# {{title}}\n{{cursor}}",
+	})
+
+	day := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+
+	ref, cursor, err := n.JournalEntry(day)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "journal", "2024-07-01.md"), ref.Filename)
+	assert.Equal(t, "# 2024-07-01\n", GetContent(ref.Filename))
+	assert.Equal(t, len("# 2024-07-01\n"), cursor)
+
+	// re-opening returns the same note
+	again, _, err := n.JournalEntry(day)
+	require.NoError(t, err)
+	assert.Equal(t, ref.DocumentID, again.DocumentID)
+}
+
+func TestJournalNavigation(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "journal"), 0755))
+
+	for _, name := range []string{"journal/2024-07-01.md", "journal/2024-07-05.md", "journal/notes.md", "2024-07-03.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("entry"), 0644))
+	}
+
+	_, err := n.Refresh()
+	require.NoError(t, err)
+
+	days, err := n.JournalDays()
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 7, 5, 0, 0, 0, 0, time.Local),
+	}, days)
+
+	first, err := n.db.GetFileRef(filepath.Join(dir, "journal", "2024-07-01.md"))
+	require.NoError(t, err)
+
+	next, err := n.AdjacentJournalEntry(first, 1)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "journal", "2024-07-05.md"), next.Filename)
+
+	prev, err := n.AdjacentJournalEntry(first, -1)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+
+	// notes outside the journal directory are not daily notes
+	other, err := n.db.GetFileRef(filepath.Join(dir, "2024-07-03.md"))
+	require.NoError(t, err)
+
+	next, err = n.AdjacentJournalEntry(other, 1)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+}
+
+func TestCreateNoteWithinNotesDirectory(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	// names merely starting with dots are within the notes directory
+	ref, _, err := n.CreateNoteFromTemplate("..ideas", DefaultTemplate)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(ref.Filename))
+
+	// folders leading outside of it are dropped
+	ref, _, err = n.CreateNoteFromTemplate(filepath.Join("..", "outside"), DefaultTemplate)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(ref.Filename))
+}
+
+func TestCalendarWeeks(t *testing.T) {
+	// July 2024 starts on a Monday and spans five weeks
+	weeks := calendarWeeks(time.Date(2024, 7, 15, 0, 0, 0, 0, time.Local))
+
+	assert.Len(t, weeks, 5)
+	assert.Equal(t, [7]int{1, 2, 3, 4, 5, 6, 7}, weeks[0])
+	assert.Equal(t, [7]int{29, 30, 31, 0, 0, 0, 0}, weeks[4])
+
+	// September 2024 starts on a Sunday
+	weeks = calendarWeeks(time.Date(2024, 9, 1, 0, 0, 0, 0, time.Local))
+
+	assert.Len(t, weeks, 6)
+	assert.Equal(t, [7]int{0, 0, 0, 0, 0, 0, 1}, weeks[0])
+}
@@ -0,0 +1,698 @@
+package nve
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+)
+
+// Action identifies something the user can do with a keybinding.
+type Action string
+
+const (
+	// global
+	ActionHelp            Action = "help"
+	ActionNextPane        Action = "next-pane"
+	ActionFocusSearch     Action = "focus-search"
+	ActionToggleTags      Action = "toggle-tags"
+	ActionToggleFolders   Action = "toggle-folders"
+	ActionScopeFolder     Action = "scope-folder"
+	ActionRename          Action = "rename"
+	ActionNewFromTemplate Action = "new-from-template"
+	ActionJournalToday    Action = "journal-today"
+	ActionJournalCalendar Action = "journal-calendar"
+	ActionJournalPrevious Action = "journal-previous"
+	ActionJournalNext     Action = "journal-next"
+	ActionCommandPalette  Action = "command-palette"
+	ActionToggleLayout    Action = "toggle-layout"
+	ActionGrowList        Action = "grow-list"
+	ActionShrinkList      Action = "shrink-list"
+	ActionZenMode         Action = "zen-mode"
+	ActionTogglePreview   Action = "toggle-preview"
+
+	// global, usually run from the command palette
+	ActionCreateNote    Action = "create-note"
+	ActionDeleteNote    Action = "delete-note"
+	ActionChangeSort    Action = "change-sort"
+	ActionOpenInEditor  Action = "open-in-editor"
+	ActionExport        Action = "export"
+	ActionHistory       Action = "history"
+	ActionReindex       Action = "reindex"
+	ActionReplaceNotes  Action = "replace-in-notes"
+	ActionTrashNotes    Action = "trash-notes"
+	ActionMoveNotes     Action = "move-notes"
+	ActionAddTag        Action = "add-tag"
+	ActionRemoveTag     Action = "remove-tag"
+	ActionMergeNotes    Action = "merge-notes"
+	ActionUnmarkNotes   Action = "unmark-notes"
+	ActionSplitNote     Action = "split-note"
+	ActionSplitHeadings Action = "split-at-headings"
+	ActionUndoChange    Action = "undo-change"
+	ActionCopyNote      Action = "copy-note"
+	ActionCopyPath      Action = "copy-path"
+	ActionCopyLink      Action = "copy-link"
+
+	// search box and list
+	ActionSelectNext     Action = "select-next"
+	ActionSelectPrevious Action = "select-previous"
+	ActionOpenOrCreate   Action = "open-or-create"
+
+	// list
+	ActionOpenNote   Action = "open-note"
+	ActionEditSearch Action = "edit-search"
+	ActionTogglePin  Action = "toggle-pin"
+	ActionPassages   Action = "show-passages"
+	ActionToggleMark Action = "toggle-mark"
+	ActionMarkDown   Action = "mark-down"
+	ActionMarkUp     Action = "mark-up"
+
+	// content: moving the cursor
+	ActionFollowLink    Action = "follow-link"
+	ActionFind          Action = "find"
+	ActionCursorUp      Action = "cursor-up"
+	ActionCursorDown    Action = "cursor-down"
+	ActionCursorLeft    Action = "cursor-left"
+	ActionCursorRight   Action = "cursor-right"
+	ActionWordLeft      Action = "word-left"
+	ActionWordRight     Action = "word-right"
+	ActionNextWord      Action = "next-word"
+	ActionLineStart     Action = "line-start"
+	ActionLineEnd       Action = "line-end"
+	ActionPageUp        Action = "page-up"
+	ActionPageDown      Action = "page-down"
+	ActionDocumentStart Action = "document-start"
+	ActionDocumentEnd   Action = "document-end"
+
+	// content: editing
+	ActionDeleteChar         Action = "delete-char"
+	ActionDeleteBackward     Action = "delete-backward"
+	ActionDeleteWordBackward Action = "delete-word-backward"
+	ActionDeleteEmptyLine    Action = "delete-empty-line"
+	ActionKillLine           Action = "kill-line"
+	ActionDeleteLine         Action = "delete-line"
+	ActionYankLine           Action = "yank-line"
+	ActionSetMark            Action = "set-mark"
+	ActionCancel             Action = "cancel"
+	ActionSelectAll          Action = "select-all"
+	ActionCopy               Action = "copy"
+	ActionCut                Action = "cut"
+	ActionPaste              Action = "paste"
+	ActionPasteBefore        Action = "paste-before"
+	ActionUndo               Action = "undo"
+	ActionRedo               Action = "redo"
+
+	// content: switching modes of a modal keymap
+	ActionNormalMode      Action = "normal-mode"
+	ActionInsertMode      Action = "insert-mode"
+	ActionAppend          Action = "append"
+	ActionAppendLineEnd   Action = "append-line-end"
+	ActionInsertLineStart Action = "insert-line-start"
+	ActionOpenLineBelow   Action = "open-line-below"
+	ActionOpenLineAbove   Action = "open-line-above"
+	ActionVisualMode      Action = "visual-mode"
+
+	// find bar
+	ActionFindNext     Action = "find-next"
+	ActionFindPrevious Action = "find-previous"
+	ActionReplace      Action = "replace"
+	ActionReplaceAll   Action = "replace-all"
+	ActionToggleRegex  Action = "toggle-regex"
+	ActionToggleCase   Action = "toggle-case"
+)
+
+// actionDescriptions describes every action, as listed in the help overlay.
+var actionDescriptions = map[Action]string{
+	ActionHelp:            "Show this help",
+	ActionNextPane:        "Move to the next pane",
+	ActionFocusSearch:     "Clear search and return to search box",
+	ActionToggleTags:      "Show or hide tags",
+	ActionToggleFolders:   "Show or hide folders of notes",
+	ActionScopeFolder:     "Search only the current note's folder",
+	ActionRename:          "Rename note",
+	ActionNewFromTemplate: "Create note from a template",
+	ActionJournalToday:    "Open today's daily note",
+	ActionJournalCalendar: "Pick a daily note from the calendar",
+	ActionJournalPrevious: "Previous daily note",
+	ActionJournalNext:     "Next daily note",
+	ActionCommandPalette:  "Show all commands",
+	ActionToggleLayout:    "Show list above or beside the note",
+	ActionGrowList:        "Make the list larger",
+	ActionShrinkList:      "Make the list smaller",
+	ActionZenMode:         "Show only the note, or all panes",
+	ActionTogglePreview:   "Read note as rendered Markdown, or edit it",
+
+	ActionCreateNote:    "Create note named by the search",
+	ActionDeleteNote:    "Delete note",
+	ActionChangeSort:    "Sort notes by date or by name",
+	ActionOpenInEditor:  "Open note in external editor",
+	ActionExport:        "Export notes to a zip file",
+	ActionHistory:       "Show recently opened notes",
+	ActionReindex:       "Rebuild the search index",
+	ActionReplaceNotes:  "Find and replace in all notes",
+	ActionTrashNotes:    "Move notes to the trash",
+	ActionMoveNotes:     "Move notes to a folder",
+	ActionAddTag:        "Add a tag to notes",
+	ActionRemoveTag:     "Remove a tag from notes",
+	ActionMergeNotes:    "Merge marked notes into the first one",
+	ActionUnmarkNotes:   "Unmark all notes",
+	ActionSplitNote:     "Split note at the cursor into a new note",
+	ActionSplitHeadings: "Split note into a new note for each heading",
+	ActionUndoChange:    "Undo the last merge or split of notes",
+	ActionCopyNote:      "Copy the text of the note",
+	ActionCopyPath:      "Copy the path of the note",
+	ActionCopyLink:      "Copy a [[link]] to the note",
+
+	ActionSelectNext:     "Select next note",
+	ActionSelectPrevious: "Select previous note",
+	ActionOpenOrCreate:   "Open note, or create it if nothing matches",
+
+	ActionOpenNote:   "Edit selected note",
+	ActionEditSearch: "Edit search",
+	ActionTogglePin:  "Pin or unpin note",
+	ActionPassages:   "Show or hide the best matches in the selected note",
+	ActionToggleMark: "Mark or unmark note",
+	ActionMarkDown:   "Mark note and the one below",
+	ActionMarkUp:     "Mark note and the one above",
+
+	ActionFollowLink:    "Follow [[link]] under cursor",
+	ActionFind:          "Find and replace in note",
+	ActionCursorUp:      "Move cursor up",
+	ActionCursorDown:    "Move cursor down",
+	ActionCursorLeft:    "Move cursor left",
+	ActionCursorRight:   "Move cursor right",
+	ActionWordLeft:      "Move to start of word",
+	ActionWordRight:     "Move to end of word",
+	ActionNextWord:      "Move to start of next word",
+	ActionLineStart:     "Move to start of line",
+	ActionLineEnd:       "Move to end of line",
+	ActionPageUp:        "Move up one page",
+	ActionPageDown:      "Move down one page",
+	ActionDocumentStart: "Move to start of note",
+	ActionDocumentEnd:   "Move to end of note",
+
+	ActionDeleteChar:         "Delete character under cursor",
+	ActionDeleteBackward:     "Delete character before cursor",
+	ActionDeleteWordBackward: "Delete word before cursor",
+	ActionDeleteEmptyLine:    "Delete empty line",
+	ActionKillLine:           "Cut to end of line",
+	ActionDeleteLine:         "Cut line",
+	ActionYankLine:           "Copy line",
+	ActionSetMark:            "Start or stop selecting text",
+	ActionCancel:             "Cancel selection",
+	ActionSelectAll:          "Select all text",
+	ActionCopy:               "Copy selection",
+	ActionCut:                "Cut selection",
+	ActionPaste:              "Paste after cursor",
+	ActionPasteBefore:        "Paste before cursor",
+	ActionUndo:               "Undo",
+	ActionRedo:               "Redo",
+
+	ActionNormalMode:      "Return to normal mode",
+	ActionInsertMode:      "Insert before cursor",
+	ActionAppend:          "Insert after cursor",
+	ActionAppendLineEnd:   "Insert at end of line",
+	ActionInsertLineStart: "Insert at start of line",
+	ActionOpenLineBelow:   "Insert on a new line below",
+	ActionOpenLineAbove:   "Insert on a new line above",
+	ActionVisualMode:      "Select text",
+
+	ActionFindNext:     "Select next match",
+	ActionFindPrevious: "Select previous match",
+	ActionReplace:      "Replace match and select the next one",
+	ActionReplaceAll:   "Replace all matches",
+	ActionToggleRegex:  "Find text or a regular expression",
+	ActionToggleCase:   "Match case or ignore it",
+}
+
+// KeyContext is the part of the UI a keybinding applies to. Global bindings
+// apply wherever no overlay is shown.
+type KeyContext string
+
+const (
+	ContextGlobal  KeyContext = "global"
+	ContextSearch  KeyContext = "search"
+	ContextList    KeyContext = "list"
+	ContextContent KeyContext = "content"
+
+	// ContextNormal and ContextVisual are only bound by modal keymaps, where
+	// the content box starts in normal mode and ContextContent applies while
+	// inserting text.
+	ContextNormal KeyContext = "normal"
+	ContextVisual KeyContext = "visual"
+
+	// ContextFind applies while the find bar is shown in the content box.
+	ContextFind KeyContext = "find"
+)
+
+// KeyContexts lists all contexts, in the order they are documented.
+var KeyContexts = []KeyContext{ContextGlobal, ContextSearch, ContextList, ContextContent, ContextNormal, ContextVisual, ContextFind}
+
+// Title returns a human readable name of the context.
+func (c KeyContext) Title() string {
+	switch c {
+	case ContextSearch:
+		return "Search box"
+	case ContextList:
+		return "List"
+	case ContextContent:
+		return "Content"
+	case ContextNormal:
+		return "Content (normal mode)"
+	case ContextVisual:
+		return "Content (visual mode)"
+	case ContextFind:
+		return "Find bar"
+	default:
+		return "Global"
+	}
+}
+
+// Key is a single key press, with modifiers.
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// KeyOf returns the key of a special (non-rune) key press.
+func KeyOf(key tcell.Key, mod tcell.ModMask) Key {
+	return Key{Key: key, Mod: mod}
+}
+
+// RuneKey returns the key typing the given rune.
+func RuneKey(r rune) Key {
+	return Key{Key: tcell.KeyRune, Rune: r}
+}
+
+// KeyFromEvent returns the key pressed in an event, dropping modifiers that
+// are implied by the key itself.
+func KeyFromEvent(event *tcell.EventKey) Key {
+	switch key := event.Key(); {
+	case key == tcell.KeyRune:
+		// shift is implied by the rune typed
+		return Key{Key: key, Rune: event.Rune(), Mod: event.Modifiers() & tcell.ModAlt}
+	case isControlKey(key):
+		// control keys may or may not be reported with the ctrl modifier
+		return KeyOf(key, event.Modifiers()&(tcell.ModAlt|tcell.ModShift))
+	default:
+		return KeyOf(key, event.Modifiers()&(tcell.ModAlt|tcell.ModShift|tcell.ModCtrl))
+	}
+}
+
+func isControlKey(key tcell.Key) bool {
+	return key <= tcell.KeyUS || key == tcell.KeyDEL
+}
+
+// keyNames overrides the names tcell gives to keys sharing a code with
+// another key.
+var keyNames = map[tcell.Key]string{
+	tcell.KeyBS:  "Ctrl-H",
+	tcell.KeyDEL: "Backspace",
+}
+
+// keyAliases are further names accepted in config files.
+var keyAliases = map[string]Key{
+	"escape":    KeyOf(tcell.KeyEsc, 0),
+	"return":    KeyOf(tcell.KeyEnter, 0),
+	"del":       KeyOf(tcell.KeyDelete, 0),
+	"pageup":    KeyOf(tcell.KeyPgUp, 0),
+	"pagedown":  KeyOf(tcell.KeyPgDn, 0),
+	"shift-tab": KeyOf(tcell.KeyBacktab, 0),
+	"space":     RuneKey(' '),
+}
+
+func keyName(key tcell.Key) (string, bool) {
+	if name, ok := keyNames[key]; ok {
+		return name, true
+	}
+
+	name, ok := tcell.KeyNames[key]
+	return name, ok
+}
+
+// String returns the name of the key, e.g. 'Ctrl-T', 'Alt-Enter' or '?'.
+func (k Key) String() string {
+	var sb strings.Builder
+
+	if k.Mod&tcell.ModCtrl != 0 {
+		sb.WriteString("Ctrl-")
+	}
+	if k.Mod&tcell.ModAlt != 0 {
+		sb.WriteString("Alt-")
+	}
+	if k.Mod&tcell.ModShift != 0 {
+		sb.WriteString("Shift-")
+	}
+
+	switch name, ok := keyName(k.Key); {
+	case k.Key == tcell.KeyRune && k.Rune == ' ':
+		sb.WriteString("Space")
+	case k.Key == tcell.KeyRune:
+		sb.WriteRune(k.Rune)
+	case ok:
+		sb.WriteString(name)
+	}
+
+	return sb.String()
+}
+
+// Matches returns true if an event is a press of this key.
+func (k Key) Matches(event *tcell.EventKey) bool {
+	return KeyFromEvent(event) == k
+}
+
+// ParseKey parses the name of a key as returned by Key.String, such as
+// 'Ctrl-T', 'Alt-Enter', 'F5' or 'G'. Names of special keys and modifiers
+// are case-insensitive.
+func ParseKey(name string) (Key, error) {
+	var mod tcell.ModMask
+
+	for rest := name; rest != ""; {
+		if key, ok := lookupKeyName(rest); ok {
+			return withMod(key, mod)
+		}
+
+		if r, size := utf8.DecodeRuneInString(rest); size == len(rest) && r != utf8.RuneError {
+			return withMod(RuneKey(r), mod)
+		}
+
+		prefix, after, _ := strings.Cut(rest, "-")
+
+		switch strings.ToLower(prefix) {
+		case "ctrl":
+			mod |= tcell.ModCtrl
+		case "alt":
+			mod |= tcell.ModAlt
+		case "shift":
+			mod |= tcell.ModShift
+		default:
+			return Key{}, errors.Errorf("unknown key: %s", name)
+		}
+
+		rest = after
+	}
+
+	return Key{}, errors.Errorf("unknown key: %s", name)
+}
+
+// lookupKeyName returns the special key with the given name.
+func lookupKeyName(name string) (Key, bool) {
+	name = strings.ToLower(name)
+
+	if key, ok := keyAliases[name]; ok {
+		return key, true
+	}
+
+	for key, keyName := range keyNames {
+		if strings.ToLower(keyName) == name {
+			return KeyOf(key, 0), true
+		}
+	}
+
+	for key, keyName := range tcell.KeyNames {
+		if _, ok := keyNames[key]; !ok && strings.ToLower(keyName) == name {
+			return KeyOf(key, 0), true
+		}
+	}
+
+	return Key{}, false
+}
+
+// withMod adds modifiers to a key, the same way KeyFromEvent reports them.
+func withMod(key Key, mod tcell.ModMask) (Key, error) {
+	switch {
+	case key.Key != tcell.KeyRune:
+		if isControlKey(key.Key) {
+			mod &^= tcell.ModCtrl
+		}
+	case mod&tcell.ModCtrl != 0:
+		// ctrl with a rune types a control key, e.g. Ctrl-A or Ctrl-_
+		r := unicode.ToUpper(key.Rune)
+		if r == ' ' {
+			r = '@'
+		}
+		if r < '@' || r > '_' {
+			return Key{}, errors.Errorf("unknown key: Ctrl-%c", key.Rune)
+		}
+		key = KeyOf(tcell.KeyCtrlSpace+tcell.Key(r-'@'), 0)
+		mod &^= tcell.ModCtrl
+	default:
+		if mod&tcell.ModShift != 0 {
+			key.Rune = unicode.ToUpper(key.Rune)
+		}
+		mod &= tcell.ModAlt
+	}
+
+	key.Mod = mod
+	return key, nil
+}
+
+// KeySequence is a key press, or several pressed one after another (such
+// as 'g g').
+type KeySequence []Key
+
+// Seq returns a sequence of keys.
+func Seq(keys ...Key) KeySequence {
+	return KeySequence(keys)
+}
+
+// String returns the names of the keys, separated by spaces.
+func (s KeySequence) String() string {
+	names := make([]string, 0, len(s))
+
+	for _, key := range s {
+		names = append(names, key.String())
+	}
+
+	return strings.Join(names, " ")
+}
+
+// ParseKeySequence parses the names of keys separated by spaces, such as
+// 'g g' or 'Ctrl-X u'.
+func ParseKeySequence(text string) (KeySequence, error) {
+	var seq KeySequence
+
+	for _, name := range strings.Fields(text) {
+		key, err := ParseKey(name)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, key)
+	}
+
+	if len(seq) == 0 {
+		return nil, errors.Errorf("no keys given: '%s'", text)
+	}
+
+	return seq, nil
+}
+
+// hasPrefix returns true if the sequence starts with the given keys.
+func (s KeySequence) hasPrefix(keys []Key) bool {
+	if len(keys) > len(s) {
+		return false
+	}
+
+	for i, key := range keys {
+		if s[i] != key {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Binding maps keys to an action within a context.
+type Binding struct {
+	Action      Action
+	Context     KeyContext
+	Keys        []KeySequence
+	Description string
+}
+
+// Keymap is the registry of all keybindings. Widgets look up the action of
+// a key press here rather than checking for keys themselves.
+type Keymap struct {
+	bindings []*Binding
+}
+
+// Keys is the keymap consulted by all widgets.
+var Keys = DefaultKeymap()
+
+// DefaultKeymap returns the built-in keybindings.
+func DefaultKeymap() *Keymap {
+	var (
+		km  = &Keymap{}
+		key = func(key tcell.Key, mod tcell.ModMask) KeySequence { return Seq(KeyOf(key, mod)) }
+	)
+
+	km.Bind(ContextGlobal, ActionHelp, key(tcell.KeyF1, 0))
+	km.Bind(ContextGlobal, ActionNextPane, key(tcell.KeyTab, 0))
+	km.Bind(ContextGlobal, ActionFocusSearch, key(tcell.KeyEscape, 0))
+	km.Bind(ContextGlobal, ActionToggleTags, key(tcell.KeyCtrlT, 0))
+	km.Bind(ContextGlobal, ActionToggleFolders, key(tcell.KeyF3, 0))
+	km.Bind(ContextGlobal, ActionScopeFolder, key(tcell.KeyF4, 0))
+	km.Bind(ContextGlobal, ActionRename, key(tcell.KeyF2, 0))
+	km.Bind(ContextGlobal, ActionNewFromTemplate, key(tcell.KeyEnter, tcell.ModAlt))
+	km.Bind(ContextGlobal, ActionJournalToday, key(tcell.KeyF5, 0))
+	km.Bind(ContextGlobal, ActionJournalCalendar, key(tcell.KeyF6, 0))
+	km.Bind(ContextGlobal, ActionJournalPrevious, key(tcell.KeyLeft, tcell.ModAlt))
+	km.Bind(ContextGlobal, ActionJournalNext, key(tcell.KeyRight, tcell.ModAlt))
+	km.Bind(ContextGlobal, ActionCommandPalette, key(tcell.KeyF8, 0), Seq(Key{Key: tcell.KeyRune, Rune: 'x', Mod: tcell.ModAlt}))
+	km.Bind(ContextGlobal, ActionToggleLayout, key(tcell.KeyF7, 0))
+	km.Bind(ContextGlobal, ActionGrowList, Seq(Key{Key: tcell.KeyRune, Rune: '=', Mod: tcell.ModAlt}))
+	km.Bind(ContextGlobal, ActionShrinkList, Seq(Key{Key: tcell.KeyRune, Rune: '-', Mod: tcell.ModAlt}))
+	km.Bind(ContextGlobal, ActionZenMode, Seq(Key{Key: tcell.KeyRune, Rune: 'z', Mod: tcell.ModAlt}))
+	km.Bind(ContextGlobal, ActionTogglePreview, Seq(Key{Key: tcell.KeyRune, Rune: 'p', Mod: tcell.ModAlt}))
+
+	km.Bind(ContextSearch, ActionSelectNext, key(tcell.KeyDown, 0), key(tcell.KeyCtrlN, 0))
+	km.Bind(ContextSearch, ActionSelectPrevious, key(tcell.KeyUp, 0), key(tcell.KeyCtrlP, 0))
+	km.Bind(ContextSearch, ActionOpenOrCreate, key(tcell.KeyEnter, 0))
+
+	km.Bind(ContextList, ActionSelectNext, key(tcell.KeyDown, 0), key(tcell.KeyCtrlN, 0))
+	km.Bind(ContextList, ActionSelectPrevious, key(tcell.KeyUp, 0), key(tcell.KeyCtrlP, 0))
+	km.Bind(ContextList, ActionOpenNote, key(tcell.KeyEnter, 0))
+	km.Bind(ContextList, ActionEditSearch, key(tcell.KeyLeft, 0))
+	km.Bind(ContextList, ActionTogglePin, key(tcell.KeyCtrlS, 0))
+	km.Bind(ContextList, ActionPassages, key(tcell.KeyRight, 0))
+	km.Bind(ContextList, ActionToggleMark, Seq(RuneKey(' ')))
+	km.Bind(ContextList, ActionMarkDown, key(tcell.KeyDown, tcell.ModShift))
+	km.Bind(ContextList, ActionMarkUp, key(tcell.KeyUp, tcell.ModShift))
+	km.Bind(ContextList, ActionHelp, Seq(RuneKey('?')))
+
+	km.Bind(ContextContent, ActionFollowLink, key(tcell.KeyCtrlRightSq, 0))
+	km.Bind(ContextContent, ActionCursorUp, key(tcell.KeyCtrlP, 0))
+	km.Bind(ContextContent, ActionCursorDown, key(tcell.KeyCtrlN, 0))
+	km.Bind(ContextContent, ActionFind, key(tcell.KeyCtrlF, 0))
+	km.Bind(ContextContent, ActionDeleteEmptyLine, key(tcell.KeyCtrlK, 0))
+
+	km.Bind(ContextFind, ActionFindNext, key(tcell.KeyEnter, 0), key(tcell.KeyDown, 0))
+	km.Bind(ContextFind, ActionFindPrevious, key(tcell.KeyUp, 0))
+	km.Bind(ContextFind, ActionReplace, key(tcell.KeyCtrlR, 0))
+	km.Bind(ContextFind, ActionReplaceAll, Seq(Key{Key: tcell.KeyRune, Rune: 'a', Mod: tcell.ModAlt}))
+	km.Bind(ContextFind, ActionToggleRegex, Seq(Key{Key: tcell.KeyRune, Rune: 'r', Mod: tcell.ModAlt}))
+	km.Bind(ContextFind, ActionToggleCase, Seq(Key{Key: tcell.KeyRune, Rune: 'c', Mod: tcell.ModAlt}))
+
+	return km
+}
+
+// Bind binds keys to an action, replacing any keys the action had within
+// the same context. Binding no keys removes the binding.
+func (km *Keymap) Bind(context KeyContext, action Action, keys ...KeySequence) {
+	for _, b := range km.bindings {
+		if b.Context == context && b.Action == action {
+			b.Keys = keys
+			return
+		}
+	}
+
+	km.bindings = append(km.bindings, &Binding{
+		Action:      action,
+		Context:     context,
+		Keys:        keys,
+		Description: actionDescriptions[action],
+	})
+}
+
+// Unbind removes a key sequence from any action bound to it within a
+// context.
+func (km *Keymap) Unbind(context KeyContext, keys KeySequence) {
+	for _, b := range km.bindings {
+		if b.Context != context {
+			continue
+		}
+
+		kept := b.Keys[:0:0]
+
+		for _, seq := range b.Keys {
+			if len(seq) != len(keys) || !seq.hasPrefix(keys) {
+				kept = append(kept, seq)
+			}
+		}
+
+		b.Keys = kept
+	}
+}
+
+// Lookup returns the action bound to a key press within a context.
+func (km *Keymap) Lookup(context KeyContext, event *tcell.EventKey) (Action, bool) {
+	action, ok, _ := km.LookupSequence(context, []Key{KeyFromEvent(event)})
+	return action, ok
+}
+
+// LookupSequence returns the action bound to keys pressed one after another
+// within a context. If no action is bound, partial is true if the keys
+// start a longer sequence that is.
+func (km *Keymap) LookupSequence(context KeyContext, keys []Key) (action Action, ok bool, partial bool) {
+	for _, b := range km.bindings {
+		if b.Context != context {
+			continue
+		}
+
+		for _, seq := range b.Keys {
+			switch {
+			case !seq.hasPrefix(keys):
+			case len(seq) == len(keys):
+				return b.Action, true, false
+			default:
+				partial = true
+			}
+		}
+	}
+
+	return "", false, partial
+}
+
+// Bindings returns all bindings of a context, in the order they were added.
+func (km *Keymap) Bindings(context KeyContext) []*Binding {
+	var bindings []*Binding
+
+	for _, b := range km.bindings {
+		if b.Context == context && len(b.Keys) > 0 {
+			bindings = append(bindings, b)
+		}
+	}
+
+	return bindings
+}
+
+// KeysOf returns the keys bound to an action in any context, in the order
+// contexts are documented.
+func (km *Keymap) KeysOf(action Action) []KeySequence {
+	var keys []KeySequence
+
+	for _, context := range KeyContexts {
+		for _, b := range km.Bindings(context) {
+			if b.Action != action {
+				continue
+			}
+
+			for _, seq := range b.Keys {
+				if !containsSequence(keys, seq) {
+					keys = append(keys, seq)
+				}
+			}
+		}
+	}
+
+	return keys
+}
+
+func containsSequence(keys []KeySequence, seq KeySequence) bool {
+	for _, other := range keys {
+		if len(other) == len(seq) && other.hasPrefix(seq) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsModal returns true if the keymap has a normal mode for editing content,
+// as the vi preset does.
+func (km *Keymap) IsModal() bool {
+	return len(km.Bindings(ContextNormal)) > 0
+}
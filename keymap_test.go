@@ -0,0 +1,223 @@
+package nve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      Key
+		event    *tcell.EventKey
+		expected bool
+	}{
+		{name: "ctrl key", key: KeyOf(tcell.KeyCtrlT, 0), event: tcell.NewEventKey(tcell.KeyCtrlT, 0, tcell.ModCtrl), expected: true},
+		{name: "ctrl key without modifier", key: KeyOf(tcell.KeyCtrlT, 0), event: tcell.NewEventKey(tcell.KeyCtrlT, 0, tcell.ModNone), expected: true},
+		{name: "other ctrl key", key: KeyOf(tcell.KeyCtrlT, 0), event: tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl), expected: false},
+		{name: "alt modified key", key: KeyOf(tcell.KeyEnter, tcell.ModAlt), event: tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModAlt), expected: true},
+		{name: "missing alt", key: KeyOf(tcell.KeyEnter, tcell.ModAlt), event: tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), expected: false},
+		{name: "unexpected alt", key: KeyOf(tcell.KeyLeft, 0), event: tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModAlt), expected: false},
+		{name: "unexpected ctrl", key: KeyOf(tcell.KeyLeft, 0), event: tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl), expected: false},
+		{name: "rune", key: RuneKey('?'), event: tcell.NewEventKey(tcell.KeyRune, '?', tcell.ModShift), expected: true},
+		{name: "other rune", key: RuneKey('?'), event: tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModNone), expected: false},
+		{name: "alt rune", key: RuneKey('?'), event: tcell.NewEventKey(tcell.KeyRune, '?', tcell.ModAlt), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.key.Matches(tt.event))
+		})
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	assert.Equal(t, "Ctrl-T", KeyOf(tcell.KeyCtrlT, 0).String())
+	assert.Equal(t, "Alt-Enter", KeyOf(tcell.KeyEnter, tcell.ModAlt).String())
+	assert.Equal(t, "Shift-Left", KeyOf(tcell.KeyLeft, tcell.ModShift).String())
+	assert.Equal(t, "F1", KeyOf(tcell.KeyF1, 0).String())
+	assert.Equal(t, "?", RuneKey('?').String())
+	assert.Equal(t, "Space", RuneKey(' ').String())
+	assert.Equal(t, "Ctrl-Left", KeyOf(tcell.KeyLeft, tcell.ModCtrl).String())
+	assert.Equal(t, "Backspace", KeyOf(tcell.KeyBackspace2, 0).String())
+	assert.Equal(t, "Ctrl-X u", Seq(KeyOf(tcell.KeyCtrlX, 0), RuneKey('u')).String())
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Key
+	}{
+		{name: "Ctrl-T", expected: KeyOf(tcell.KeyCtrlT, 0)},
+		{name: "ctrl-t", expected: KeyOf(tcell.KeyCtrlT, 0)},
+		{name: "Ctrl-Space", expected: KeyOf(tcell.KeyCtrlSpace, 0)},
+		{name: "Ctrl-_", expected: KeyOf(tcell.KeyCtrlUnderscore, 0)},
+		{name: "Ctrl-]", expected: KeyOf(tcell.KeyCtrlRightSq, 0)},
+		{name: "Ctrl-H", expected: KeyOf(tcell.KeyBS, 0)},
+		{name: "Backspace", expected: KeyOf(tcell.KeyBackspace2, 0)},
+		{name: "Alt-Enter", expected: KeyOf(tcell.KeyEnter, tcell.ModAlt)},
+		{name: "Ctrl-Left", expected: KeyOf(tcell.KeyLeft, tcell.ModCtrl)},
+		{name: "Shift-Tab", expected: KeyOf(tcell.KeyBacktab, 0)},
+		{name: "escape", expected: KeyOf(tcell.KeyEsc, 0)},
+		{name: "F5", expected: KeyOf(tcell.KeyF5, 0)},
+		{name: "G", expected: RuneKey('G')},
+		{name: "Shift-g", expected: RuneKey('G')},
+		{name: "Alt-f", expected: Key{Key: tcell.KeyRune, Rune: 'f', Mod: tcell.ModAlt}},
+		{name: "Alt--", expected: Key{Key: tcell.KeyRune, Rune: '-', Mod: tcell.ModAlt}},
+		{name: "Space", expected: RuneKey(' ')},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.name)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, key)
+		})
+	}
+
+	for _, name := range []string{"", "Ctrl-", "Hyper-x", "Ctrl-1", "Enterr"} {
+		_, err := ParseKey(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseKeySequence(t *testing.T) {
+	seq, err := ParseKeySequence("Ctrl-X  u")
+	assert.NoError(t, err)
+	assert.Equal(t, Seq(KeyOf(tcell.KeyCtrlX, 0), RuneKey('u')), seq)
+
+	_, err = ParseKeySequence(" ")
+	assert.Error(t, err)
+
+	// names of all keys of the presets parse back to the same keys
+	for _, preset := range []string{PresetDefault, PresetEmacs, PresetVi} {
+		km, err := NewKeymap(preset)
+		assert.NoError(t, err)
+
+		for _, context := range KeyContexts {
+			for _, binding := range km.Bindings(context) {
+				for _, seq := range binding.Keys {
+					parsed, err := ParseKeySequence(seq.String())
+					assert.NoError(t, err)
+					assert.Equal(t, seq, parsed, "%s: %s", preset, seq)
+				}
+			}
+		}
+	}
+}
+
+func TestKeymapLookup(t *testing.T) {
+	km := DefaultKeymap()
+
+	ctrlN := tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl)
+
+	action, ok := km.Lookup(ContextContent, ctrlN)
+	assert.True(t, ok)
+	assert.Equal(t, ActionCursorDown, action)
+
+	action, ok = km.Lookup(ContextSearch, ctrlN)
+	assert.True(t, ok)
+	assert.Equal(t, ActionSelectNext, action)
+
+	_, ok = km.Lookup(ContextGlobal, ctrlN)
+	assert.False(t, ok)
+
+	// rebinding replaces the keys of an action
+	km.Bind(ContextContent, ActionCursorDown, Seq(KeyOf(tcell.KeyCtrlJ, 0)))
+
+	_, ok = km.Lookup(ContextContent, ctrlN)
+	assert.False(t, ok)
+
+	action, _ = km.Lookup(ContextContent, tcell.NewEventKey(tcell.KeyCtrlJ, 0, tcell.ModCtrl))
+	assert.Equal(t, ActionCursorDown, action)
+
+	// the default keymap is left unchanged
+	action, _ = DefaultKeymap().Lookup(ContextContent, ctrlN)
+	assert.Equal(t, ActionCursorDown, action)
+}
+
+func TestKeymapLookupSequence(t *testing.T) {
+	km := ViKeymap()
+
+	g := RuneKey('g')
+
+	_, ok, partial := km.LookupSequence(ContextNormal, []Key{g})
+	assert.False(t, ok)
+	assert.True(t, partial)
+
+	action, ok, partial := km.LookupSequence(ContextNormal, []Key{g, g})
+	assert.True(t, ok)
+	assert.False(t, partial)
+	assert.Equal(t, ActionDocumentStart, action)
+
+	_, ok, partial = km.LookupSequence(ContextNormal, []Key{g, RuneKey('x')})
+	assert.False(t, ok)
+	assert.False(t, partial)
+
+	// unbinding removes a sequence from its action
+	km.Unbind(ContextNormal, Seq(g, g))
+
+	_, ok, partial = km.LookupSequence(ContextNormal, []Key{g})
+	assert.False(t, ok)
+	assert.False(t, partial)
+}
+
+func TestKeymapKeysOf(t *testing.T) {
+	km := DefaultKeymap()
+
+	// bound in both the search box and the list
+	assert.Equal(t, "Down, Ctrl-N", formatKeys(km.KeysOf(ActionSelectNext)))
+	assert.Equal(t, "F1, ?", formatKeys(km.KeysOf(ActionHelp)))
+	assert.Empty(t, km.KeysOf(ActionReindex))
+}
+
+func TestKeymapHasNoConflicts(t *testing.T) {
+	for _, preset := range []string{PresetDefault, PresetEmacs, PresetVi} {
+		km, err := NewKeymap(preset)
+		assert.NoError(t, err)
+
+		for _, context := range KeyContexts {
+			var seen []KeySequence
+
+			for _, binding := range km.Bindings(context) {
+				assert.NotEmpty(t, binding.Description, binding.Action)
+
+				for _, seq := range binding.Keys {
+					// a sequence starting another one could never be completed
+					for _, other := range seen {
+						if seq.hasPrefix(other) || other.hasPrefix(seq) {
+							t.Errorf("%s %s: %s conflicts with %s", preset, context, seq, other)
+						}
+					}
+					seen = append(seen, seq)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatKeymap(t *testing.T) {
+	help := formatKeymap(DefaultKeymap())
+
+	for _, context := range []KeyContext{ContextGlobal, ContextSearch, ContextList, ContextContent, ContextFind} {
+		assert.Contains(t, help, context.Title())
+	}
+
+	assert.Contains(t, help, "Down, Ctrl-N")
+	assert.Contains(t, help, "Follow [[link[]] under cursor")
+
+	// contexts of modal keymaps are only listed for them
+	assert.NotContains(t, help, ContextNormal.Title())
+	assert.Contains(t, formatKeymap(ViKeymap()), ContextNormal.Title())
+
+	// the command palette stands in for Ctrl-Shift-P
+	assert.Contains(t, help, "Ctrl-Shift-P is not sent by most terminals: F8, Alt-x show all commands.")
+
+	// one line per binding, plus a header and a blank line per context, and
+	// the note on the command palette
+	lines := strings.Count(help, "\n")
+	assert.Equal(t, len(DefaultKeymap().bindings)+2*5-1+2, lines)
+}
@@ -0,0 +1,252 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+	"gopkg.in/yaml.v3"
+)
+
+// layoutFileName is the name of the file within the per-vault config
+// directory remembering how panes were last arranged.
+const layoutFileName = "layout.yaml"
+
+// LayoutMode is how the list and the content of notes are arranged below
+// the search box.
+type LayoutMode string
+
+const (
+	// LayoutStacked shows the list above the content.
+	LayoutStacked LayoutMode = "stacked"
+	// LayoutSideBySide shows the list left of the content, like Notational
+	// Velocity does.
+	LayoutSideBySide LayoutMode = "side-by-side"
+)
+
+// Next returns the layout mode switched to from this one.
+func (m LayoutMode) Next() LayoutMode {
+	if m == LayoutSideBySide {
+		return LayoutStacked
+	}
+	return LayoutSideBySide
+}
+
+const (
+	// MinListSize and MaxListSize bound the share of the list, in percent,
+	// so neither the list nor the content disappear when resizing.
+	MinListSize = 10
+	MaxListSize = 90
+
+	// ListSizeStep is how much the list grows or shrinks at a time.
+	ListSizeStep = 5
+)
+
+// Layout is the arrangement of panes, remembered in '.nve/layout.yaml'.
+type Layout struct {
+	Mode LayoutMode `yaml:"mode"`
+
+	// ListSize is the share of the list, in percent of the height (when
+	// stacked) or width (when side by side) it shares with the content.
+	ListSize int `yaml:"list-size"`
+
+	// Zen shows only the content of the note being edited.
+	Zen bool `yaml:"zen"`
+}
+
+// DefaultLayout returns the layout used until panes are rearranged.
+func DefaultLayout() Layout {
+	return Layout{
+		Mode:     LayoutStacked,
+		ListSize: 25,
+	}
+}
+
+// Resize returns the layout with the list grown by delta percent, or shrunk
+// if delta is negative.
+func (l Layout) Resize(delta int) Layout {
+	l.ListSize += delta
+
+	if l.ListSize < MinListSize {
+		l.ListSize = MinListSize
+	} else if l.ListSize > MaxListSize {
+		l.ListSize = MaxListSize
+	}
+
+	return l
+}
+
+// LoadLayout reads the layout last saved for the notes in dir. Returns the
+// default layout if none was saved.
+func LoadLayout(dir string) (Layout, error) {
+	layout := DefaultLayout()
+
+	bytes, err := os.ReadFile(filepath.Join(dir, configDirName, layoutFileName))
+
+	if os.IsNotExist(err) {
+		return layout, nil
+	} else if err != nil {
+		return layout, errors.WithStack(err)
+	}
+
+	if err := yaml.Unmarshal(bytes, &layout); err != nil {
+		return DefaultLayout(), errors.Wrap(err, "invalid layout")
+	}
+
+	if layout.Mode != LayoutStacked && layout.Mode != LayoutSideBySide {
+		return DefaultLayout(), errors.Errorf("unknown layout: %s", layout.Mode)
+	}
+
+	return layout.Resize(0), nil
+}
+
+// SaveLayout remembers the layout for the notes in dir.
+func SaveLayout(dir string, layout Layout) error {
+	bytes, err := yaml.Marshal(layout)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, configDirName), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(filepath.Join(dir, configDirName, layoutFileName), bytes, 0644))
+}
+
+// Panes arranges the search box, the list and the content of notes as
+// given by a Layout.
+type Panes struct {
+	*tview.Flex
+	search  tview.Primitive
+	list    tview.Primitive
+	content tview.Primitive
+	editor  tview.Primitive
+	layout  Layout
+
+	// whether the border between the list and the content is dragged, and
+	// the handler called once it is dropped
+	dragging    bool
+	resizedFunc func(layout Layout)
+}
+
+// NewPanes arranges the given panes. The content is the note along with
+// panes shown next to it, such as backlinks, while zen mode shows the
+// editor alone.
+func NewPanes(search, list, content, editor tview.Primitive, layout Layout) *Panes {
+	panes := Panes{
+		Flex:    tview.NewFlex(),
+		search:  search,
+		list:    list,
+		content: content,
+		editor:  editor,
+	}
+
+	panes.SetLayout(layout)
+
+	return &panes
+}
+
+// SetResizedFunc sets a handler called with the layout once the list was
+// resized by dragging its border.
+func (p *Panes) SetResizedFunc(handler func(layout Layout)) *Panes {
+	p.resizedFunc = handler
+	return p
+}
+
+// Layout returns the current arrangement of panes.
+func (p *Panes) Layout() Layout {
+	return p.layout
+}
+
+// SetLayout rearranges the panes. Focus is kept by the caller, as a pane
+// may be hidden.
+func (p *Panes) SetLayout(layout Layout) {
+	p.layout = layout
+	p.Clear().SetDirection(tview.FlexRow)
+
+	if layout.Zen {
+		p.AddItem(p.editor, 0, 1, true)
+		return
+	}
+
+	p.AddItem(p.search, 3, 0, true)
+
+	switch layout.Mode {
+	case LayoutSideBySide:
+		p.AddItem(tview.NewFlex().
+			AddItem(p.list, 0, layout.ListSize, false).
+			AddItem(p.content, 0, 100-layout.ListSize, false), 0, 1, false)
+	default:
+		p.AddItem(p.list, 0, layout.ListSize, false).
+			AddItem(p.content, 0, 100-layout.ListSize, false)
+	}
+}
+
+// MouseHandler lets the border between the list and the content be dragged
+// to resize them, and passes other events on to the panes.
+func (p *Panes) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return p.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+
+		switch {
+		case action == tview.MouseLeftDown && p.onBorder(x, y):
+			p.dragging = true
+			return true, p
+		case p.dragging && action == tview.MouseMove:
+			p.dragTo(x, y)
+			return true, p
+		case p.dragging && action == tview.MouseLeftUp:
+			p.dragging = false
+			if p.resizedFunc != nil {
+				p.resizedFunc(p.layout)
+			}
+			return true, nil
+		}
+
+		return p.Flex.MouseHandler()(action, event, setFocus)
+	})
+}
+
+// onBorder returns true if a position is on the border between the list and
+// the content, which are both drawn with borders of their own.
+func (p *Panes) onBorder(x, y int) bool {
+	if p.layout.Zen {
+		return false
+	}
+
+	listX, listY, listWidth, listHeight := p.list.GetRect()
+	contentX, contentY, _, _ := p.content.GetRect()
+
+	if p.layout.Mode == LayoutSideBySide {
+		return (x == listX+listWidth-1 || x == contentX) && y >= listY && y < listY+listHeight
+	}
+
+	return (y == listY+listHeight-1 || y == contentY) && x >= listX && x < listX+listWidth
+}
+
+// dragTo resizes the list so that its border is at a position, within the
+// bounds of the list size.
+func (p *Panes) dragTo(x, y int) {
+	listX, listY, listWidth, listHeight := p.list.GetRect()
+	_, _, contentWidth, contentHeight := p.content.GetRect()
+	size, total := y-listY+1, listHeight+contentHeight
+
+	if p.layout.Mode == LayoutSideBySide {
+		size, total = x-listX+1, listWidth+contentWidth
+	}
+
+	if total <= 0 {
+		return
+	}
+
+	layout := p.layout
+	layout.ListSize = (200*size + total) / (2 * total)
+
+	if layout = layout.Resize(0); layout != p.layout {
+		p.SetLayout(layout)
+	}
+}
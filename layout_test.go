@@ -0,0 +1,165 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutResize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		delta    int
+		expected int
+	}{
+		{name: "grows list", size: 25, delta: 5, expected: 30},
+		{name: "shrinks list", size: 25, delta: -5, expected: 20},
+		{name: "keeps some content", size: 88, delta: 5, expected: MaxListSize},
+		{name: "keeps some list", size: 12, delta: -5, expected: MinListSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := Layout{Mode: LayoutSideBySide, ListSize: tt.size}
+
+			resized := layout.Resize(tt.delta)
+
+			assert.Equal(t, tt.expected, resized.ListSize)
+			assert.Equal(t, LayoutSideBySide, resized.Mode)
+		})
+	}
+}
+
+func TestLayoutMode(t *testing.T) {
+	assert.Equal(t, LayoutSideBySide, LayoutStacked.Next())
+	assert.Equal(t, LayoutStacked, LayoutSideBySide.Next())
+}
+
+func TestLoadLayout(t *testing.T) {
+	writeLayout := func(t *testing.T, content string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, configDirName), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, configDirName, layoutFileName), []byte(content), 0644))
+		return dir
+	}
+
+	t.Run("defaults until saved", func(t *testing.T) {
+		layout, err := LoadLayout(t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, DefaultLayout(), layout)
+	})
+
+	t.Run("remembers saved layout", func(t *testing.T) {
+		dir := t.TempDir()
+		saved := Layout{Mode: LayoutSideBySide, ListSize: 40, Zen: true}
+
+		require.NoError(t, SaveLayout(dir, saved))
+
+		layout, err := LoadLayout(dir)
+		require.NoError(t, err)
+		assert.Equal(t, saved, layout)
+	})
+
+	t.Run("keeps default of missing settings", func(t *testing.T) {
+		layout, err := LoadLayout(writeLayout(t, "mode: side-by-side\n"))
+		require.NoError(t, err)
+		assert.Equal(t, Layout{Mode: LayoutSideBySide, ListSize: 25}, layout)
+	})
+
+	t.Run("bounds list size", func(t *testing.T) {
+		layout, err := LoadLayout(writeLayout(t, "mode: stacked\nlist-size: 100\n"))
+		require.NoError(t, err)
+		assert.Equal(t, MaxListSize, layout.ListSize)
+	})
+
+	t.Run("rejects unknown mode", func(t *testing.T) {
+		layout, err := LoadLayout(writeLayout(t, "mode: diagonal\n"))
+		assert.EqualError(t, err, "unknown layout: diagonal")
+		assert.Equal(t, DefaultLayout(), layout)
+	})
+}
+
+func TestPanes(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	require.NoError(t, screen.Init())
+	screen.SetSize(100, 43)
+
+	var (
+		search  = tview.NewBox()
+		list    = tview.NewBox()
+		content = tview.NewBox()
+		editor  = tview.NewBox()
+		panes   = NewPanes(search, list, content, editor, DefaultLayout())
+	)
+
+	// rect returns the x, y, width and height of a pane once drawn
+	rect := func(p tview.Primitive) []int {
+		x, y, width, height := p.GetRect()
+		return []int{x, y, width, height}
+	}
+
+	draw := func(layout Layout) {
+		panes.SetLayout(layout)
+		panes.SetRect(0, 0, 100, 43)
+		panes.Draw(screen)
+	}
+
+	t.Run("stacks list above content", func(t *testing.T) {
+		draw(DefaultLayout())
+
+		assert.Equal(t, []int{0, 0, 100, 3}, rect(search))
+		assert.Equal(t, []int{0, 3, 100, 10}, rect(list))
+		assert.Equal(t, []int{0, 13, 100, 30}, rect(content))
+	})
+
+	t.Run("shows list beside content", func(t *testing.T) {
+		draw(Layout{Mode: LayoutSideBySide, ListSize: 30})
+
+		assert.Equal(t, []int{0, 0, 100, 3}, rect(search))
+		assert.Equal(t, []int{0, 3, 30, 40}, rect(list))
+		assert.Equal(t, []int{30, 3, 70, 40}, rect(content))
+	})
+
+	t.Run("drags the border of the list", func(t *testing.T) {
+		var resized []Layout
+		panes.SetResizedFunc(func(layout Layout) { resized = append(resized, layout) })
+
+		mouse := func(action tview.MouseAction, x, y int) {
+			panes.MouseHandler()(action, tcell.NewEventMouse(x, y, tcell.ButtonNone, 0), func(tview.Primitive) {})
+			panes.Draw(screen)
+		}
+
+		draw(DefaultLayout())
+		mouse(tview.MouseLeftDown, 50, 12)
+		mouse(tview.MouseMove, 50, 22)
+		mouse(tview.MouseLeftUp, 50, 22)
+
+		assert.Equal(t, []Layout{{Mode: LayoutStacked, ListSize: 50}}, resized)
+		assert.Equal(t, []int{0, 3, 100, 20}, rect(list))
+
+		draw(Layout{Mode: LayoutSideBySide, ListSize: 30})
+		mouse(tview.MouseLeftDown, 30, 20)
+		mouse(tview.MouseMove, 0, 20)
+		mouse(tview.MouseLeftUp, 0, 20)
+
+		assert.Equal(t, MinListSize, resized[1].ListSize, "keeps some list")
+
+		mouse(tview.MouseLeftDown, 50, 20)
+		mouse(tview.MouseLeftUp, 60, 20)
+		assert.Len(t, resized, 2, "only borders are dragged")
+	})
+
+	t.Run("shows only the editor in zen mode", func(t *testing.T) {
+		draw(Layout{Mode: LayoutSideBySide, ListSize: 30, Zen: true})
+
+		assert.Equal(t, 1, panes.GetItemCount())
+		assert.Equal(t, editor, panes.GetItem(0))
+		assert.Equal(t, []int{0, 0, 100, 43}, rect(editor))
+	})
+}
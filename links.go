@@ -0,0 +1,92 @@
+package nve
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wikiLinkPattern matches [[Note Name]] and [[Note Name|label]] links.
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+?)\]\]`)
+
+// parseLinks returns the distinct link targets found in text, in the order
+// they first appear. Any "|label" suffix is dropped from the target.
+func parseLinks(text string) []string {
+	var (
+		targets []string
+		seen    = map[string]bool{}
+	)
+
+	for _, match := range wikiLinkPattern.FindAllStringSubmatch(text, -1) {
+		target := linkTarget(match[1])
+
+		if target == "" || seen[strings.ToLower(target)] {
+			continue
+		}
+
+		seen[strings.ToLower(target)] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// linkAt returns the target of the link enclosing the byte offset pos, if any.
+func linkAt(text string, pos int) (string, bool) {
+	for _, loc := range wikiLinkPattern.FindAllStringSubmatchIndex(text, -1) {
+		if pos >= loc[0] && pos <= loc[1] {
+			if target := linkTarget(text[loc[2]:loc[3]]); target != "" {
+				return target, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// openLinkAt reports whether the byte offset pos sits inside a [[link]] that
+// is still being typed, returning the offset just past the opening brackets.
+func openLinkAt(text string, pos int) (int, bool) {
+	if pos > len(text) {
+		return 0, false
+	}
+
+	line := text[:pos]
+
+	if idx := strings.LastIndex(line, "\n"); idx >= 0 {
+		line = line[idx+1:]
+	}
+
+	idx := strings.LastIndex(line, "[[")
+
+	if idx < 0 || strings.ContainsAny(line[idx+2:], "[]|") {
+		return 0, false
+	}
+
+	return pos - len(line) + idx + 2, true
+}
+
+// rewriteLinks replaces links pointing at oldName (case-insensitive) with
+// links to newName, preserving any "|label" suffix.
+func rewriteLinks(text, oldName, newName string) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		inner := link[2 : len(link)-2]
+
+		if !strings.EqualFold(linkTarget(inner), oldName) {
+			return link
+		}
+
+		if idx := strings.Index(inner, "|"); idx >= 0 {
+			return "[[" + newName + inner[idx:] + "]]"
+		}
+
+		return "[[" + newName + "]]"
+	})
+}
+
+func linkTarget(inner string) string {
+	if idx := strings.Index(inner, "|"); idx >= 0 {
+		inner = inner[:idx]
+	}
+
+	return strings.TrimSpace(inner)
+}
@@ -0,0 +1,149 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "no links",
+			input:    "plain text with [brackets]",
+			expected: nil,
+		},
+		{
+			name:     "single link",
+			input:    "see [[Zebra in zoo]] for details",
+			expected: []string{"Zebra in zoo"},
+		},
+		{
+			name:     "link with label",
+			input:    "see [[cats|my cats]]",
+			expected: []string{"cats"},
+		},
+		{
+			name:     "duplicate links are collapsed case-insensitively",
+			input:    "[[cats]] and [[Cats]] and [[dogs]]",
+			expected: []string{"cats", "dogs"},
+		},
+		{
+			name:     "links do not span lines",
+			input:    "[[broken\nlink]]",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseLinks(tc.input))
+		})
+	}
+}
+
+func TestLinkAt(t *testing.T) {
+	text := "go to [[cats|kittens]] now"
+
+	target, ok := linkAt(text, 10)
+	assert.True(t, ok)
+	assert.Equal(t, "cats", target)
+
+	_, ok = linkAt(text, 2)
+	assert.False(t, ok)
+}
+
+func TestOpenLinkAt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected int
+		ok       bool
+	}{
+		{name: "unfinished link", input: "see [[zeb", expected: 6, ok: true},
+		{name: "empty link", input: "see [[", expected: 6, ok: true},
+		{name: "finished link", input: "see [[zebra]] ", ok: false},
+		{name: "label is not completed", input: "see [[zebra|", ok: false},
+		{name: "link on previous line", input: "[[zeb\nra", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			start, ok := openLinkAt(tc.input, len(tc.input))
+			assert.Equal(t, tc.ok, ok)
+
+			if tc.ok {
+				assert.Equal(t, tc.expected, start)
+			}
+		})
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	text := "[[Cats]], [[cats|kittens]] and [[catsup]]"
+
+	assert.Equal(t, "[[felines]], [[felines|kittens]] and [[catsup]]", rewriteLinks(text, "cats", "felines"))
+}
+
+func TestBacklinks(t *testing.T) {
+	withNewDB(func(db *DB) {
+		source := &FileRef{Filename: "/tmp/source.md", MD5: "abc", ModifiedAt: time.Now()}
+		require.NoError(t, db.Upsert(source, []byte("links to [[Target]]")))
+
+		refs, err := db.Backlinks("target")
+		require.NoError(t, err)
+
+		if assert.Len(t, refs, 1) {
+			assert.Equal(t, "/tmp/source.md", refs[0].Filename)
+		}
+
+		// removing the link removes the backlink
+		updated := &FileRef{Filename: "/tmp/source.md", MD5: "def", ModifiedAt: time.Now()}
+		require.NoError(t, db.Upsert(updated, []byte("no more links")))
+
+		refs, err = db.Backlinks("target")
+		require.NoError(t, err)
+		assert.Len(t, refs, 0)
+	})
+}
+
+func TestRenameNoteRewritesLinks(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "target.md"), []byte("the target"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "source.md"), []byte("see [[target]]"), 0644))
+	_, err := n.Refresh()
+	require.NoError(t, err)
+
+	ref, err := n.FollowLink("target")
+	require.NoError(t, err)
+
+	renamed, err := n.RenameNote(ref, "renamed")
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "renamed.md"), renamed.Filename)
+	assert.NoFileExists(t, filepath.Join(dir, "target.md"))
+	assert.Equal(t, "see [[renamed]]", GetContent(filepath.Join(dir, "source.md")))
+
+	backlinks, err := n.Backlinks(renamed)
+	require.NoError(t, err)
+	assert.Len(t, backlinks, 1)
+}
+
+func TestFollowLinkCreatesMissingNote(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	ref, err := n.FollowLink("brand new")
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "brand new.md"), ref.Filename)
+	assert.FileExists(t, ref.Filename)
+}
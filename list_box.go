@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"os"
 	"strings"
 	"time"
 
@@ -16,8 +17,22 @@ type ListBox struct {
 	contentView *ContentBox
 	searchView  *SearchBox
 	notes       *Notes
+	compact     bool
+
+	// whether the selected note is expanded to show its best passages, and
+	// the result they were found for
+	expanded   bool
+	passages   []string
+	passagesOf *SearchResult
+
+	// notes marked to act on several at once, in the order they were marked;
+	// marks are kept by filename while the results change
+	marks []*FileRef
 }
 
+// maxPassages is the number of passages shown below an expanded note.
+const maxPassages = 3
+
 func NewListBox(contentView *ContentBox, notes *Notes) *ListBox {
 	box := ListBox{
 		List:        tview.NewList(),
@@ -28,16 +43,12 @@ func NewListBox(contentView *ContentBox, notes *Notes) *ListBox {
 	box.ShowSecondaryText(false).
 		SetWrapAround(false).
 		SetHighlightFullLine(true).
-		SetSelectedStyle(
-			tcell.StyleDefault.
-				Background(tcell.ColorDarkBlue).
-				Foreground(tcell.ColorLightSkyBlue),
-		)
+		SetSelectedStyle(Colors.SelectedStyle())
 
 	box.SetBorder(true).
 		SetTitle("List Box").
-		SetTitleColor(tcell.ColorOrange).
-		SetBorderStyle(tcell.StyleDefault.Dim(true)).
+		SetTitleColor(Colors.PaneTitle).
+		SetBorderStyle(Colors.BorderStyle()).
 		SetBorderPadding(0, 0, 1, 1).
 		SetTitleAlign(tview.AlignLeft)
 
@@ -52,7 +63,7 @@ func NewListBox(contentView *ContentBox, notes *Notes) *ListBox {
 			box.contentView.Clear()
 		} else {
 			result := notes.LastSearchResults[index]
-			box.contentView.SetFile(result.FileRef)
+			box.contentView.SetResult(result)
 		}
 	})
 
@@ -64,8 +75,16 @@ func NewListBox(contentView *ContentBox, notes *Notes) *ListBox {
 		for i := offsetX; i < offsetX+innerHeight && i < box.GetItemCount(); i++ {
 			result := notes.LastSearchResults[i]
 			log.Printf("[DEBUG] ListBox: DrawFunc called - Item %d: %d", i, len(result.Snippet))
-			box.SetItemText(i, formatResult(result, innerWidth), "")
+			if box.compact {
+				box.SetItemText(i, formatCompactResult(result, innerWidth), "")
+			} else {
+				box.SetItemText(i, formatResult(result, innerWidth), "")
+			}
 
+			// marks are drawn in the padding left of the note
+			if box.IsMarked(result.FileRef) {
+				screen.SetContent(innerX-1, innerY+i-offsetX, markIndicator, nil, tcell.StyleDefault.Foreground(Colors.Accent).Background(Colors.Background))
+			}
 		}
 
 		return innerX, innerY, innerWidth, innerHeight
@@ -74,13 +93,148 @@ func NewListBox(contentView *ContentBox, notes *Notes) *ListBox {
 	box.SetFocusFunc(func() {
 		if notes.LastQuery == "" {
 			result := notes.LastSearchResults[box.GetCurrentItem()]
-			box.contentView.SetFile(result.FileRef)
+			box.contentView.SetResult(result)
 		}
 	})
 
 	return &box
 }
 
+// Draw draws the list, expanding the selected note to show its passages
+// matching the search while expanded.
+func (b *ListBox) Draw(screen tcell.Screen) {
+	b.List.Draw(screen)
+
+	if b.expanded {
+		b.drawPassages(screen)
+	}
+}
+
+// selectedPassages returns the passages of the selected note matching the
+// search, found once for each result.
+func (b *ListBox) selectedPassages() []string {
+	index := b.GetCurrentItem()
+
+	if b.notes.LastQuery == "" || index >= len(b.notes.LastSearchResults) {
+		return nil
+	}
+
+	if result := b.notes.LastSearchResults[index]; result != b.passagesOf {
+		b.passages = b.notes.Passages(result.FileRef, maxPassages)
+		b.passagesOf = result
+	}
+
+	return b.passages
+}
+
+// passageRows returns the row the selected note is drawn at while it is
+// expanded, how many rows the notes before it are moved up to make room for
+// its passages below it, and the number of passages, which is 0 if none are
+// shown.
+func (b *ListBox) passageRows() (row, up, count int) {
+	passages := b.selectedPassages()
+	_, y, _, height := b.GetInnerRect()
+	offset, _ := b.GetOffset()
+	row = y + b.GetCurrentItem() - offset
+
+	if !b.expanded || len(passages) == 0 || row < y || row >= y+height {
+		return row, 0, 0
+	}
+
+	// notes near the bottom are moved up, as far as the top
+	up = row + len(passages) - (y + height - 1)
+
+	if up > row-y {
+		up = row - y
+	} else if up < 0 {
+		up = 0
+	}
+
+	return row - up, up, len(passages)
+}
+
+// drawPassages makes room for the passages of the selected note below it,
+// moving the notes after it down, or those before it up if it is near the
+// bottom, then draws the passages there.
+func (b *ListBox) drawPassages(screen tcell.Screen) {
+	passages := b.selectedPassages()
+	x, y, width, height := b.GetInnerRect()
+	row, up, count := b.passageRows()
+
+	if count == 0 {
+		return
+	}
+
+	bottom := y + height
+
+	// rows are moved with the padding left of them, where marks are drawn
+	if up > 0 {
+		for r := y; r <= row; r++ {
+			copyRow(screen, x-1, r+up, r, width+1)
+		}
+	}
+
+	for r := bottom - 1; r > row+count; r-- {
+		copyRow(screen, x-1, r-count, r, width+1)
+	}
+
+	for i, passage := range passages {
+		if row+1+i >= bottom {
+			break
+		}
+
+		for col := -1; col < width; col++ {
+			screen.SetContent(x+col, row+1+i, ' ', nil, tcell.StyleDefault.Background(Colors.Background))
+		}
+
+		tview.Print(screen, "  "+highlightSnippet(passage), x, row+1+i, width, tview.AlignLeft, Colors.Muted)
+	}
+}
+
+// itemAt returns the index of the note drawn at a row of the screen, which
+// is the selected note for the rows of its passages, or -1 if there is none.
+func (b *ListBox) itemAt(y int) int {
+	_, top, _, height := b.GetInnerRect()
+	offset, _ := b.GetOffset()
+
+	if y < top || y >= top+height {
+		return -1
+	}
+
+	row, up, count := b.passageRows()
+	index := y - top + offset
+
+	switch {
+	case count == 0:
+	case y <= row:
+		index += up
+	case y <= row+count:
+		return b.GetCurrentItem()
+	default:
+		index -= count
+	}
+
+	if index < 0 || index >= b.GetItemCount() {
+		return -1
+	}
+
+	return index
+}
+
+// copyRow copies width cells of a row of the screen to another row.
+func copyRow(screen tcell.Screen, x, from, to, width int) {
+	for col := 0; col < width; col++ {
+		mainc, combc, style, _ := screen.GetContent(x+col, from)
+		screen.SetContent(x+col, to, mainc, combc, style)
+	}
+}
+
+// SetCompact leaves out timestamps, for a list shown beside the content.
+func (b *ListBox) SetCompact(compact bool) *ListBox {
+	b.compact = compact
+	return b
+}
+
 func (b *ListBox) SearchResultsUpdate(notes *Notes) {
 	b.contentView.SetSearchQuery(notes.LastQuery)
 
@@ -92,6 +246,7 @@ func (b *ListBox) SearchResultsUpdate(notes *Notes) {
 	b.Clear()
 
 	b.SetSelectedFocusOnly(emptyQuery)
+	b.updateMarks(lastResult)
 
 	if len(lastResult) == 0 && !b.contentView.HasFocus() {
 		b.contentView.Clear()
@@ -103,7 +258,7 @@ func (b *ListBox) SearchResultsUpdate(notes *Notes) {
 		mainText := formatResult(result, -1)
 		b.AddItem(mainText, "", 0, nil)
 
-		if selectedIndex == -1 && strings.HasPrefix(result.DisplayName(), notes.LastQuery) {
+		if selectedIndex == -1 && (strings.HasPrefix(result.DisplayName(), notes.LastQuery) || strings.HasPrefix(notes.RelativeName(result.FileRef), notes.LastQuery)) {
 			selectedIndex = index
 		}
 	}
@@ -124,11 +279,15 @@ func (b *ListBox) SearchResultsUpdate(notes *Notes) {
 	}
 }
 
+// pinMarker precedes the names of pinned notes in the list.
+const pinMarker = "* "
+
 func formatResult(result *SearchResult, lineWidth int) string {
 	// Format of a single line in the list box:
 	// <filename> : <snippet> <timestamp>
 	//
-	//   Filename is left-aligned, max 22 characters (20 + ".." if truncated)
+	//   Filename (or front matter title) is left-aligned, max 22 characters (20 + ".." if truncated),
+	//   prefixed by its folder when shown (see Notes.ShowFolders), and by pinMarker if pinned
 	//   Snippet is left-aligned, max width depends on overall maxWidth
 	//   Timestamp is right-aligned, fixed width of 20 characters (e.g., "Aug 16, 2025 12:15PM", or "5 min ago", or "now")
 	//
@@ -146,16 +305,10 @@ func formatResult(result *SearchResult, lineWidth int) string {
 		minWidth         = widthFilename + paddingFilename + +minSnippetWidth + paddingTimestamp + widthTimestamp
 	)
 
-	filename := result.DisplayName()
+	filename := formatResultName(result, widthFilename)
 	snippet := result.Snippet
 	timestamp := formatModifiedTime(result.ModifiedAt)
 
-	if len(filename) > widthFilename {
-		// truncate filename to fit
-		filename = strings.TrimSpace(filename[:widthFilename-widthElipsis])
-		filename = fmt.Sprintf("%s%s", filename, ellipsis)
-	}
-
 	// Right-pad filename to fixed width
 	filename = fmt.Sprintf("%-*s", widthFilename, filename)
 
@@ -166,31 +319,144 @@ func formatResult(result *SearchResult, lineWidth int) string {
 	snippet = strings.Join(strings.Fields(snippet), " ")
 
 	if lineWidth <= 0 {
-		lineWidth = minWidth - minSnippetWidth + len(snippet)
+		lineWidth = minWidth - minSnippetWidth + snippetWidth(snippet)
 	}
 
 	// Omit filename and timestamp if lineWidth is too narrow
 	if lineWidth < minWidth {
 		// too narrow to fit filename and timestamp, so omit both
-		if len(snippet) > lineWidth {
-			snippet = snippet[:lineWidth-widthElipsis] + ellipsis
-		}
+		snippet = truncateSnippet(snippet, lineWidth, ellipsis)
 
-		return tview.Escape(fmt.Sprintf("%*s", lineWidth, snippet))
+		return strings.Repeat(" ", lineWidth-snippetWidth(snippet)) + highlightSnippet(snippet)
 	}
 
 	// Calculate max width for snippet
 	maxSnippetLen := lineWidth - minWidth + minSnippetWidth
 
-	if len(snippet) > maxSnippetLen {
-		snippet = snippet[:maxSnippetLen-widthElipsis] + ellipsis
+	snippet = truncateSnippet(snippet, maxSnippetLen, ellipsis)
+	snippet += strings.Repeat(" ", maxSnippetLen-snippetWidth(snippet))
+
+	return tview.Escape(filename+strings.Repeat(" ", paddingFilename)) +
+		highlightSnippet(snippet) +
+		tview.Escape(strings.Repeat(" ", paddingTimestamp)+timestamp)
+}
+
+// formatResultName returns the name of a result within width characters,
+// prefixed by its folder when shown, and by pinMarker if pinned.
+func formatResultName(result *SearchResult, width int) string {
+	const ellipsis = ".."
+
+	filename := result.Name()
+
+	// pinned notes are marked, leaving less room for the name
+	widthName := width
+
+	if result.Pinned {
+		widthName -= len(pinMarker)
+	}
+
+	if len(filename) > widthName && len(filename)-len(result.Folder) <= widthName-len(ellipsis) {
+		// keep the name itself, truncating its folder from the left
+		filename = ellipsis + filename[len(filename)-widthName+len(ellipsis):]
+	} else if len(filename) > widthName {
+		// truncate filename to fit
+		filename = strings.TrimSpace(filename[:widthName-len(ellipsis)])
+		filename = fmt.Sprintf("%s%s", filename, ellipsis)
+	}
+
+	if result.Pinned {
+		filename = pinMarker + filename
+	}
+
+	return filename
+}
+
+// formatCompactResult formats a result for a list shown beside the content,
+// which is too narrow for timestamps. The name comes first, followed by the
+// snippet if there is room for some of it.
+func formatCompactResult(result *SearchResult, lineWidth int) string {
+	const (
+		ellipsis        = ".."
+		widthFilename   = 20
+		paddingFilename = 3
+		minSnippetWidth = 10
+	)
+
+	if lineWidth < widthFilename+paddingFilename+minSnippetWidth {
+		return tview.Escape(formatResultName(result, int(math.Min(float64(lineWidth), widthFilename))))
+	}
+
+	filename := fmt.Sprintf("%-*s", widthFilename, formatResultName(result, widthFilename))
+	snippet := strings.Join(strings.Fields(result.Snippet), " ")
+	snippet = truncateSnippet(snippet, lineWidth-widthFilename-paddingFilename, ellipsis)
+
+	return tview.Escape(filename+strings.Repeat(" ", paddingFilename)) + highlightSnippet(snippet)
+}
+
+// snippetWidth returns the width of a snippet, leaving out the markers of
+// its matches.
+func snippetWidth(snippet string) int {
+	return len(snippet) - strings.Count(snippet, matchStart) - strings.Count(snippet, matchEnd)
+}
+
+// truncateSnippet truncates a snippet to width, ending it with ellipsis if
+// it is too wide. A match cut short is still marked as ending.
+func truncateSnippet(snippet string, width int, ellipsis string) string {
+	if snippetWidth(snippet) <= width {
+		return snippet
+	}
+
+	var (
+		sb      strings.Builder
+		n       int
+		inMatch bool
+	)
+
+	for i := 0; i < len(snippet) && n < width-len(ellipsis); i++ {
+		switch snippet[i] {
+		case matchStart[0]:
+			inMatch = true
+		case matchEnd[0]:
+			inMatch = false
+		default:
+			n++
+		}
+
+		sb.WriteByte(snippet[i])
+	}
+
+	if inMatch {
+		sb.WriteString(matchEnd)
 	}
 
-	snippet = fmt.Sprintf("%-*s", maxSnippetLen, snippet)
+	return sb.String() + ellipsis
+}
+
+// highlightSnippet escapes a snippet to be shown by tview, highlighting its
+// matches the way the content box does.
+func highlightSnippet(snippet string) string {
+	var sb strings.Builder
+
+	for {
+		start := strings.Index(snippet, matchStart)
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(snippet[start:], matchEnd)
+		if end < 0 {
+			end = len(snippet) - start
+		}
+
+		sb.WriteString(tview.Escape(snippet[:start]))
+		sb.WriteString(Colors.highlightTag() + tview.Escape(snippet[start+len(matchStart):start+end]) + "[-:-:-]")
+
+		snippet = strings.TrimPrefix(snippet[start+end:], matchEnd)
+	}
 
-	mainText := fmt.Sprintf("%s%s%s%s%s", filename, strings.Repeat(" ", paddingFilename), snippet, strings.Repeat(" ", paddingTimestamp), timestamp)
+	sb.WriteString(tview.Escape(strings.ReplaceAll(snippet, matchEnd, "")))
 
-	return tview.Escape(mainText)
+	return sb.String()
 }
 
 // isNavigationalKey returns true if the key is for navigation purposes
@@ -208,23 +474,52 @@ func (lb *ListBox) isNavigationalKey(event *tcell.EventKey) bool {
 // InputHandler overrides default handling to switch focus away from search box when necessary.
 func (lb *ListBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return lb.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		// keys handled by the global key handler are passed on as an empty
+		// event, which is no key to forward
+		if event.When().IsZero() {
+			return
+		}
 
-		// Handle left arrow to move focus to SearchBox with cursor at start
-		if event.Key() == tcell.KeyLeft {
-			log.Printf("[DEBUG] ListBox: Left arrow pressed, moving focus to SearchBox")
+		action, _ := Keys.Lookup(ContextList, event)
+
+		switch action {
+		case ActionEditSearch:
+			// move focus to SearchBox with cursor at start
+			log.Printf("[DEBUG] ListBox: Edit search, moving focus to SearchBox")
 			setFocus(lb.searchView)
 			// Move cursor to start by simulating Home key press
 			if handler := lb.searchView.InputHandler(); handler != nil {
 				handler(tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone), setFocus)
 			}
 			return
-		}
-
-		// Handle Enter key press
-		if event.Key() == tcell.KeyEnter {
+		case ActionOpenNote:
 			setFocus(lb.contentView)
-			log.Printf("[DEBUG] ListBox: Enter pressed, setting focus to content view")
+			log.Printf("[DEBUG] ListBox: Open note, setting focus to content view")
 			return
+		case ActionTogglePin:
+			lb.TogglePin()
+			return
+		case ActionPassages:
+			lb.expanded = !lb.expanded
+			return
+		case ActionToggleMark:
+			// marking moves on to the next note, to mark several in a row
+			lb.toggleMark(lb.GetCurrentItem())
+			event = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case ActionMarkDown, ActionMarkUp:
+			// both the note left and the note moved to are marked
+			lb.mark(lb.GetCurrentItem())
+			defer func() { lb.mark(lb.GetCurrentItem()) }()
+
+			if action == ActionMarkDown {
+				event = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+			} else {
+				event = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+			}
+		case ActionSelectNext:
+			event = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case ActionSelectPrevious:
+			event = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
 		}
 
 		// Forward non-navigational characters to SearchBox
@@ -248,16 +543,9 @@ func (lb *ListBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tv
 		}
 
 		// For arrow keys, always sync SearchBox and ContentView regardless of selection change
-		if event.Key() == tcell.KeyUp || event.Key() == tcell.KeyDown || event.Key() == tcell.KeyCtrlP || event.Key() == tcell.KeyCtrlN {
-			lb.SetSelectedFocusOnly(false)
-			currentItem := lb.GetCurrentItem()
-			if currentItem < len(lb.notes.LastSearchResults) {
-				filename := lb.notes.LastSearchResults[currentItem].DisplayName()
-				log.Printf("[DEBUG] ListBox: Arrow key pressed, updating search box to '%s'", filename)
-				lb.searchView.SetTextFromList(filename)
-				result := lb.notes.LastSearchResults[currentItem]
-				lb.contentView.SetFile(result.FileRef)
-			}
+		if event.Key() == tcell.KeyUp || event.Key() == tcell.KeyDown {
+			log.Printf("[DEBUG] ListBox: Arrow key pressed, syncing item %d", lb.GetCurrentItem())
+			lb.syncSelected()
 			return
 		}
 
@@ -278,6 +566,188 @@ func (lb *ListBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tv
 	})
 }
 
+// syncSelected shows the name of the selected note in the search box and the
+// note itself in the content.
+func (lb *ListBox) syncSelected() {
+	lb.SetSelectedFocusOnly(false)
+	currentItem := lb.GetCurrentItem()
+
+	if currentItem < len(lb.notes.LastSearchResults) {
+		result := lb.notes.LastSearchResults[currentItem]
+		lb.searchView.SetTextFromList(result.DisplayName())
+		lb.contentView.SetResult(result)
+	}
+}
+
+// MouseHandler selects the note clicked, syncing the search box and the
+// content as the arrow keys do, and opens it on a double click. The wheel
+// scrolls the list.
+func (lb *ListBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return lb.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+
+		if !lb.InRect(x, y) {
+			return false, nil
+		}
+
+		switch action {
+		case tview.MouseLeftClick, tview.MouseLeftDoubleClick:
+			index := lb.itemAt(y)
+
+			if index < 0 {
+				return true, nil
+			}
+
+			// clicks on two notes in a row are not a double click
+			open := action == tview.MouseLeftDoubleClick && index == lb.GetCurrentItem()
+
+			log.Printf("[DEBUG] ListBox: Clicked item %d, open=%t", index, open)
+			setFocus(lb)
+			lb.SetCurrentItem(index)
+			lb.syncSelected()
+
+			if open {
+				setFocus(lb.contentView)
+			}
+
+			return true, nil
+		case tview.MouseScrollUp, tview.MouseScrollDown:
+			return keepFocus(lb, lb.List.MouseHandler(), nil)(action, event, setFocus)
+		}
+
+		return false, nil
+	})
+}
+
+// markIndicator is drawn left of marked notes.
+const markIndicator = '▌'
+
+// IsMarked returns true if a note is marked.
+func (lb *ListBox) IsMarked(ref *FileRef) bool {
+	return lb.markIndex(ref.Filename) >= 0
+}
+
+func (lb *ListBox) markIndex(filename string) int {
+	for i, mark := range lb.marks {
+		if mark.Filename == filename {
+			return i
+		}
+	}
+	return -1
+}
+
+// Marked returns the marked notes, in the order they were marked.
+func (lb *ListBox) Marked() []*FileRef {
+	return append([]*FileRef(nil), lb.marks...)
+}
+
+// Selected returns the marked notes, or else the selected note. Returns
+// nothing if no note is marked or selected.
+func (lb *ListBox) Selected() []*FileRef {
+	if len(lb.marks) > 0 {
+		return lb.Marked()
+	}
+
+	if index := lb.GetCurrentItem(); index < len(lb.notes.LastSearchResults) {
+		return []*FileRef{lb.notes.LastSearchResults[index].FileRef}
+	}
+
+	return nil
+}
+
+// ClearMarks unmarks all notes.
+func (lb *ListBox) ClearMarks() {
+	lb.marks = nil
+	lb.updateTitle()
+}
+
+// mark marks the note at index of the results, unless it is marked.
+func (lb *ListBox) mark(index int) {
+	if index >= len(lb.notes.LastSearchResults) {
+		return
+	}
+
+	if ref := lb.notes.LastSearchResults[index].FileRef; !lb.IsMarked(ref) {
+		lb.marks = append(lb.marks, ref)
+		lb.updateTitle()
+	}
+}
+
+// toggleMark marks the note at index of the results, or unmarks it.
+func (lb *ListBox) toggleMark(index int) {
+	if index >= len(lb.notes.LastSearchResults) {
+		return
+	}
+
+	ref := lb.notes.LastSearchResults[index].FileRef
+
+	if i := lb.markIndex(ref.Filename); i >= 0 {
+		lb.marks = append(lb.marks[:i], lb.marks[i+1:]...)
+	} else {
+		lb.marks = append(lb.marks, ref)
+	}
+
+	lb.updateTitle()
+}
+
+// updateMarks keeps the marks of notes which still exist as the results
+// change, taking up their latest references.
+func (lb *ListBox) updateMarks(results []*SearchResult) {
+	var marks []*FileRef
+
+	for _, mark := range lb.marks {
+		if _, err := os.Stat(mark.Filename); err != nil {
+			continue
+		}
+
+		for _, result := range results {
+			if result.Filename == mark.Filename {
+				mark = result.FileRef
+				break
+			}
+		}
+
+		marks = append(marks, mark)
+	}
+
+	lb.marks = marks
+	lb.updateTitle()
+}
+
+// updateTitle shows the number of marked notes in the title.
+func (lb *ListBox) updateTitle() {
+	if len(lb.marks) == 0 {
+		lb.SetTitle("List Box")
+	} else {
+		lb.SetTitle(fmt.Sprintf("List Box (%d marked)", len(lb.marks)))
+	}
+}
+
+// TogglePin pins or unpins the selected note, keeping it selected as the
+// results are reordered. Marked notes are all pinned, unless all of them are
+// pinned already, in which case they are unpinned.
+func (lb *ListBox) TogglePin() {
+	index := lb.GetCurrentItem()
+
+	if index >= len(lb.notes.LastSearchResults) {
+		return
+	}
+
+	ref := lb.notes.LastSearchResults[index].FileRef
+
+	if err := lb.notes.TogglePins(lb.Selected()); err != nil {
+		log.Printf("[ERROR] ListBox: could not pin '%s': %v", ref.Filename, err)
+		return
+	}
+
+	for i, result := range lb.notes.LastSearchResults {
+		if result.DocumentID == ref.DocumentID {
+			lb.SetCurrentItem(i)
+			break
+		}
+	}
+}
+
 func formatModifiedTime(modTime time.Time) string {
 	now := time.Now()
 	diff := now.Sub(modTime)
@@ -18,6 +18,9 @@ func TestFormatResult(t *testing.T) {
 	tests := []struct {
 		name       string
 		filename   string
+		title      string
+		folder     string
+		pinned     bool
 		snippet    string
 		maxWidth   int
 		modifiedAt string
@@ -45,6 +48,53 @@ func TestFormatResult(t *testing.T) {
 			modifiedAt: "2006-01-02T15:04:05Z",
 			expected:   "test_file              This is a test snippet             Jan 02, 2006",
 		},
+		{
+			name:     "highlights matches",
+			filename: "plans.md",
+			snippet:  "the " + matchStart + "agenda" + matchEnd + " [items]",
+			maxWidth: 70,
+			expected: "plans                  the [black:yellow:b]agenda[-:-:-] [items[]                 Jan 01, 2001",
+		},
+		{
+			name:     "front matter title replaces filename",
+			filename: "2024-07-01.md",
+			title:    "Q3 Planning",
+			snippet:  "agenda",
+			maxWidth: 70,
+			expected: "Q3 Planning            agenda                             Jan 01, 2001",
+		},
+		{
+			name:     "folder prefixes name",
+			filename: "work/todo.md",
+			folder:   "work",
+			snippet:  "agenda",
+			maxWidth: 70,
+			expected: "work/todo              agenda                             Jan 01, 2001",
+		},
+		{
+			name:     "long folder is truncated before name",
+			filename: "projects/archive/2023/meeting.md",
+			folder:   "projects/archive/2023",
+			snippet:  "agenda",
+			maxWidth: 70,
+			expected: "..chive/2023/meeting   agenda                             Jan 01, 2001",
+		},
+		{
+			name:     "pinned notes are marked",
+			filename: "reference.md",
+			pinned:   true,
+			snippet:  "agenda",
+			maxWidth: 70,
+			expected: "* reference            agenda                             Jan 01, 2001",
+		},
+		{
+			name:     "pinned marker shortens long names",
+			filename: "a very long reference name.md",
+			pinned:   true,
+			snippet:  "agenda",
+			maxWidth: 70,
+			expected: "* a very long refe..   agenda                             Jan 01, 2001",
+		},
 		{
 			name:     "no width specified",
 			filename: "test_file.txt",
@@ -86,6 +136,9 @@ func TestFormatResult(t *testing.T) {
 			result := &SearchResult{
 				FileRef: fileRef,
 				Snippet: tt.snippet,
+				Title:   tt.title,
+				Folder:  tt.folder,
+				Pinned:  tt.pinned,
 			}
 
 			actual := formatResult(result, tt.maxWidth)
@@ -99,3 +152,63 @@ func TestFormatResult(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatCompactResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		pinned   bool
+		snippet  string
+		maxWidth int
+		expected string
+	}{
+		{
+			name:     "name followed by snippet",
+			filename: "groceries.md",
+			snippet:  "buy milk\nand eggs",
+			maxWidth: 40,
+			expected: "groceries              buy milk and eggs",
+		},
+		{
+			name:     "truncates snippet",
+			filename: "groceries.md",
+			snippet:  "buy milk and eggs and bread",
+			maxWidth: 36,
+			expected: "groceries              buy milk an..",
+		},
+		{
+			name:     "ends a match cut short by truncating",
+			filename: "list.md",
+			snippet:  "buy " + matchStart + "groceries" + matchEnd + " now",
+			maxWidth: 35,
+			expected: "list                   buy [black:yellow:b]grocer[-:-:-]..",
+		},
+		{
+			name:     "only name when too narrow for snippet",
+			filename: "groceries.md",
+			pinned:   true,
+			snippet:  "buy milk",
+			maxWidth: 30,
+			expected: "* groceries",
+		},
+		{
+			name:     "truncates name to width",
+			filename: "a_rather_long_file_name.md",
+			snippet:  "buy milk",
+			maxWidth: 12,
+			expected: "a_rather_l..",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &SearchResult{
+				FileRef: &FileRef{Filename: tt.filename},
+				Snippet: tt.snippet,
+				Pinned:  tt.pinned,
+			}
+
+			assert.Equal(t, tt.expected, formatCompactResult(result, tt.maxWidth))
+		})
+	}
+}
@@ -0,0 +1,548 @@
+package nve
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listPattern    = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))*\s*$`)
+	fencePattern   = regexp.MustCompile("^\\s*(```+|~~~+)")
+	tableRuleCell  = regexp.MustCompile(`^\s*:?-+:?\s*$`)
+)
+
+// bullets mark the items of unordered lists, by how deeply they are nested.
+var bullets = []string{"•", "◦", "▪"}
+
+// RenderMarkdown renders Markdown as text with tview color tags, for a
+// read-only view of a note. Paragraphs, list items and quotes are wrapped
+// to width, unless it is 0. Links are numbered, and listed at the end.
+func RenderMarkdown(text string, width int) string {
+	r := markdownRenderer{}
+
+	_, body, _ := splitFrontMatter(strings.ReplaceAll(text, "\r\n", "\n"))
+
+	blocks := r.blocks(strings.Split(body, "\n"), width)
+
+	if len(r.links) > 0 {
+		links := []string{colorTag(Colors.PaneTitle, "b") + "Links" + "[-::-]"}
+		for i, link := range r.links {
+			links = append(links, tview.Escape(fmt.Sprintf("[%d] %s", i+1, link)))
+		}
+		blocks = append(blocks, strings.Join(links, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// markdownRenderer keeps the links found while rendering, which are listed
+// after the text.
+type markdownRenderer struct {
+	links []string
+}
+
+// blocks renders lines of Markdown, returning each paragraph, heading, list
+// and so on as lines of text.
+func (r *markdownRenderer) blocks(lines []string, width int) []string {
+	var res []string
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		var (
+			block []string
+			next  int
+		)
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+			continue
+		case fencePattern.MatchString(line):
+			block, next = r.code(lines, i)
+		case headingPattern.MatchString(line):
+			block, next = r.heading(line, width), i+1
+		case isRule(line):
+			block, next = []string{colorTag(Colors.Muted, "") + strings.Repeat("─", ruleWidth(width)) + "[-]"}, i+1
+		case isTableStart(lines, i):
+			block, next = r.table(lines, i)
+		case strings.HasPrefix(strings.TrimSpace(line), ">"):
+			block, next = r.quote(lines, i, width)
+		case listPattern.MatchString(line):
+			block, next = r.list(lines, i, width)
+		default:
+			block, next = r.paragraph(lines, i, width)
+		}
+
+		res = append(res, strings.Join(block, "\n"))
+		i = next
+	}
+
+	return res
+}
+
+// startsBlock returns true if a line starts a block other than a paragraph,
+// ending the paragraph before it.
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+
+	return strings.TrimSpace(line) == "" ||
+		fencePattern.MatchString(line) ||
+		headingPattern.MatchString(line) ||
+		isRule(line) ||
+		strings.HasPrefix(strings.TrimSpace(line), ">") ||
+		listPattern.MatchString(line) ||
+		isTableStart(lines, i)
+}
+
+// isRule returns true for a horizontal rule: three or more '-', '*' or '_',
+// optionally separated by spaces.
+func isRule(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return rulePattern.MatchString(line) && strings.Count(trimmed, trimmed[:1]) >= 3
+}
+
+// ruleWidth returns the width of a horizontal rule.
+func ruleWidth(width int) int {
+	if width <= 0 {
+		return 40
+	}
+	return width
+}
+
+func (r *markdownRenderer) heading(line string, width int) []string {
+	match := headingPattern.FindStringSubmatch(line)
+	level, text := len(match[1]), r.inline(match[2])
+
+	if level > 2 {
+		return []string{colorTag(Colors.PaneTitle, "b") + text + "[-::-]"}
+	}
+
+	// the two top levels are underlined, as in setext headings
+	underline := "═"
+	if level == 2 {
+		underline = "─"
+	}
+
+	length := tview.TaggedStringWidth(text)
+	if width > 0 && length > width {
+		length = width
+	}
+
+	return []string{
+		colorTag(Colors.Title, "b") + text + "[-::-]",
+		colorTag(Colors.Title, "") + strings.Repeat(underline, length) + "[-]",
+	}
+}
+
+// code returns the lines of a fenced code block as they are.
+func (r *markdownRenderer) code(lines []string, start int) ([]string, int) {
+	fence := fencePattern.FindStringSubmatch(lines[start])[1]
+
+	var block []string
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		block = append(block, colorTag(Colors.Accent, "")+"  "+tview.Escape(lines[i])+"[-]")
+	}
+
+	return block, i
+}
+
+func (r *markdownRenderer) paragraph(lines []string, start int, width int) ([]string, int) {
+	var parts []string
+
+	i := start
+	for ; i < len(lines) && (i == start || !startsBlock(lines, i)); i++ {
+		parts = append(parts, strings.TrimSpace(lines[i]))
+	}
+
+	return wrapText(r.inline(strings.Join(parts, " ")), width, "", ""), i
+}
+
+// quote renders the lines of a block quote, which may hold any other block,
+// behind a bar.
+func (r *markdownRenderer) quote(lines []string, start int, width int) ([]string, int) {
+	var inner []string
+
+	i := start
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if !strings.HasPrefix(line, ">") {
+			break
+		}
+
+		line = strings.TrimPrefix(line, ">")
+		inner = append(inner, strings.TrimPrefix(line, " "))
+	}
+
+	innerWidth := width
+	if width > 0 {
+		innerWidth = width - 2
+	}
+
+	bar := colorTag(Colors.Muted, "") + "│" + "[-] "
+
+	var block []string
+	for _, line := range strings.Split(strings.Join(r.blocks(inner, innerWidth), "\n\n"), "\n") {
+		block = append(block, strings.TrimRight(bar+line, " "))
+	}
+
+	return block, i
+}
+
+// list renders the items of a list, which are nested by indenting them.
+// Ordered items are numbered from the number of their first item.
+func (r *markdownRenderer) list(lines []string, start int, width int) ([]string, int) {
+	var (
+		block   []string
+		indents []int
+		numbers = map[int]int{}
+	)
+
+	i := start
+	for i < len(lines) {
+		match := listPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			break
+		}
+
+		// the level of an item is how many items it is indented below
+		indent := len(strings.ReplaceAll(match[1], "\t", "    "))
+		for len(indents) > 0 && indent < indents[len(indents)-1] {
+			delete(numbers, len(indents))
+			indents = indents[:len(indents)-1]
+		}
+		if len(indents) == 0 || indent > indents[len(indents)-1] {
+			indents = append(indents, indent)
+		}
+		level := len(indents) - 1
+
+		parts := []string{match[3]}
+
+		// lines up to the next item or block continue the item
+		for i++; i < len(lines) && !startsBlock(lines, i); i++ {
+			parts = append(parts, strings.TrimSpace(lines[i]))
+		}
+
+		text := strings.Join(parts, " ")
+		marker := bullets[len(bullets)-1]
+		if level < len(bullets) {
+			marker = bullets[level]
+		}
+
+		switch {
+		case strings.HasSuffix(match[2], ".") || strings.HasSuffix(match[2], ")"):
+			number, ok := numbers[level]
+			if !ok {
+				number, _ = strconv.Atoi(match[2][:len(match[2])-1])
+			}
+			numbers[level] = number + 1
+			marker = strconv.Itoa(number) + "."
+		case strings.HasPrefix(text, "[ ] "):
+			marker, text = "☐", text[4:]
+		case strings.HasPrefix(text, "[x] "), strings.HasPrefix(text, "[X] "):
+			marker, text = "☑", text[4:]
+		}
+
+		prefix := strings.Repeat("  ", level+1) + marker + " "
+		hanging := strings.Repeat(" ", tview.TaggedStringWidth(prefix))
+
+		block = append(block, wrapText(r.inline(text), width, colorTag(Colors.Accent, "")+prefix+"[-]", hanging)...)
+	}
+
+	return block, i
+}
+
+// isTableStart returns true if a line is the header of a table, followed by
+// a line separating it from the rows, such as '|---|:--:|'.
+func isTableStart(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !strings.Contains(lines[i+1], "-") {
+		return false
+	}
+
+	cells := tableCells(lines[i+1])
+	if len(cells) == 0 {
+		return false
+	}
+
+	for _, cell := range cells {
+		if !tableRuleCell.MatchString(cell) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tableCells splits a table row into its cells.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	// escaped pipes are part of a cell
+	cells := strings.Split(strings.ReplaceAll(line, `\|`, "\x00"), "|")
+
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(cell, "\x00", "|"))
+	}
+
+	return cells
+}
+
+// table renders a table with its columns aligned. Tables are not wrapped,
+// but scroll horizontally if wider than the view.
+func (r *markdownRenderer) table(lines []string, start int) ([]string, int) {
+	var (
+		aligns = []int{}
+		rows   [][]string
+	)
+
+	for _, cell := range tableCells(lines[start+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, tview.AlignCenter)
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, tview.AlignRight)
+		default:
+			aligns = append(aligns, tview.AlignLeft)
+		}
+	}
+
+	i := start
+	for ; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+		if i == start+1 {
+			continue
+		}
+
+		row := make([]string, len(aligns))
+		for column, cell := range tableCells(lines[i]) {
+			if column < len(row) {
+				row[column] = r.inline(cell)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(aligns))
+	for _, row := range rows {
+		for column, cell := range row {
+			if width := tview.TaggedStringWidth(cell); width > widths[column] {
+				widths[column] = width
+			}
+		}
+	}
+
+	var (
+		block = make([]string, 0, len(rows)+1)
+		rule  = make([]string, 0, len(widths))
+	)
+
+	for column, width := range widths {
+		rule = append(rule, strings.Repeat("─", width))
+		rows[0][column] = "[::b]" + rows[0][column] + "[::-]"
+	}
+
+	separator := colorTag(Colors.Muted, "") + " │ " + "[-]"
+
+	for index, row := range rows {
+		cells := make([]string, 0, len(row))
+		for column, cell := range row {
+			cells = append(cells, alignCell(cell, widths[column], aligns[column]))
+		}
+		block = append(block, strings.TrimRight(strings.Join(cells, separator), " "))
+
+		if index == 0 {
+			block = append(block, colorTag(Colors.Muted, "")+strings.Join(rule, "─┼─")+"[-]")
+		}
+	}
+
+	return block, i
+}
+
+// alignCell pads a table cell to width.
+func alignCell(cell string, width, align int) string {
+	padding := width - tview.TaggedStringWidth(cell)
+
+	switch align {
+	case tview.AlignRight:
+		return strings.Repeat(" ", padding) + cell
+	case tview.AlignCenter:
+		return strings.Repeat(" ", padding/2) + cell + strings.Repeat(" ", padding-padding/2)
+	default:
+		return cell + strings.Repeat(" ", padding)
+	}
+}
+
+// inline renders emphasis, code, and links within a block. [[Links]] to
+// notes are shown by name, other links are numbered.
+func (r *markdownRenderer) inline(text string) string {
+	var (
+		res     strings.Builder
+		literal strings.Builder
+	)
+
+	flush := func() {
+		res.WriteString(tview.Escape(literal.String()))
+		literal.Reset()
+	}
+
+	// styled wraps the text between delimiters, if closed, in tags
+	styled := func(i int, open, close, before, after string) (int, bool) {
+		end := strings.Index(text[i+len(open):], close)
+		if end <= 0 {
+			return i, false
+		}
+
+		inner := text[i+len(open) : i+len(open)+end]
+		if strings.TrimSpace(inner) != inner {
+			return i, false
+		}
+
+		flush()
+		res.WriteString(before + r.inline(inner) + after)
+		return i + len(open) + end + len(close), true
+	}
+
+	for i := 0; i < len(text); {
+		var (
+			next int
+			ok   bool
+		)
+
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && strings.ContainsRune("\\`*_[]()#+-.!|~", rune(text[i+1])):
+			literal.WriteByte(text[i+1])
+			i += 2
+			continue
+		case text[i] == '`':
+			if end := strings.Index(text[i+1:], "`"); end >= 0 {
+				flush()
+				res.WriteString(colorTag(Colors.Accent, "") + tview.Escape(text[i+1:i+1+end]) + "[-]")
+				next, ok = i+end+2, true
+			}
+		case strings.HasPrefix(text[i:], "[["):
+			if end := strings.Index(text[i:], "]]"); end > 2 {
+				flush()
+				res.WriteString(colorTag(Colors.Accent, "u") + tview.Escape(text[i+2:i+end]) + "[-::-]")
+				next, ok = i+end+2, true
+			}
+		case text[i] == '[' || strings.HasPrefix(text[i:], "!["):
+			next, ok = r.link(text, i, &res, flush)
+		case strings.HasPrefix(text[i:], "**"), strings.HasPrefix(text[i:], "__"):
+			next, ok = styled(i, text[i:i+2], text[i:i+2], "[::b]", "[::-]")
+		case strings.HasPrefix(text[i:], "~~"):
+			next, ok = styled(i, "~~", "~~", "[::s]", "[::-]")
+		case text[i] == '*', text[i] == '_' && (i == 0 || !isWordByte(text[i-1])):
+			next, ok = styled(i, text[i:i+1], text[i:i+1], "[::i]", "[::-]")
+		}
+
+		if ok {
+			i = next
+		} else {
+			literal.WriteByte(text[i])
+			i++
+		}
+	}
+
+	flush()
+	return res.String()
+}
+
+// link renders a Markdown link or image at text[i] by its text, followed by
+// its number in the list of links.
+func (r *markdownRenderer) link(text string, i int, res *strings.Builder, flush func()) (int, bool) {
+	image := text[i] == '!'
+	start := i
+	if image {
+		start++
+	}
+
+	middle := strings.Index(text[start:], "](")
+	if middle < 0 {
+		return i, false
+	}
+	middle += start
+
+	end := strings.Index(text[middle:], ")")
+	if end < 0 {
+		return i, false
+	}
+	end += middle
+
+	label, url := text[start+1:middle], strings.TrimSpace(text[middle+2:end])
+
+	// a title may follow the url, e.g. (https://example.com "Example")
+	if fields := strings.Fields(url); len(fields) > 0 {
+		url = fields[0]
+	}
+
+	if image {
+		label = "image: " + label
+	}
+
+	flush()
+	res.WriteString(colorTag(Colors.Accent, "u") + r.inline(label) + "[-::-]" + tview.Escape(fmt.Sprintf("[%d]", r.linkNumber(url))))
+
+	return end + 1, true
+}
+
+// linkNumber returns the number of a link in the list of links, adding it if
+// it was not linked before.
+func (r *markdownRenderer) linkNumber(url string) int {
+	for i, link := range r.links {
+		if link == url {
+			return i + 1
+		}
+	}
+
+	r.links = append(r.links, url)
+	return len(r.links)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// wrapText wraps text with tags into lines of width, prefixing the first
+// line and indenting the others. A width of 0 does not wrap.
+func wrapText(text string, width int, prefix, indent string) []string {
+	words := strings.Fields(text)
+
+	if len(words) == 0 {
+		return []string{prefix}
+	}
+
+	var (
+		lines  []string
+		line   = prefix + words[0]
+		length = tview.TaggedStringWidth(line)
+	)
+
+	for _, word := range words[1:] {
+		wordLength := tview.TaggedStringWidth(word)
+
+		if width > 0 && length+1+wordLength > width {
+			lines = append(lines, line)
+			line, length = indent+word, tview.TaggedStringWidth(indent)+wordLength
+			continue
+		}
+
+		line += " " + word
+		length += 1 + wordLength
+	}
+
+	return append(lines, line)
+}
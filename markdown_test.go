@@ -0,0 +1,124 @@
+package nve
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestRenderMarkdownGolden(t *testing.T) {
+	defer func(theme *Theme) { Colors = theme }(Colors)
+	Colors = DarkTheme()
+
+	inputs, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.md"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			text, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			golden := strings.TrimSuffix(input, ".md") + ".golden"
+			actual := RenderMarkdown(string(text), 60) + "\n"
+
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(actual), 0644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), actual)
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	defer func(theme *Theme) { Colors = theme }(Colors)
+	Colors = NoColorTheme()
+
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected string
+	}{
+		{
+			name:     "keeps underscores within words",
+			text:     "snake_case_name and _emphasis_",
+			expected: "snake_case_name and [::i]emphasis[::-]",
+		},
+		{
+			name:     "escapes markdown and tags",
+			text:     `\*not emphasis\* and [red] text`,
+			expected: "*not emphasis* and [red[] text",
+		},
+		{
+			name:     "leaves unclosed emphasis",
+			text:     "2 * 3 * 4 and **open",
+			expected: "2 * 3 * 4 and **open",
+		},
+		{
+			name:     "numbers each link once",
+			text:     "[a](https://a.com), [b](https://b.com) and [a again](https://a.com)",
+			expected: "[-::u]a[-::-][1[], [-::u]b[-::-][2[] and [-::u]a again[-::-][1[]\n\n[-::b]Links[-::-]\n[1[] https://a.com\n[2[] https://b.com",
+		},
+		{
+			name:     "wraps paragraphs to width",
+			text:     "one two three\nfour five",
+			width:    9,
+			expected: "one two\nthree\nfour five",
+		},
+		{
+			name:     "does not wrap without width",
+			text:     "one two three\nfour five",
+			expected: "one two three four five",
+		},
+		{
+			name:     "skips front matter",
+			text:     "---\ntitle: Note\n---\nbody",
+			expected: "body",
+		},
+		{
+			name:     "pads table cells",
+			text:     "| a | b |\n|---|--:|\n| long | 1 |",
+			expected: "[::b]a[::-]   [-] │ [-][::b]b[::-]\n[-]─────┼──[-]\nlong[-] │ [-]1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RenderMarkdown(tt.text, tt.width))
+		})
+	}
+}
+
+func TestContentBoxPreview(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(filename, []byte("# Title\n\nbody"), 0644))
+
+	box := NewContentBox()
+	box.SetFile(&FileRef{Filename: filename})
+
+	box.TogglePreview()
+	assert.True(t, box.IsPreviewing())
+	assert.Equal(t, "Preview", box.GetTitle())
+
+	// typing does not change the note while previewing
+	box.InputHandler()(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), func(p tview.Primitive) {})
+	assert.Equal(t, "# Title\n\nbody", box.GetText())
+
+	box.TogglePreview()
+	assert.False(t, box.IsPreviewing())
+	assert.Equal(t, "Content", box.GetTitle())
+}
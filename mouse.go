@@ -0,0 +1,46 @@
+package nve
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// mouseHandler handles mouse events for a primitive, as returned by its
+// MouseHandler method.
+type mouseHandler func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (bool, tview.Primitive)
+
+// keepFocus returns the mouse handler of a primitive embedded in (or
+// contained by) p, which focuses and captures the mouse for p rather than
+// for the primitive clicked, so that keys keep going through p. The
+// primitive clicked is passed to clicked, if given, to focus it within p.
+func keepFocus(p tview.Primitive, handler mouseHandler, clicked func(target tview.Primitive)) mouseHandler {
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (bool, tview.Primitive) {
+		consumed, capture := handler(action, event, func(target tview.Primitive) {
+			if clicked != nil {
+				clicked(target)
+			}
+			setFocus(p)
+		})
+
+		if capture != nil {
+			capture = p
+		}
+
+		return consumed, capture
+	}
+}
+
+// modal is a primitive centered over the rest of the UI, which keeps the
+// mouse from reaching the panes below while it is shown.
+type modal struct {
+	*tview.Flex
+}
+
+// MouseHandler passes events on to the primitive shown, and ignores those
+// around it.
+func (m *modal) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return m.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		_, capture = m.Flex.MouseHandler()(action, event, setFocus)
+		return true, capture
+	})
+}
@@ -1,31 +1,84 @@
 package nve
 
 import (
+	"database/sql"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3" // sqlite driver
+	"github.com/pkg/errors"
 )
 
 var logger = log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
 
 type NotesConfig struct {
-	Filepath string
-	DBPath   string
+	Filepath      string
+	DBPath        string
+	Extension     string
+	SnippetLength int
 }
 
 type Notes struct {
 	LastQuery         string
 	LastSearchResults []*SearchResult
 
+	// Status is what happens to notes in the background, such as saving.
+	Status Status
+
+	// ShowFolders displays the folder of every search result, rather than
+	// only for results whose names collide.
+	ShowFolders bool
+
 	config    NotesConfig
 	db        *DB
 	observers []Observer
 	watcher   io.Closer
 	drawFunc  func(func())
+	scope     string
+	sortOrder SortOrder
+
+	// status updates made off the event loop, queued until a goroutine of
+	// their own passes them to it, and the channels waking and stopping it
+	statusLock   sync.Mutex
+	statusQueue  []func(s *Status)
+	statusSignal chan struct{}
+	statusStop   chan struct{}
+
+	// refreshFailed is set while the watcher fails to refresh the index
+	refreshFailed bool
+
+	// changes are the last merges and splits of notes, to be undone
+	changes []*change
+}
+
+// SortOrder is the order in which notes are listed, after pinned notes.
+type SortOrder int
+
+const (
+	// SortByModified lists notes most recently modified first, except for
+	// full-text searches which list the best matches first.
+	SortByModified SortOrder = iota
+	// SortByName lists notes alphabetically.
+	SortByName
+)
+
+// String returns the name of the order, e.g. 'name'.
+func (o SortOrder) String() string {
+	if o == SortByName {
+		return "name"
+	}
+	return "modified"
+}
+
+// Next returns the order following this one, cycling through all orders.
+func (o SortOrder) Next() SortOrder {
+	return (o + 1) % (SortByName + 1)
 }
 
 var DefaultDBPath = "./nve.db"
@@ -39,11 +92,22 @@ func NewNotes(config NotesConfig) *Notes {
 		config.DBPath = DefaultDBPath
 	}
 
+	if config.Extension == "" {
+		config.Extension = DefaultExtension
+	}
+
+	if config.SnippetLength == 0 {
+		config.SnippetLength = DefaultSnippetLength
+	}
+
 	notes := &Notes{
 		config: config,
 		db:     MustOpen(config.DBPath),
 	}
 
+	notes.db.SnippetLength = config.SnippetLength
+	notes.db.Root = config.Filepath
+
 	if _, err := notes.Refresh(); err != nil {
 		panic(err)
 	}
@@ -62,9 +126,15 @@ func (n *Notes) Search(text string) ([]string, error) {
 	log.Printf("[DEBUG] Notes: Search called with text='%s'", text)
 	n.LastQuery = text
 
-	if text == "" {
+	switch {
+	case n.scope != "":
+		searchResults, err = n.db.Search(fmt.Sprintf("in:%s %s", n.scope, text))
+	case text == "" && n.sortOrder == SortByName:
+		// the most recent notes would be an arbitrary subset by name
+		searchResults, err = n.db.Search("")
+	case text == "":
 		searchResults, err = n.db.Recent(20)
-	} else {
+	default:
 		searchResults, err = n.db.Search(text)
 	}
 
@@ -72,6 +142,12 @@ func (n *Notes) Search(text string) ([]string, error) {
 		return nil, err
 	}
 
+	if n.sortOrder == SortByName {
+		sortByName(searchResults)
+	}
+
+	n.labelFolders(searchResults)
+
 	// 1. perform the search
 	n.LastSearchResults = searchResults
 
@@ -89,37 +165,383 @@ func (n *Notes) Search(text string) ([]string, error) {
 	return res, nil
 }
 
+// Passages returns up to count passages of a note matching the last search,
+// best first, each of the configured snippet length with its matches marked
+// as in snippets. Front matter is left out, as it is when searching.
+func (n *Notes) Passages(ref *FileRef, count int) []string {
+	_, body, _ := splitFrontMatter(GetContent(ref.Filename))
+
+	return passages(body, searchTerms(n.LastQuery), count, n.config.SnippetLength)
+}
+
+// TogglePin pins a note to the top of the results, or unpins it, then
+// refreshes the last search.
+func (n *Notes) TogglePin(ref *FileRef) error {
+	return n.TogglePins([]*FileRef{ref})
+}
+
+// SortOrder returns the order in which notes are listed.
+func (n *Notes) SortOrder() SortOrder {
+	return n.sortOrder
+}
+
+// SetSortOrder changes the order in which notes are listed, then refreshes
+// the last search.
+func (n *Notes) SetSortOrder(order SortOrder) error {
+	n.sortOrder = order
+
+	_, err := n.Search(n.LastQuery)
+	return err
+}
+
+// sortByName sorts results by name, keeping pinned notes first.
+func sortByName(results []*SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		return strings.ToLower(a.Name()) < strings.ToLower(b.Name())
+	})
+}
+
+// DeleteNote removes a note from disk and from the index, then refreshes the
+// last search.
+func (n *Notes) DeleteNote(ref *FileRef) error {
+	return n.DeleteNotes([]*FileRef{ref})
+}
+
+// Reindex rebuilds the index of every note from the files on disk, then
+// refreshes the last search.
+func (n *Notes) Reindex() error {
+	if err := n.db.Invalidate(); err != nil {
+		return err
+	}
+
+	if _, err := n.Refresh(); err != nil {
+		return err
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return err
+}
+
+// labelFolders sets the folder of results to be displayed with their names:
+// all of them if ShowFolders is set, otherwise those sharing a name.
+func (n *Notes) labelFolders(results []*SearchResult) {
+	counts := map[string]int{}
+
+	for _, result := range results {
+		counts[strings.ToLower(result.Name())]++
+	}
+
+	for _, result := range results {
+		if n.ShowFolders || counts[strings.ToLower(result.Name())] > 1 {
+			result.Folder = n.Folder(result.FileRef)
+		}
+	}
+}
+
+// Folder returns the folder of a note relative to the notes directory, using
+// '/' as separator, or an empty string for notes at the top level.
+func (n *Notes) Folder(ref *FileRef) string {
+	dir, err := filepath.Rel(n.config.Filepath, filepath.Dir(ref.Filename))
+
+	if err != nil || dir == "." {
+		return ""
+	}
+
+	return filepath.ToSlash(dir)
+}
+
+// RelativeName returns the name of a note qualified by its folder (e.g.
+// 'folder/name'), as accepted by CreateNote.
+func (n *Notes) RelativeName(ref *FileRef) string {
+	if folder := n.Folder(ref); folder != "" {
+		return folder + "/" + ref.DisplayName()
+	}
+
+	return ref.DisplayName()
+}
+
+// RelativePath returns the path of a note relative to the notes directory,
+// using '/' as separator, e.g. 'folder/name.md'.
+func (n *Notes) RelativePath(ref *FileRef) string {
+	path, err := filepath.Rel(n.config.Filepath, ref.Filename)
+
+	if err != nil {
+		return ref.Filename
+	}
+
+	return filepath.ToSlash(path)
+}
+
+// Scope returns the folder searches are restricted to, if any.
+func (n *Notes) Scope() string {
+	return n.scope
+}
+
+// SetScope restricts searches to notes within the given folder (relative to
+// the notes directory). An empty folder searches all notes.
+func (n *Notes) SetScope(folder string) {
+	n.scope = normalizeFolder(folder)
+}
+
 func (n *Notes) CreateNote(name string) (*FileRef, error) {
-	path := filepath.Join(n.config.Filepath, fmt.Sprintf("%s.%s", name, "md"))
-	newFile, err := os.OpenFile(path, os.O_CREATE, 0644)
+	fileRef, _, err := n.CreateNoteFromTemplate(name, DefaultTemplate)
+	return fileRef, err
+}
+
+// CreateNoteFromTemplate creates a note filled in from the named template,
+// if such a template exists. Existing notes are left untouched. Names may
+// include subdirectories (e.g. 'journal/2024-07-01'), which are created as
+// needed, and an extension. Names which are not safe to use as file names
+// are sanitized, keeping the name as typed as the note's title. Returns the
+// offset at which the cursor should be placed.
+func (n *Notes) CreateNoteFromTemplate(name, template string) (*FileRef, int, error) {
+	var (
+		content = ""
+		cursor  = 0
+	)
+
+	path, title, err := n.notePath(name)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	sanitized := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) != title
+
+	if sanitized {
+		path = uniquePath(path, title)
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if tmpl, ok := n.templateContent(template); ok {
+			content, cursor = renderTemplate(tmpl, title)
+		}
+
+		if sanitized {
+			content, cursor = withTitle(content, cursor, title)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, 0, err
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		content = GetContent(path)
 	}
 
 	md5, err := calculateMD5(path)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	stat, err := newFile.Stat()
+	stat, err := os.Stat(path)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	fileRef := FileRef{
-		Filename:   newFile.Name(),
+		Filename:   path,
 		MD5:        md5,
 		ModifiedAt: stat.ModTime(),
 	}
 
-	if err := n.db.Insert(&fileRef, []byte{}); err != nil {
+	if err := n.db.Upsert(&fileRef, []byte(content)); err != nil {
+		return nil, 0, err
+	}
+
+	// re-read, as an already indexed note is left unchanged by Upsert
+	indexed, err := n.db.GetFileRef(path)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return indexed, cursor, nil
+}
+
+// FollowLink returns the note named by a [[link]] target, creating it
+// if it does not exist yet.
+func (n *Notes) FollowLink(name string) (*FileRef, error) {
+	ref, err := n.db.FindByName(name)
+
+	switch err {
+	case nil:
+		return ref, nil
+	case sql.ErrNoRows:
+		return n.CreateNote(name)
+	default:
+		return nil, err
+	}
+}
+
+// Titles returns note names fuzzy-matching query, best matches first.
+func (n *Notes) Titles(query string) ([]string, error) {
+	return n.db.Titles(query, maxCompletionItems)
+}
+
+// Tags returns every tag in use along with its note count.
+func (n *Notes) Tags() ([]*TagCount, error) {
+	return n.db.Tags()
+}
+
+// TagNames returns tag names fuzzy-matching query, best matches first.
+func (n *Notes) TagNames(query string) ([]string, error) {
+	tags, err := n.db.Tags()
+
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		names = append(names, tag.Tag)
+	}
+
+	return fuzzyFilter(query, names, maxCompletionItems), nil
+}
+
+// Backlinks returns the notes that contain a [[link]] to the given note.
+func (n *Notes) Backlinks(fileRef *FileRef) ([]*FileRef, error) {
+	refs, err := n.db.Backlinks(fileRef.DisplayName())
+
+	if err != nil {
 		return nil, err
 	}
 
-	return &fileRef, nil
+	// a note linking to itself is not a backlink
+	res := make([]*FileRef, 0, len(refs))
+
+	for _, ref := range refs {
+		if ref.DocumentID != fileRef.DocumentID {
+			res = append(res, ref)
+		}
+	}
+
+	return res, nil
+}
+
+// RenameNote renames a note on disk, keeping its extension and directory,
+// and rewrites any [[links]] in other notes that pointed to the old name.
+func (n *Notes) RenameNote(fileRef *FileRef, name string) (*FileRef, error) {
+	name = sanitizeFilename(strings.TrimSpace(name))
+
+	if name == "" {
+		return nil, errors.New("name is blank")
+	}
+
+	oldName := fileRef.DisplayName()
+	path := filepath.Join(filepath.Dir(fileRef.Filename), name+filepath.Ext(fileRef.Filename))
+
+	if path == fileRef.Filename {
+		return fileRef, nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil, errors.Errorf("note already exists: %s", name)
+	}
+
+	backlinks, err := n.Backlinks(fileRef)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(fileRef.Filename, path); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	renamed := *fileRef
+
+	if err := n.db.Rename(&renamed, path); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range backlinks {
+		content := GetContent(ref.Filename)
+
+		if updated := rewriteLinks(content, oldName, name); updated != content {
+			if err := SaveContent(ref.Filename, updated); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if err := n.indexFile(ref.Filename); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &renamed, nil
+}
+
+// indexFile reads a single file from disk and upserts it into the database.
+func (n *Notes) indexFile(path string) error {
+	md5, err := calculateMD5(path)
+
+	if err != nil {
+		return err
+	}
+
+	stats, err := os.Stat(path)
+
+	if err != nil {
+		return err
+	}
+
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	return n.db.Upsert(&FileRef{
+		Filename:   path,
+		MD5:        md5,
+		ModifiedAt: stats.ModTime(),
+	}, bytes)
+}
+
+// SetDrawFunc sets the function used to marshal updates from background
+// goroutines, such as saving, onto the UI's event loop. Setting nil stops
+// marshaling updates, e.g. once the UI exits.
+func (n *Notes) SetDrawFunc(drawFunc func(func())) {
+	n.drawFunc = drawFunc
+
+	n.statusLock.Lock()
+	defer n.statusLock.Unlock()
+
+	if n.statusStop != nil {
+		close(n.statusStop)
+		n.statusSignal, n.statusStop = nil, nil
+	}
+
+	if drawFunc == nil {
+		return
+	}
+
+	signal, stop := make(chan struct{}, 1), make(chan struct{})
+	n.statusSignal, n.statusStop = signal, stop
+
+	go func() {
+		for {
+			select {
+			case <-signal:
+				drawFunc(n.applyStatusUpdates)
+			case <-stop:
+				return
+			}
+		}
+	}()
 }
 
 func (n *Notes) RegisterObservers(obs ...Observer) {
@@ -1,10 +1,16 @@
 package nve
 
 import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var notes *Notes
@@ -66,6 +72,16 @@ func TestSearch(t *testing.T) {
 			input:    "yor",
 			expected: []string{"test_data/apples in zoo.md"},
 		},
+		{
+			name:     "filters by folder",
+			input:    "in:nested",
+			expected: []string{"test_data/nested/cucumbers.md"},
+		},
+		{
+			name:     "filters by folder and content",
+			input:    "in:nested/ zoo",
+			expected: []string{},
+		},
 		{
 			name:     "locates files by case-insensitive content match",
 			input:    "YOR",
@@ -104,9 +120,162 @@ func TestNotifyObservers(t *testing.T) {
 		res := mock.lastResult[0]
 
 		// assert snippet
-		assert.Equal(t, "new york **seattle** ", res.Snippet)
+		assert.Equal(t, "new york "+matchStart+"seattle"+matchEnd+" ", res.Snippet)
 
 		// assert filename
 		assert.Equal(t, "test_data/apples in zoo.md", res.Filename)
 	}
 }
+
+func TestNotesInFolders(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	for _, name := range []string{"todo", "work/todo", "work/plans"} {
+		_, err := n.CreateNote(name)
+		require.NoError(t, err)
+	}
+
+	assert.FileExists(t, filepath.Join(dir, "work", "todo.md"))
+
+	names := func() []string {
+		var names []string
+		for _, result := range n.LastSearchResults {
+			names = append(names, result.Name())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("disambiguates colliding names", func(t *testing.T) {
+		n.Search("")
+		assert.Equal(t, []string{"plans", "todo", "work/todo"}, names())
+	})
+
+	t.Run("shows all folders", func(t *testing.T) {
+		n.ShowFolders = true
+		defer func() { n.ShowFolders = false }()
+
+		n.Search("")
+		assert.Equal(t, []string{"todo", "work/plans", "work/todo"}, names())
+	})
+
+	t.Run("scopes searches to folder", func(t *testing.T) {
+		n.SetScope("work/")
+		defer n.SetScope("")
+
+		n.Search("")
+		assert.Equal(t, []string{"plans", "todo"}, names())
+	})
+
+	t.Run("finds notes by qualified name", func(t *testing.T) {
+		ref, err := n.db.FindByName("Work/Todo")
+		require.NoError(t, err)
+		assert.Equal(t, "work/todo", n.RelativeName(ref))
+	})
+
+	t.Run("rejects names without a note", func(t *testing.T) {
+		_, err := n.CreateNote("work/")
+		assert.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, "work", ".md"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestNotesInFoldersNamedLikeNotesDirectory(t *testing.T) {
+	// the notes directory is itself within a folder named like a note folder
+	dir := filepath.Join(t.TempDir(), "work", "notes")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	n := NewNotes(NotesConfig{Filepath: dir, DBPath: filepath.Join(dir, "test.db")})
+
+	for _, name := range []string{"todo", "work/plans", "projects/work/ideas"} {
+		_, err := n.CreateNote(name)
+		require.NoError(t, err)
+	}
+
+	n.Search("in:work")
+	require.Len(t, n.LastSearchResults, 1)
+	assert.Equal(t, "work/plans", n.RelativeName(n.LastSearchResults[0].FileRef))
+
+	n.Search("in:projects/work")
+	require.Len(t, n.LastSearchResults, 1)
+	assert.Equal(t, "projects/work/ideas", n.RelativeName(n.LastSearchResults[0].FileRef))
+
+	// qualified names are found from the notes directory only
+	_, err := n.db.FindByName("notes/todo")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = n.db.FindByName("work/ideas")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	ref, err := n.db.FindByName("Projects/Work/Ideas")
+	require.NoError(t, err)
+	assert.Equal(t, "projects/work/ideas", n.RelativeName(ref))
+}
+
+func TestNotesCommands(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	for _, name := range []string{"banana", "apple", "work/cherry"} {
+		_, err := n.CreateNote(name)
+		require.NoError(t, err)
+	}
+
+	names := func() []string {
+		var names []string
+		for _, result := range n.LastSearchResults {
+			names = append(names, result.Name())
+		}
+		return names
+	}
+
+	t.Run("sorts by name", func(t *testing.T) {
+		ref, err := n.db.FindByName("cherry")
+		require.NoError(t, err)
+		require.NoError(t, n.TogglePin(ref))
+		defer n.TogglePin(ref)
+
+		require.NoError(t, n.SetSortOrder(SortByName))
+		defer n.SetSortOrder(SortByModified)
+
+		assert.Equal(t, []string{"cherry", "apple", "banana"}, names())
+		assert.Equal(t, SortByModified, n.SortOrder().Next())
+	})
+
+	t.Run("exports notes", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		count, err := n.Export(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+
+		var files []string
+		for _, f := range archive.File {
+			files = append(files, f.Name)
+		}
+		assert.ElementsMatch(t, []string{"apple.md", "banana.md", "work/cherry.md"}, files)
+	})
+
+	t.Run("reindexes notes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "apple.md"), []byte("crunchy"), 0644))
+		require.NoError(t, n.Reindex())
+
+		n.Search("crunchy")
+		assert.Equal(t, []string{"apple"}, names())
+	})
+
+	t.Run("deletes note", func(t *testing.T) {
+		ref, err := n.db.FindByName("banana")
+		require.NoError(t, err)
+		require.NoError(t, n.DeleteNote(ref))
+
+		assert.NoFileExists(t, ref.Filename)
+
+		n.Search("")
+		assert.ElementsMatch(t, []string{"apple", "cherry"}, names())
+	})
+}
@@ -3,3 +3,10 @@ package nve
 type Observer interface {
 	SearchResultsUpdate(*Notes)
 }
+
+// StatusObserver is an Observer also told when the status of notes changes,
+// such as a note being saved.
+type StatusObserver interface {
+	Observer
+	StatusUpdate(*Notes)
+}
@@ -0,0 +1,215 @@
+package nve
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/tview"
+)
+
+const (
+	// PaletteBoxWidth and PaletteBoxHeight are the size of the palette, which
+	// scrolls if the items do not fit.
+	PaletteBoxWidth  = 64
+	PaletteBoxHeight = 16
+)
+
+// PaletteItem is an entry of the palette: a label to filter by, a hint
+// shown next to it (such as the keys of a command), and what picking it
+// does.
+type PaletteItem struct {
+	Label string
+	Hint  string
+	Run   func()
+}
+
+// PaletteBox is a modal list of items fuzzy-filtered by typing, used to run
+// any command and to pick recently opened notes.
+type PaletteBox struct {
+	*tview.Flex
+	input    *tview.InputField
+	list     *tview.List
+	items    []PaletteItem
+	shown    []PaletteItem
+	doneFunc func()
+}
+
+func NewPaletteBox() *PaletteBox {
+	box := PaletteBox{
+		Flex:  tview.NewFlex(),
+		input: tview.NewInputField(),
+		list:  tview.NewList(),
+	}
+
+	box.input.SetFieldBackgroundColor(Colors.Background).
+		SetLabel("> ").
+		SetLabelColor(Colors.Accent).
+		SetBackgroundColor(Colors.Background)
+
+	box.input.SetChangedFunc(func(text string) {
+		box.filter(text)
+	})
+
+	box.list.ShowSecondaryText(false).
+		SetWrapAround(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(Colors.SelectedStyle()).
+		SetBackgroundColor(Colors.Background)
+
+	// clicking an item runs it, as Enter does
+	box.list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		if index < len(box.shown) {
+			box.finish(box.shown[index].Run)
+		}
+	})
+
+	box.SetDirection(tview.FlexRow).
+		AddItem(box.input, 1, 0, true).
+		AddItem(box.list, 0, 1, false)
+
+	box.SetBorder(true).
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	return &box
+}
+
+// SetDoneFunc sets a handler called when the palette is closed, before the
+// picked item (if any) is run.
+func (b *PaletteBox) SetDoneFunc(handler func()) *PaletteBox {
+	b.doneFunc = handler
+	return b
+}
+
+// Show lists the given items under a title, with an empty filter.
+func (b *PaletteBox) Show(title string, items []PaletteItem) {
+	b.items = items
+	b.SetTitle(title)
+	b.input.SetText("")
+	b.filter("")
+}
+
+// ShowCommands lists all registered commands along with their keys.
+func (b *PaletteBox) ShowCommands(commands *Commands, km *Keymap) {
+	b.Show("Commands", commandItems(commands, km))
+}
+
+// commandItems returns an item running each registered command.
+func commandItems(commands *Commands, km *Keymap) []PaletteItem {
+	var items []PaletteItem
+
+	for _, action := range commands.Actions() {
+		// the palette is already shown
+		if action == ActionCommandPalette {
+			continue
+		}
+
+		action := action
+
+		items = append(items, PaletteItem{
+			Label: actionDescriptions[action],
+			Hint:  formatKeys(km.KeysOf(action)),
+			Run:   func() { commands.Run(action) },
+		})
+	}
+
+	return items
+}
+
+// filterItems returns the items whose labels fuzzy-match text, best matches
+// first, or all items in order if text is empty.
+func filterItems(text string, items []PaletteItem) []PaletteItem {
+	if strings.TrimSpace(text) == "" {
+		return items
+	}
+
+	var (
+		labels  = make([]string, 0, len(items))
+		indexes = map[string][]int{}
+		res     []PaletteItem
+	)
+
+	for i, item := range items {
+		labels = append(labels, item.Label)
+		indexes[item.Label] = append(indexes[item.Label], i)
+	}
+
+	// labels may repeat, so each match takes the next item with its label
+	for _, label := range fuzzyFilter(strings.TrimSpace(text), labels, 0) {
+		res = append(res, items[indexes[label][0]])
+		indexes[label] = indexes[label][1:]
+	}
+
+	return res
+}
+
+func (b *PaletteBox) filter(text string) {
+	b.shown = filterItems(text, b.items)
+	b.list.Clear()
+
+	for _, item := range b.shown {
+		b.list.AddItem(formatPaletteItem(item, PaletteBoxWidth-4), "", 0, nil)
+	}
+}
+
+// formatPaletteItem aligns the hint of an item to the right of its label.
+func formatPaletteItem(item PaletteItem, width int) string {
+	label := tview.Escape(item.Label)
+
+	if item.Hint == "" {
+		return label
+	}
+
+	padding := width - runewidth.StringWidth(item.Label) - runewidth.StringWidth(item.Hint)
+
+	if padding < 1 {
+		padding = 1
+	}
+
+	return label + strings.Repeat(" ", padding) + colorTag(Colors.Muted, "") + tview.Escape(item.Hint) + "[-]"
+}
+
+// InputHandler types into the filter, moves through the list with the keys
+// of the search box, and runs the selected item on Enter.
+func (b *PaletteBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		action, _ := Keys.Lookup(ContextSearch, event)
+
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			b.finish(nil)
+		case event.Key() == tcell.KeyEnter:
+			if index := b.list.GetCurrentItem(); index < len(b.shown) {
+				b.finish(b.shown[index].Run)
+			}
+		case action == ActionSelectNext:
+			b.list.SetCurrentItem(b.list.GetCurrentItem() + 1)
+		case action == ActionSelectPrevious:
+			if index := b.list.GetCurrentItem(); index > 0 {
+				b.list.SetCurrentItem(index - 1)
+			}
+		default:
+			if handler := b.input.InputHandler(); handler != nil {
+				handler(event, setFocus)
+			}
+		}
+	})
+}
+
+// MouseHandler runs the item clicked, and scrolls the list with the wheel.
+func (b *PaletteBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return keepFocus(b, b.Flex.MouseHandler(), nil)
+}
+
+func (b *PaletteBox) finish(run func()) {
+	if b.doneFunc != nil {
+		b.doneFunc()
+	}
+
+	if run != nil {
+		run()
+	}
+}
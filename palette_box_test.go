@@ -0,0 +1,83 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommands(t *testing.T) {
+	var ran []Action
+
+	commands := NewCommands()
+
+	for _, action := range []Action{ActionRename, ActionCommandPalette, ActionDeleteNote} {
+		action := action
+		commands.Register(action, func() bool {
+			ran = append(ran, action)
+			return action != ActionDeleteNote
+		})
+	}
+
+	// registering again replaces the handler, keeping the order
+	commands.Register(ActionRename, func() bool { return false })
+
+	assert.Equal(t, []Action{ActionRename, ActionCommandPalette, ActionDeleteNote}, commands.Actions())
+	assert.False(t, commands.Run(ActionRename))
+	assert.False(t, commands.Run(ActionDeleteNote))
+	assert.False(t, commands.Run(ActionExport), "no handler")
+	assert.Equal(t, []Action{ActionDeleteNote}, ran)
+
+	items := commandItems(commands, DefaultKeymap())
+
+	if assert.Len(t, items, 2, "the palette does not list itself") {
+		assert.Equal(t, PaletteItem{Label: "Rename note", Hint: "F2"}, PaletteItem{Label: items[0].Label, Hint: items[0].Hint})
+		assert.Equal(t, "Delete note", items[1].Label)
+		assert.Empty(t, items[1].Hint, "unbound")
+
+		items[1].Run()
+		assert.Equal(t, []Action{ActionDeleteNote, ActionDeleteNote}, ran)
+	}
+}
+
+func TestFilterItems(t *testing.T) {
+	items := []PaletteItem{
+		{Label: "Rename note"},
+		{Label: "Delete note"},
+		{Label: "Rebuild the search index"},
+		{Label: "work/todo"},
+		{Label: "todo"},
+	}
+
+	labels := func(items []PaletteItem) []string {
+		var labels []string
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "", expected: labels(items)},
+		{text: "  ", expected: labels(items)},
+		{text: "del", expected: []string{"Delete note"}},
+		{text: "re", expected: []string{"Rename note", "Rebuild the search index"}},
+		{text: "todo", expected: []string{"todo", "work/todo"}},
+		{text: "xyz", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, labels(filterItems(tt.text, items)))
+		})
+	}
+}
+
+func TestFormatPaletteItem(t *testing.T) {
+	assert.Equal(t, "Show all [tags[]", formatPaletteItem(PaletteItem{Label: "Show all [tags]"}, 20))
+	assert.Equal(t, "Rename note    [gray]F2[-]", formatPaletteItem(PaletteItem{Label: "Rename note", Hint: "F2"}, 17))
+	assert.Equal(t, "Rename note [gray]F2[-]", formatPaletteItem(PaletteItem{Label: "Rename note", Hint: "F2"}, 4))
+}
@@ -0,0 +1,240 @@
+package nve
+
+// porterStem returns the stem of a lower-case word by the Porter stemming
+// algorithm, as the 'porter' tokenizer of FTS5 does when indexing notes and
+// searching them. Like FTS5, words shorter than 3 or longer than 64 bytes
+// are left as they are.
+//
+// See https://tartarus.org/martin/PorterStemmer/def.txt
+func porterStem(word string) string {
+	if len(word) < 3 || len(word) > 64 {
+		return word
+	}
+
+	s := stemmer{b: []byte(word)}
+
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+
+	return string(s.b)
+}
+
+// stemmer holds a word while its suffixes are removed.
+type stemmer struct {
+	b []byte
+}
+
+// isConsonant returns true if the letter at i is a consonant: not a vowel,
+// and not a 'y' following a consonant.
+func (s *stemmer) isConsonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.isConsonant(i-1)
+	default:
+		return true
+	}
+}
+
+// measure returns the number of vowel-consonant sequences in the first n
+// letters of the word.
+func (s *stemmer) measure(n int) int {
+	var (
+		m      int
+		vowels bool
+	)
+
+	for i := 0; i < n; i++ {
+		if !s.isConsonant(i) {
+			vowels = true
+		} else if vowels {
+			m++
+			vowels = false
+		}
+	}
+
+	return m
+}
+
+// hasVowel returns true if the first n letters of the word contain a vowel.
+func (s *stemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.isConsonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsDouble returns true if the first n letters end with a double
+// consonant.
+func (s *stemmer) endsDouble(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.isConsonant(n-1)
+}
+
+// endsCVC returns true if the first n letters end with a consonant, a vowel
+// and a consonant other than 'w', 'x' or 'y', as in 'hop'.
+func (s *stemmer) endsCVC(n int) bool {
+	if n < 3 || !s.isConsonant(n-1) || s.isConsonant(n-2) || !s.isConsonant(n-3) {
+		return false
+	}
+
+	switch s.b[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+
+	return true
+}
+
+// hasSuffix returns the length of the stem before suffix, or -1 if the word
+// does not end with it.
+func (s *stemmer) hasSuffix(suffix string) int {
+	n := len(s.b) - len(suffix)
+
+	if n < 0 || string(s.b[n:]) != suffix {
+		return -1
+	}
+
+	return n
+}
+
+// replace replaces the end of the word after a stem of length n.
+func (s *stemmer) replace(n int, suffix string) {
+	s.b = append(s.b[:n], suffix...)
+}
+
+// replaceFirst replaces the first of the suffixes (given as pairs of a
+// suffix and its replacement) the word ends with, if its stem has a measure
+// above min. Returns false if the word ends with none of them.
+func (s *stemmer) replaceFirst(min int, suffixes ...string) bool {
+	for i := 0; i < len(suffixes); i += 2 {
+		if n := s.hasSuffix(suffixes[i]); n >= 0 {
+			if s.measure(n) > min {
+				s.replace(n, suffixes[i+1])
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// step1a removes plurals.
+func (s *stemmer) step1a() {
+	switch {
+	case s.hasSuffix("sses") >= 0, s.hasSuffix("ies") >= 0:
+		s.b = s.b[:len(s.b)-2]
+	case s.hasSuffix("ss") >= 0:
+	case s.hasSuffix("s") >= 0:
+		s.b = s.b[:len(s.b)-1]
+	}
+}
+
+// step1b removes -ed and -ing, tidying up the stem left.
+func (s *stemmer) step1b() {
+	if n := s.hasSuffix("eed"); n >= 0 {
+		if s.measure(n) > 0 {
+			s.replace(n, "ee")
+		}
+		return
+	}
+
+	n := s.hasSuffix("ed")
+	if n < 0 {
+		n = s.hasSuffix("ing")
+	}
+
+	if n < 0 || !s.hasVowel(n) {
+		return
+	}
+
+	s.b = s.b[:n]
+
+	switch {
+	case s.hasSuffix("at") >= 0, s.hasSuffix("bl") >= 0, s.hasSuffix("iz") >= 0:
+		s.b = append(s.b, 'e')
+	case s.endsDouble(n):
+		switch s.b[n-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:n-1]
+		}
+	case s.measure(n) == 1 && s.endsCVC(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+// step1c turns a final 'y' into 'i' if there is a vowel before it.
+func (s *stemmer) step1c() {
+	if n := s.hasSuffix("y"); n >= 0 && s.hasVowel(n) {
+		s.b[n] = 'i'
+	}
+}
+
+// step2 maps double suffixes to single ones.
+func (s *stemmer) step2() {
+	s.replaceFirst(0,
+		"ational", "ate", "tional", "tion",
+		"enci", "ence", "anci", "ance",
+		"izer", "ize",
+		"logi", "log",
+		"bli", "ble", "alli", "al", "entli", "ent", "eli", "e", "ousli", "ous",
+		"ization", "ize", "ation", "ate", "ator", "ate",
+		"alism", "al", "iveness", "ive", "fulness", "ful", "ousness", "ous",
+		"aliti", "al", "iviti", "ive", "biliti", "ble",
+	)
+}
+
+// step3 removes or shortens -ic-, -full, -ness and the like.
+func (s *stemmer) step3() {
+	s.replaceFirst(0,
+		"icate", "ic", "ative", "", "alize", "al",
+		"iciti", "ic", "ical", "ic",
+		"ful", "",
+		"ness", "",
+	)
+}
+
+// step4 removes suffixes of stems with a measure above 1.
+func (s *stemmer) step4() {
+	if n := s.hasSuffix("ion"); n >= 0 && n > 0 && (s.b[n-1] == 's' || s.b[n-1] == 't') {
+		if s.measure(n) > 1 {
+			s.b = s.b[:n]
+		}
+		return
+	}
+
+	s.replaceFirst(1,
+		"al", "",
+		"ance", "", "ence", "",
+		"er", "",
+		"ic", "",
+		"able", "", "ible", "",
+		"ant", "", "ement", "", "ment", "", "ent", "",
+		"ou", "",
+		"ism", "",
+		"ate", "", "iti", "",
+		"ous", "",
+		"ive", "",
+		"ize", "",
+	)
+}
+
+// step5 removes a final 'e', and a final double 'l', from longer stems.
+func (s *stemmer) step5() {
+	if n := s.hasSuffix("e"); n >= 0 {
+		if m := s.measure(n); m > 1 || (m == 1 && !s.endsCVC(n)) {
+			s.b = s.b[:n]
+		}
+	}
+
+	if n := len(s.b); s.measure(n) > 1 && s.endsDouble(n) && s.b[n-1] == 'l' {
+		s.b = s.b[:n-1]
+	}
+}
@@ -0,0 +1,145 @@
+package nve
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+)
+
+// Names of the built-in keymaps, as set by 'keys.preset' in the config file.
+const (
+	PresetDefault = "default"
+	PresetEmacs   = "emacs"
+	PresetVi      = "vi"
+)
+
+// NewKeymap returns the keybindings of a preset.
+func NewKeymap(preset string) (*Keymap, error) {
+	switch preset {
+	case "", PresetDefault:
+		return DefaultKeymap(), nil
+	case PresetEmacs:
+		return EmacsKeymap(), nil
+	case PresetVi:
+		return ViKeymap(), nil
+	default:
+		return nil, errors.Errorf("unknown keymap preset: %s", preset)
+	}
+}
+
+// mustSeq parses a key sequence of a built-in keymap.
+func mustSeq(text string) KeySequence {
+	seq, err := ParseKeySequence(text)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
+
+// bindNames binds an action to keys given by name, such as 'Ctrl-X u'.
+func (km *Keymap) bindNames(context KeyContext, action Action, names ...string) {
+	keys := make([]KeySequence, 0, len(names))
+
+	for _, name := range names {
+		keys = append(keys, mustSeq(name))
+	}
+
+	km.Bind(context, action, keys...)
+}
+
+// clearContext removes all bindings of a context.
+func (km *Keymap) clearContext(context KeyContext) {
+	for _, b := range km.Bindings(context) {
+		km.Bind(context, b.Action)
+	}
+}
+
+// EmacsKeymap returns the default keybindings, with the content box
+// editing text like emacs does.
+func EmacsKeymap() *Keymap {
+	km := DefaultKeymap()
+	km.clearContext(ContextContent)
+
+	km.Bind(ContextContent, ActionFollowLink, Seq(KeyOf(tcell.KeyCtrlRightSq, 0)))
+	km.bindNames(ContextContent, ActionFind, "Ctrl-S")
+	km.bindNames(ContextContent, ActionCursorUp, "Ctrl-P")
+	km.bindNames(ContextContent, ActionCursorDown, "Ctrl-N")
+	km.bindNames(ContextContent, ActionCursorLeft, "Ctrl-B")
+	km.bindNames(ContextContent, ActionCursorRight, "Ctrl-F")
+	km.bindNames(ContextContent, ActionWordLeft, "Alt-b")
+	km.bindNames(ContextContent, ActionWordRight, "Alt-f")
+	km.bindNames(ContextContent, ActionLineStart, "Ctrl-A")
+	km.bindNames(ContextContent, ActionLineEnd, "Ctrl-E")
+	km.bindNames(ContextContent, ActionPageUp, "Alt-v")
+	km.bindNames(ContextContent, ActionPageDown, "Ctrl-V")
+	km.bindNames(ContextContent, ActionDocumentStart, "Alt-<")
+	km.bindNames(ContextContent, ActionDocumentEnd, "Alt->")
+	km.bindNames(ContextContent, ActionDeleteChar, "Ctrl-D")
+	km.bindNames(ContextContent, ActionDeleteBackward, "Ctrl-H")
+	km.bindNames(ContextContent, ActionDeleteWordBackward, "Alt-Backspace")
+	km.bindNames(ContextContent, ActionKillLine, "Ctrl-K")
+	km.bindNames(ContextContent, ActionSetMark, "Ctrl-Space")
+	km.bindNames(ContextContent, ActionCancel, "Ctrl-G")
+	km.bindNames(ContextContent, ActionSelectAll, "Ctrl-X h")
+	km.bindNames(ContextContent, ActionCopy, "Alt-w")
+	km.bindNames(ContextContent, ActionCut, "Ctrl-W")
+	km.bindNames(ContextContent, ActionPaste, "Ctrl-Y")
+	km.bindNames(ContextContent, ActionUndo, "Ctrl-_", "Ctrl-X u")
+	km.bindNames(ContextContent, ActionRedo, "Alt-Ctrl-_")
+
+	return km
+}
+
+// ViKeymap returns the default keybindings, with the content box editing
+// text like vi does. Notes open in normal mode.
+func ViKeymap() *Keymap {
+	km := DefaultKeymap()
+	km.clearContext(ContextContent)
+
+	// insert mode
+	km.Bind(ContextContent, ActionFollowLink, Seq(KeyOf(tcell.KeyCtrlRightSq, 0)))
+	km.Bind(ContextContent, ActionNormalMode, Seq(KeyOf(tcell.KeyEscape, 0)))
+
+	km.Bind(ContextNormal, ActionFollowLink, Seq(KeyOf(tcell.KeyCtrlRightSq, 0)))
+	bindViMotions(km, ContextNormal)
+	km.bindNames(ContextNormal, ActionInsertMode, "i")
+	km.bindNames(ContextNormal, ActionAppend, "a")
+	km.bindNames(ContextNormal, ActionAppendLineEnd, "A")
+	km.bindNames(ContextNormal, ActionInsertLineStart, "I")
+	km.bindNames(ContextNormal, ActionOpenLineBelow, "o")
+	km.bindNames(ContextNormal, ActionOpenLineAbove, "O")
+	km.bindNames(ContextNormal, ActionVisualMode, "v")
+	km.bindNames(ContextNormal, ActionFind, "/")
+	km.bindNames(ContextNormal, ActionDeleteChar, "x")
+	km.bindNames(ContextNormal, ActionKillLine, "D")
+	km.bindNames(ContextNormal, ActionDeleteLine, "d d")
+	km.bindNames(ContextNormal, ActionYankLine, "y y")
+	km.bindNames(ContextNormal, ActionPaste, "p")
+	km.bindNames(ContextNormal, ActionPasteBefore, "P")
+	km.bindNames(ContextNormal, ActionUndo, "u")
+	km.bindNames(ContextNormal, ActionRedo, "Ctrl-R")
+
+	bindViMotions(km, ContextVisual)
+	km.bindNames(ContextVisual, ActionNormalMode, "Esc", "v")
+	km.bindNames(ContextVisual, ActionCopy, "y")
+	km.bindNames(ContextVisual, ActionCut, "d", "x")
+
+	return km
+}
+
+// bindViMotions binds the keys moving the cursor in both normal and visual
+// mode.
+func bindViMotions(km *Keymap, context KeyContext) {
+	km.bindNames(context, ActionCursorLeft, "h")
+	km.bindNames(context, ActionCursorDown, "j")
+	km.bindNames(context, ActionCursorUp, "k")
+	km.bindNames(context, ActionCursorRight, "l")
+	km.bindNames(context, ActionNextWord, "w")
+	km.bindNames(context, ActionWordLeft, "b")
+	km.bindNames(context, ActionWordRight, "e")
+	km.bindNames(context, ActionLineStart, "0")
+	km.bindNames(context, ActionLineEnd, "$")
+	km.bindNames(context, ActionDocumentStart, "g g")
+	km.bindNames(context, ActionDocumentEnd, "G")
+	km.bindNames(context, ActionPageUp, "Ctrl-B")
+	km.bindNames(context, ActionPageDown, "Ctrl-F")
+}
@@ -0,0 +1,211 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const presetText = "hello world\nsecond"
+
+// pressKeys shows text in a content box with the cursor at pos, then
+// presses keys given by name (e.g. 'Ctrl-X u' or 'd d') using a keymap.
+func pressKeys(t *testing.T, km *Keymap, text string, pos int, keys string) *ContentBox {
+	t.Helper()
+
+	defaultKeys := Keys
+	Keys = km
+	t.Cleanup(func() { Keys = defaultKeys })
+
+	// the text area needs to be drawn once to lay out its text
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+
+	box := NewContentBox()
+	box.SetText(text, false)
+	box.SetRect(0, 0, 80, 10)
+	box.Draw(screen)
+	box.Select(pos, pos)
+
+	seq, err := ParseKeySequence(keys)
+	require.NoError(t, err)
+
+	for _, key := range seq {
+		box.InputHandler()(tcell.NewEventKey(key.Key, key.Rune, key.Mod), func(p tview.Primitive) {})
+		box.Draw(screen)
+	}
+
+	return box
+}
+
+func TestDefaultPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		pos      int
+		keys     string
+		expected string
+		cursor   int
+	}{
+		{name: "cursor down", text: presetText, pos: 2, keys: "Ctrl-N", expected: presetText, cursor: 14},
+		{name: "cursor up", text: presetText, pos: 14, keys: "Ctrl-P", expected: presetText, cursor: 2},
+		{name: "cursor right", text: presetText, pos: 0, keys: "Right", expected: presetText, cursor: 1},
+		{name: "delete empty line", text: "a\n\nb", pos: 2, keys: "Ctrl-K", expected: "a\nb", cursor: 2},
+		{name: "keep line of text", text: presetText, pos: 12, keys: "Ctrl-K", expected: presetText, cursor: 12},
+		{name: "typing", text: presetText, pos: 0, keys: "x", expected: "x" + presetText, cursor: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := pressKeys(t, DefaultKeymap(), tt.text, tt.pos, tt.keys)
+
+			assert.Equal(t, tt.expected, box.GetText())
+			assert.Equal(t, tt.cursor, box.Cursor())
+			assert.Equal(t, "Content", box.GetTitle())
+		})
+	}
+}
+
+func TestEmacsPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      int
+		keys     string
+		expected string
+		cursor   int
+	}{
+		{name: "end of line", pos: 0, keys: "Ctrl-E", expected: presetText, cursor: 11},
+		{name: "start of line", pos: 5, keys: "Ctrl-A", expected: presetText, cursor: 0},
+		{name: "forward word", pos: 0, keys: "Alt-f", expected: presetText, cursor: 5},
+		{name: "backward word", pos: 8, keys: "Alt-b", expected: presetText, cursor: 6},
+		{name: "backward char", pos: 8, keys: "Ctrl-B", expected: presetText, cursor: 7},
+		{name: "next line", pos: 2, keys: "Ctrl-N", expected: presetText, cursor: 14},
+		{name: "end of note", pos: 0, keys: "Alt->", expected: presetText, cursor: 18},
+		{name: "start of note", pos: 14, keys: "Alt-<", expected: presetText, cursor: 0},
+		{name: "delete char", pos: 0, keys: "Ctrl-D", expected: "ello world\nsecond", cursor: 0},
+		{name: "delete backward", pos: 1, keys: "Ctrl-H", expected: "ello world\nsecond", cursor: 0},
+		{name: "kill line", pos: 5, keys: "Ctrl-K", expected: "hello\nsecond", cursor: 5},
+		{name: "kill newline", pos: 5, keys: "Ctrl-K Ctrl-K", expected: "hellosecond", cursor: 5},
+		{name: "kill and yank", pos: 5, keys: "Ctrl-K Alt-> Ctrl-Y", expected: "hello\nsecond world", cursor: 18},
+		{name: "copy region", pos: 0, keys: "Ctrl-Space Alt-f Alt-w Alt-> Ctrl-Y", expected: presetText + "hello", cursor: 23},
+		{name: "cut region", pos: 6, keys: "Ctrl-Space Ctrl-E Ctrl-W", expected: "hello \nsecond", cursor: 6},
+		{name: "cancel region", pos: 0, keys: "Ctrl-Space Ctrl-F Ctrl-G x", expected: "hxello world\nsecond", cursor: 2},
+		{name: "undo", pos: 0, keys: "Ctrl-D Ctrl-_", expected: presetText, cursor: 0},
+		{name: "undo with prefix", pos: 0, keys: "Ctrl-D Ctrl-X u", expected: presetText, cursor: 0},
+		{name: "unknown prefixed key", pos: 0, keys: "Ctrl-X z", expected: presetText, cursor: 0},
+		{name: "typing", pos: 0, keys: "x", expected: "x" + presetText, cursor: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := pressKeys(t, EmacsKeymap(), presetText, tt.pos, tt.keys)
+
+			assert.Equal(t, tt.expected, box.GetText())
+			assert.Equal(t, tt.cursor, box.Cursor())
+		})
+	}
+}
+
+func TestViPreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		pos      int
+		keys     string
+		expected string
+		cursor   int
+		mode     KeyContext
+	}{
+		// normal mode
+		{name: "typing is ignored", pos: 0, keys: "z", expected: presetText, cursor: 0, mode: ContextNormal},
+		{name: "left", pos: 3, keys: "h", expected: presetText, cursor: 2, mode: ContextNormal},
+		{name: "down", pos: 2, keys: "j", expected: presetText, cursor: 14, mode: ContextNormal},
+		{name: "next word", pos: 0, keys: "w", expected: presetText, cursor: 6, mode: ContextNormal},
+		{name: "next word on next line", pos: 0, keys: "w w", expected: presetText, cursor: 12, mode: ContextNormal},
+		{name: "next punctuation", text: "foo.bar baz", pos: 0, keys: "w w", expected: "foo.bar baz", cursor: 4, mode: ContextNormal},
+		{name: "previous word", pos: 8, keys: "b", expected: presetText, cursor: 6, mode: ContextNormal},
+		{name: "end of line", pos: 0, keys: "$", expected: presetText, cursor: 11, mode: ContextNormal},
+		{name: "start of line", pos: 16, keys: "0", expected: presetText, cursor: 12, mode: ContextNormal},
+		{name: "start of note", pos: 16, keys: "g g", expected: presetText, cursor: 0, mode: ContextNormal},
+		{name: "end of note", pos: 0, keys: "G", expected: presetText, cursor: 18, mode: ContextNormal},
+		{name: "unknown sequence", pos: 0, keys: "d w", expected: presetText, cursor: 0, mode: ContextNormal},
+		{name: "delete char", pos: 0, keys: "x", expected: "ello world\nsecond", cursor: 0, mode: ContextNormal},
+		{name: "delete to end of line", pos: 5, keys: "D", expected: "hello\nsecond", cursor: 5, mode: ContextNormal},
+		{name: "delete line", pos: 3, keys: "d d", expected: "second", cursor: 0, mode: ContextNormal},
+		{name: "delete last line", pos: 14, keys: "d d", expected: "hello world", cursor: 0, mode: ContextNormal},
+		{name: "delete and paste line", pos: 3, keys: "d d p", expected: "second\nhello world", cursor: 7, mode: ContextNormal},
+		{name: "yank and paste line", pos: 3, keys: "y y p", expected: "hello world\nhello world\nsecond", cursor: 12, mode: ContextNormal},
+		{name: "yank and paste line above", pos: 3, keys: "y y P", expected: "hello world\nhello world\nsecond", cursor: 0, mode: ContextNormal},
+		{name: "undo", pos: 0, keys: "x u", expected: presetText, cursor: 0, mode: ContextNormal},
+		{name: "redo", pos: 0, keys: "x u Ctrl-R", expected: "ello world\nsecond", cursor: 0, mode: ContextNormal},
+
+		// insert mode
+		{name: "insert", pos: 0, keys: "i a", expected: "a" + presetText, cursor: 1, mode: ContextContent},
+		{name: "back to normal mode", pos: 0, keys: "i a Esc z", expected: "a" + presetText, cursor: 1, mode: ContextNormal},
+		{name: "append", pos: 0, keys: "a", expected: presetText, cursor: 1, mode: ContextContent},
+		{name: "append to line", pos: 0, keys: "A ! Esc", expected: "hello world!\nsecond", cursor: 12, mode: ContextNormal},
+		{name: "insert at line start", text: "  indented", pos: 6, keys: "I", expected: "  indented", cursor: 2, mode: ContextContent},
+		{name: "open line below", pos: 0, keys: "o n e w Esc", expected: "hello world\nnew\nsecond", cursor: 15, mode: ContextNormal},
+		{name: "open line above", pos: 14, keys: "O", expected: "hello world\n\nsecond", cursor: 12, mode: ContextContent},
+
+		// visual mode
+		{name: "select", pos: 0, keys: "v e", expected: presetText, cursor: 5, mode: ContextVisual},
+		{name: "cancel selection", pos: 0, keys: "v e Esc x", expected: "helloworld\nsecond", cursor: 5, mode: ContextNormal},
+		{name: "copy selection", pos: 0, keys: "v e y $ p", expected: "hello worldhello\nsecond", cursor: 16, mode: ContextNormal},
+		{name: "cut selection", pos: 0, keys: "v w d", expected: "world\nsecond", cursor: 0, mode: ContextNormal},
+		{name: "cut lines", pos: 0, keys: "v j d", expected: "second", cursor: 0, mode: ContextNormal},
+		{name: "select backwards", pos: 8, keys: "v b d", expected: "hello rld\nsecond", cursor: 6, mode: ContextNormal},
+		{name: "select across mark", pos: 8, keys: "v b b l d", expected: "hrld\nsecond", cursor: 1, mode: ContextNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := tt.text
+			if text == "" {
+				text = presetText
+			}
+
+			box := pressKeys(t, ViKeymap(), text, tt.pos, tt.keys)
+
+			assert.Equal(t, tt.expected, box.GetText())
+			assert.Equal(t, tt.cursor, box.Cursor())
+			assert.Equal(t, tt.mode, box.context())
+		})
+	}
+}
+
+func TestViModeKeysComeFirst(t *testing.T) {
+	escape := tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
+	tab := tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone)
+
+	// Esc returns to normal mode rather than to the search box
+	box := pressKeys(t, ViKeymap(), presetText, 0, "i")
+	assert.True(t, box.HandlesKey(escape))
+
+	box = pressKeys(t, ViKeymap(), presetText, 0, "g")
+	assert.True(t, box.HandlesKey(tab), "completes a pending sequence")
+
+	box = pressKeys(t, ViKeymap(), presetText, 0, "Esc")
+	assert.False(t, box.HandlesKey(escape))
+	assert.False(t, box.HandlesKey(tab))
+	assert.Equal(t, "Content (normal)", box.GetTitle())
+
+	box = pressKeys(t, DefaultKeymap(), presetText, 0, "x")
+	assert.False(t, box.HandlesKey(escape))
+}
+
+func TestNewKeymap(t *testing.T) {
+	km, err := NewKeymap("")
+	require.NoError(t, err)
+	assert.False(t, km.IsModal())
+
+	km, err = NewKeymap(PresetVi)
+	require.NoError(t, err)
+	assert.True(t, km.IsModal())
+
+	_, err = NewKeymap("nano")
+	assert.Error(t, err)
+}
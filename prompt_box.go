@@ -0,0 +1,71 @@
+package nve
+
+import (
+	"log"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// PromptBox is a modal input asking for a line of text, such as the folder
+// to move notes to.
+type PromptBox struct {
+	*tview.InputField
+	title      string
+	acceptFunc func(text string) error
+	closedFunc func()
+}
+
+func NewPromptBox() *PromptBox {
+	box := PromptBox{
+		InputField: tview.NewInputField(),
+	}
+
+	box.SetFieldBackgroundColor(Colors.Background)
+
+	box.SetBorder(true).
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.InputField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if err := box.acceptFunc(box.GetText()); err != nil {
+				log.Printf("[ERROR] PromptBox: %v", err)
+				box.SetTitle(box.title + ": " + err.Error())
+				return
+			}
+
+			box.finish()
+		case tcell.KeyEscape:
+			box.finish()
+		}
+	})
+
+	return &box
+}
+
+// SetClosedFunc sets a handler called when the box is closed, whether the
+// text was accepted or not.
+func (b *PromptBox) SetClosedFunc(handler func()) *PromptBox {
+	b.closedFunc = handler
+	return b
+}
+
+// Show prepares the box to ask for text, starting from the given text. The
+// accept function is called with the text entered; the box stays open to
+// show the error it returns, if any.
+func (b *PromptBox) Show(title, text string, accept func(text string) error) {
+	b.title = title
+	b.acceptFunc = accept
+	b.SetTitle(title)
+	b.SetText(text)
+}
+
+func (b *PromptBox) finish() {
+	if b.closedFunc != nil {
+		b.closedFunc()
+	}
+}
@@ -0,0 +1,100 @@
+package nve
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// searchQuery is a search string split into full-text terms and filters.
+//
+// Supported filters:
+//
+//	tag:<name>    only notes carrying the given tag
+//	in:<folder>   only notes within the given folder (or its subfolders)
+//	is:pinned     only pinned notes
+type searchQuery struct {
+	terms   string
+	tags    []string
+	folders []string
+	pinned  bool
+}
+
+func parseQuery(text string) searchQuery {
+	var (
+		q     searchQuery
+		terms []string
+	)
+
+	for _, part := range strings.Fields(text) {
+		switch lower := strings.ToLower(part); {
+		case strings.HasPrefix(lower, "tag:"):
+			// ignore filters still being typed
+			if tag := normalizeTag(part[len("tag:"):]); tag != "" {
+				q.tags = append(q.tags, tag)
+			}
+		case strings.HasPrefix(lower, "in:"):
+			if folder := normalizeFolder(part[len("in:"):]); folder != "" {
+				q.folders = append(q.folders, folder)
+			}
+		case lower == "is:pinned":
+			q.pinned = true
+		default:
+			terms = append(terms, part)
+		}
+	}
+
+	q.terms = strings.Join(terms, " ")
+	return q
+}
+
+// normalizeFolder cleans a folder path, dropping leading and trailing
+// separators. Returns an empty string for the notes directory itself.
+func normalizeFolder(folder string) string {
+	folder = strings.Trim(filepath.ToSlash(filepath.Clean(filepath.FromSlash(folder))), "/")
+
+	if folder == "." {
+		return ""
+	}
+
+	return folder
+}
+
+// hasFilters returns true if the query restricts results beyond its terms.
+func (q searchQuery) hasFilters() bool {
+	return len(q.tags) > 0 || len(q.folders) > 0 || q.pinned
+}
+
+// filterSQL returns SQL conditions (each prefixed by AND) restricting the
+// 'docs' table to this query's filters, along with their arguments. Folders
+// are within root, the notes directory.
+func (q searchQuery) filterSQL(root string) (string, []interface{}) {
+	var (
+		sb   strings.Builder
+		args []interface{}
+	)
+
+	for _, tag := range q.tags {
+		sb.WriteString(" AND docs.id IN (SELECT document_id FROM tags WHERE tag = ?)")
+		args = append(args, tag)
+	}
+
+	for _, folder := range q.folders {
+		// filenames are stored joined with the notes directory
+		pattern := escapeLike(filepath.Join(root, filepath.FromSlash(folder))+string(filepath.Separator)) + "%"
+
+		sb.WriteString(` AND docs.filename LIKE ? ESCAPE '\'`)
+		args = append(args, pattern)
+	}
+
+	if q.pinned {
+		sb.WriteString(" AND docs.pinned = 1")
+	}
+
+	return sb.String(), args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using '\' as the
+// escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
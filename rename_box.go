@@ -0,0 +1,89 @@
+package nve
+
+import (
+	"log"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// RenameBox is a modal input used to rename the current note.
+type RenameBox struct {
+	*tview.InputField
+	notes    *Notes
+	fileRef  *FileRef
+	doneFunc func(f *FileRef)
+}
+
+func NewRenameBox(notes *Notes) *RenameBox {
+	box := RenameBox{
+		InputField: tview.NewInputField(),
+		notes:      notes,
+	}
+
+	box.SetFieldBackgroundColor(Colors.Background)
+
+	box.SetBorder(true).
+		SetTitle("Rename").
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.InputField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			renamed, err := notes.RenameNote(box.fileRef, box.GetText())
+
+			if err != nil {
+				log.Printf("[ERROR] RenameBox: %v", err)
+				box.SetTitle("Rename: " + err.Error())
+				return
+			}
+
+			box.finish(renamed)
+		case tcell.KeyEscape:
+			box.finish(nil)
+		}
+	})
+
+	return &box
+}
+
+// SetDoneFunc sets a handler called when renaming is finished. The handler
+// receives the renamed file, or nil if renaming was cancelled.
+func (b *RenameBox) SetDoneFunc(handler func(f *FileRef)) *RenameBox {
+	b.doneFunc = handler
+	return b
+}
+
+// Show prepares the box to rename the given file.
+func (b *RenameBox) Show(f *FileRef) {
+	b.fileRef = f
+	b.SetTitle("Rename")
+	b.SetText(f.DisplayName())
+}
+
+func (b *RenameBox) finish(f *FileRef) {
+	if b.doneFunc != nil {
+		b.doneFunc(f)
+	}
+}
+
+// Modal centers a primitive of the given size over the rest of the UI,
+// which clicks around it do not reach.
+func Modal(p tview.Primitive, width, height int) tview.Primitive {
+	return &modal{tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)}
+}
+
+// Overlay shows a primitive laying itself out over the rest of the UI, such
+// as a tview.Modal, which clicks around it do not reach.
+func Overlay(p tview.Primitive) tview.Primitive {
+	return &modal{tview.NewFlex().AddItem(p, 0, 1, true)}
+}
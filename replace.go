@@ -0,0 +1,249 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Hunk is a line of a note (or a few, if a match spans lines) changed by
+// replacing text in all notes. Only selected hunks are applied.
+type Hunk struct {
+	Line     int
+	Before   string
+	After    string
+	Selected bool
+
+	// start and end are the offsets of the lines within the note
+	start, end int
+}
+
+// NoteChange is a note changed by replacing text in all notes, along with
+// the content its hunks were found in.
+type NoteChange struct {
+	Ref     *FileRef
+	Hunks   []*Hunk
+	content string
+}
+
+// Selected returns the number of hunks to be applied.
+func (c *NoteChange) Selected() int {
+	var count int
+
+	for _, hunk := range c.Hunks {
+		if hunk.Selected {
+			count++
+		}
+	}
+
+	return count
+}
+
+// PreviewReplace finds the text of notes that would change by replacing
+// query in all of them, as indexed in content_index. Front matter is not
+// indexed, so it is left as is. All hunks start selected.
+func (n *Notes) PreviewReplace(query, replacement string, options FindOptions) ([]*NoteChange, error) {
+	if _, err := findPattern(query, options); err != nil {
+		return nil, err
+	}
+
+	texts, err := n.db.IndexedTexts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*NoteChange
+
+	for _, indexed := range texts {
+		matches, _ := FindMatches(indexed.Text, query, options)
+
+		if len(matches) == 0 {
+			continue
+		}
+
+		// offsets within the body are shifted past any front matter
+		content := GetContent(indexed.Filename)
+
+		if !strings.HasSuffix(content, indexed.Text) {
+			return nil, errors.Errorf("note changed since it was indexed: %s", n.RelativeName(&indexed.FileRef))
+		}
+
+		offset := len(content) - len(indexed.Text)
+
+		for _, match := range matches {
+			for i := range match {
+				if match[i] >= 0 {
+					match[i] += offset
+				}
+			}
+		}
+
+		hunks, err := findHunks(content, query, replacement, matches, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ref := indexed.FileRef
+		changes = append(changes, &NoteChange{Ref: &ref, Hunks: hunks, content: content})
+	}
+
+	return changes, nil
+}
+
+// findHunks groups matches by the lines they are on, along with what the
+// lines become once the matches are replaced.
+func findHunks(content, query, replacement string, matches [][]int, options FindOptions) ([]*Hunk, error) {
+	var (
+		hunks   []*Hunk
+		grouped [][][]int
+	)
+
+	for _, match := range matches {
+		start, end := lineStart(content, match[0]), lineEnd(content, match[1])
+
+		if last := len(hunks) - 1; last >= 0 && start <= hunks[last].end {
+			hunks[last].end = end
+			grouped[last] = append(grouped[last], match)
+			continue
+		}
+
+		hunks = append(hunks, &Hunk{
+			Line:     strings.Count(content[:start], "\n") + 1,
+			Selected: true,
+			start:    start,
+			end:      end,
+		})
+		grouped = append(grouped, [][]int{match})
+	}
+
+	for i, hunk := range hunks {
+		// the text before the hunk is unchanged by replacing within it
+		replaced, err := ReplaceMatches(content[:hunk.end], query, replacement, grouped[i], options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hunk.Before = content[hunk.start:hunk.end]
+		hunk.After = replaced[hunk.start:]
+	}
+
+	return hunks, nil
+}
+
+// ApplyReplace applies the selected hunks of changes, then reindexes the
+// notes changed. The new content of every note is written next to it before
+// any note is replaced, so that none are if any note changed since the
+// preview or cannot be written. Returns the number of notes changed.
+func (n *Notes) ApplyReplace(changes []*NoteChange) (int, error) {
+	var (
+		paths []string
+		temps []string
+	)
+
+	// removeTemps removes the new content of notes not yet moved in place
+	removeTemps := func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}
+
+	for _, change := range changes {
+		if change.Selected() == 0 {
+			continue
+		}
+
+		if GetContent(change.Ref.Filename) != change.content {
+			removeTemps()
+			return 0, errors.Errorf("note changed since the preview: %s", n.RelativeName(change.Ref))
+		}
+
+		temp, err := writeTemp(change.Ref.Filename, change.apply())
+
+		if err != nil {
+			removeTemps()
+			return 0, err
+		}
+
+		paths = append(paths, change.Ref.Filename)
+		temps = append(temps, temp)
+	}
+
+	for i, path := range paths {
+		if err := os.Rename(temps[i], path); err != nil {
+			removeTemps()
+			return i, errors.WithStack(err)
+		}
+
+		temps[i] = ""
+	}
+
+	for _, path := range paths {
+		if err := n.indexFile(path); err != nil {
+			return len(paths), err
+		}
+	}
+
+	_, err := n.Search(n.LastQuery)
+	return len(paths), err
+}
+
+// apply returns the content of the note with the selected hunks applied.
+func (c *NoteChange) apply() string {
+	var (
+		sb   strings.Builder
+		last int
+	)
+
+	for _, hunk := range c.Hunks {
+		if !hunk.Selected {
+			continue
+		}
+
+		sb.WriteString(c.content[last:hunk.start])
+		sb.WriteString(hunk.After)
+		last = hunk.end
+	}
+
+	sb.WriteString(c.content[last:])
+
+	return sb.String()
+}
+
+// writeTemp writes content to a hidden file next to path, to be renamed over
+// it, keeping the mode of path. Returns the name of the file.
+func writeTemp(path, content string) (string, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", errors.WithStack(err)
+	}
+
+	if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+		os.Remove(f.Name())
+		return "", errors.WithStack(err)
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,360 @@
+package nve
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	// ReplaceBoxWidth and ReplaceBoxHeight are the size of the box replacing
+	// text in all notes, whose changes scroll if they do not fit.
+	ReplaceBoxWidth  = 90
+	ReplaceBoxHeight = 24
+)
+
+// replaceItem is what an item of the list of changes stands for: a note,
+// or one of its hunks if hunk is not negative.
+type replaceItem struct {
+	change int
+	hunk   int
+}
+
+// ReplaceBox is a modal used to replace text in all notes. It previews the
+// lines changed in each note, any of which may be deselected before the
+// changes are applied.
+type ReplaceBox struct {
+	*tview.Flex
+	find     *tview.InputField
+	replace  *tview.InputField
+	status   *tview.TextView
+	list     *tview.List
+	notes    *Notes
+	options  FindOptions
+	changes  []*NoteChange
+	items    []replaceItem
+	doneFunc func(refs []*FileRef)
+}
+
+func NewReplaceBox(notes *Notes) *ReplaceBox {
+	box := ReplaceBox{
+		Flex:    tview.NewFlex(),
+		find:    tview.NewInputField(),
+		replace: tview.NewInputField(),
+		status:  tview.NewTextView(),
+		list:    tview.NewList(),
+		notes:   notes,
+	}
+
+	for _, field := range []*tview.InputField{box.find, box.replace} {
+		field.SetFieldBackgroundColor(Colors.Background).
+			SetLabelColor(Colors.Accent).
+			SetBackgroundColor(Colors.Background)
+	}
+
+	box.find.SetLabel("Find:    ")
+	box.replace.SetLabel("Replace: ")
+
+	box.status.SetDynamicColors(true).
+		SetBackgroundColor(Colors.Background)
+
+	box.list.SetWrapAround(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(Colors.SelectedStyle()).
+		SetBackgroundColor(Colors.Background)
+
+	box.SetDirection(tview.FlexRow).
+		AddItem(box.find, 1, 0, true).
+		AddItem(box.replace, 1, 0, false).
+		AddItem(box.status, 2, 0, false).
+		AddItem(box.list, 0, 1, false)
+
+	box.SetBorder(true).
+		SetTitle("Replace in all notes").
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	return &box
+}
+
+// SetDoneFunc sets a handler called when the box is closed. The handler
+// receives the notes changed, or nil if nothing was replaced.
+func (b *ReplaceBox) SetDoneFunc(handler func(refs []*FileRef)) *ReplaceBox {
+	b.doneFunc = handler
+	return b
+}
+
+// Show prepares the box to find the given text, which may be empty.
+func (b *ReplaceBox) Show(query string) {
+	b.find.SetText(query)
+	b.replace.SetText("")
+	b.changes = nil
+	b.update()
+	b.focus(b.find)
+}
+
+// preview finds the changes replacing the text, and lists them.
+func (b *ReplaceBox) preview() {
+	b.changes = nil
+
+	if b.find.GetText() == "" {
+		b.update()
+		return
+	}
+
+	changes, err := b.notes.PreviewReplace(b.find.GetText(), b.replace.GetText(), b.options)
+
+	if err != nil {
+		log.Printf("[ERROR] ReplaceBox: %v", err)
+		b.update()
+		b.setStatus(colorTag(Colors.Error, "") + tview.Escape(err.Error()) + "[-]")
+		return
+	}
+
+	b.changes = changes
+	b.update()
+}
+
+// apply applies the selected changes, and closes the box unless they could
+// not be applied.
+func (b *ReplaceBox) apply() {
+	var refs []*FileRef
+
+	for _, change := range b.changes {
+		if change.Selected() > 0 {
+			refs = append(refs, change.Ref)
+		}
+	}
+
+	if len(refs) == 0 {
+		return
+	}
+
+	if _, err := b.notes.ApplyReplace(b.changes); err != nil {
+		log.Printf("[ERROR] ReplaceBox: %v", err)
+		b.setStatus(colorTag(Colors.Error, "") + tview.Escape(err.Error()) + "[-]")
+		return
+	}
+
+	b.finish(refs)
+}
+
+// toggle selects or deselects the current item. Toggling a note toggles
+// all of its hunks.
+func (b *ReplaceBox) toggle() {
+	index := b.list.GetCurrentItem()
+
+	if index >= len(b.items) {
+		return
+	}
+
+	item := b.items[index]
+	change := b.changes[item.change]
+
+	if item.hunk >= 0 {
+		change.Hunks[item.hunk].Selected = !change.Hunks[item.hunk].Selected
+	} else {
+		selected := change.Selected() < len(change.Hunks)
+
+		for _, hunk := range change.Hunks {
+			hunk.Selected = selected
+		}
+	}
+
+	b.update()
+	b.list.SetCurrentItem(index)
+}
+
+// update lists the changes, and counts those selected.
+func (b *ReplaceBox) update() {
+	var selected, total, notes int
+
+	b.items = nil
+	b.list.Clear()
+
+	for i, change := range b.changes {
+		b.items = append(b.items, replaceItem{change: i, hunk: -1})
+		b.list.AddItem(formatNoteChange(b.notes.RelativeName(change.Ref), change), "", 0, nil)
+
+		for j, hunk := range change.Hunks {
+			before, after := formatHunk(hunk)
+			b.items = append(b.items, replaceItem{change: i, hunk: j})
+			b.list.AddItem(before, after, 0, nil)
+		}
+
+		if count := change.Selected(); count > 0 {
+			selected += count
+			notes++
+		}
+
+		total += len(change.Hunks)
+	}
+
+	switch {
+	case b.find.GetText() == "":
+		b.setStatus("")
+	case total == 0:
+		b.setStatus("no matches")
+	default:
+		b.setStatus(fmt.Sprintf("%d of %s in %s", selected, plural(total, "line"), plural(notes, "note")))
+	}
+}
+
+// setStatus shows a status after the options, which are highlighted when
+// turned on, along with the keys of the box.
+func (b *ReplaceBox) setStatus(status string) {
+	option := func(label string, on bool) string {
+		if on {
+			return colorTag(Colors.Accent, "b") + label + "[-::-]"
+		}
+		return colorTag(Colors.Muted, "") + label + "[-]"
+	}
+
+	hint := "Enter to preview, Tab to switch"
+	if b.list.HasFocus() {
+		hint = "Space to select, Enter to replace"
+	}
+
+	b.status.SetText(option(".*", b.options.Regex) + " " + option("Aa", b.options.CaseSensitive) + " " + status +
+		"\n" + colorTag(Colors.Muted, "") + hint + "[-]")
+}
+
+// formatNoteChange shows the name of a note changed, and whether all, some
+// or none of its hunks are selected.
+func formatNoteChange(name string, change *NoteChange) string {
+	check := "☑"
+
+	switch change.Selected() {
+	case 0:
+		check = "☐"
+	case len(change.Hunks):
+	default:
+		check = "◪"
+	}
+
+	return check + " " + colorTag(Colors.Title, "b") + tview.Escape(name) + "[-::-]"
+}
+
+// formatHunk shows the lines of a hunk before and after replacing, the
+// latter aligned below the former.
+func formatHunk(hunk *Hunk) (string, string) {
+	check := "☑"
+	if !hunk.Selected {
+		check = "☐"
+	}
+
+	oneLine := func(text string) string {
+		return tview.Escape(strings.ReplaceAll(text, "\n", "↵"))
+	}
+
+	line := fmt.Sprintf("%4d", hunk.Line)
+
+	return fmt.Sprintf("  %s %s %s- %s[-]", check, line, colorTag(Colors.Error, ""), oneLine(hunk.Before)),
+		fmt.Sprintf("    %s %s+ %s[-]", strings.Repeat(" ", len(line)), colorTag(Colors.Accent, ""), oneLine(hunk.After))
+}
+
+// focus moves the cursor to a field, or the list of changes.
+func (b *ReplaceBox) focus(p tview.Primitive) {
+	for _, item := range []tview.Primitive{b.find, b.replace, b.list} {
+		item.Blur()
+	}
+
+	p.Focus(nil)
+	b.update()
+}
+
+// focusNext moves the cursor to the next (or previous, if offset is -1)
+// of the fields and the list.
+func (b *ReplaceBox) focusNext(offset int) {
+	items := []tview.Primitive{b.find, b.replace, b.list}
+
+	for i, item := range items {
+		if item.HasFocus() {
+			b.focus(items[(i+offset+len(items))%len(items)])
+			return
+		}
+	}
+}
+
+// Draw clears the box before drawing its parts, as a flex leaves whatever is
+// below its padding.
+func (b *ReplaceBox) Draw(screen tcell.Screen) {
+	x, y, width, height := b.GetRect()
+	style := tcell.StyleDefault.Background(Colors.Background)
+
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			screen.SetContent(col, row, ' ', nil, style)
+		}
+	}
+
+	b.Flex.Draw(screen)
+}
+
+// InputHandler types into the fields, previewing the changes on Enter, and
+// selects the changes to apply in the list.
+func (b *ReplaceBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return b.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		action, _ := Keys.Lookup(ContextFind, event)
+
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			b.finish(nil)
+		case event.Key() == tcell.KeyTab:
+			b.focusNext(1)
+		case event.Key() == tcell.KeyBacktab:
+			b.focusNext(-1)
+		case action == ActionToggleRegex:
+			b.options.Regex = !b.options.Regex
+			b.preview()
+		case action == ActionToggleCase:
+			b.options.CaseSensitive = !b.options.CaseSensitive
+			b.preview()
+		case b.list.HasFocus() && event.Key() == tcell.KeyEnter:
+			b.apply()
+		case b.list.HasFocus() && event.Key() == tcell.KeyRune && event.Rune() == ' ':
+			b.toggle()
+		case b.list.HasFocus():
+			if handler := b.list.InputHandler(); handler != nil {
+				handler(event, setFocus)
+			}
+		case event.Key() == tcell.KeyEnter:
+			b.preview()
+			if len(b.changes) > 0 {
+				b.focus(b.list)
+			}
+		default:
+			field := b.find
+			if b.replace.HasFocus() {
+				field = b.replace
+			}
+
+			if handler := field.InputHandler(); handler != nil {
+				handler(event, setFocus)
+			}
+		}
+	})
+}
+
+// MouseHandler moves the cursor to the field or the list clicked, and
+// scrolls the list with the wheel.
+func (b *ReplaceBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return keepFocus(b, b.Flex.MouseHandler(), func(target tview.Primitive) {
+		for _, item := range []tview.Primitive{b.find, b.replace, b.list} {
+			if item == target && !item.HasFocus() {
+				b.focus(item)
+			}
+		}
+	})
+}
+
+func (b *ReplaceBox) finish(refs []*FileRef) {
+	if b.doneFunc != nil {
+		b.doneFunc(refs)
+	}
+}
@@ -0,0 +1,118 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewReplace(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	write("plan.md", "---\ntitle: Apollo\n---\nApollo starts.\nNothing here.\napollo and Apollo.\n")
+	write("work/todo.md", "Ask about Apollo")
+	write("other.md", "Nothing to replace")
+
+	_, err := n.Refresh()
+	require.NoError(t, err)
+
+	type hunk struct {
+		Line   int
+		Before string
+		After  string
+	}
+
+	hunks := func(change *NoteChange) []hunk {
+		var res []hunk
+		for _, h := range change.Hunks {
+			res = append(res, hunk{h.Line, h.Before, h.After})
+		}
+		return res
+	}
+
+	t.Run("previews changed lines of notes", func(t *testing.T) {
+		changes, err := n.PreviewReplace("apollo", "Artemis", FindOptions{})
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		assert.Equal(t, "plan", n.RelativeName(changes[0].Ref))
+		assert.Equal(t, []hunk{
+			{Line: 4, Before: "Apollo starts.", After: "Artemis starts."},
+			{Line: 6, Before: "apollo and Apollo.", After: "Artemis and Artemis."},
+		}, hunks(changes[0]), "front matter is left as is")
+
+		assert.Equal(t, "work/todo", n.RelativeName(changes[1].Ref))
+		assert.Equal(t, []hunk{{Line: 1, Before: "Ask about Apollo", After: "Ask about Artemis"}}, hunks(changes[1]))
+	})
+
+	t.Run("expands groups of regular expressions", func(t *testing.T) {
+		changes, err := n.PreviewReplace(`(\w+) (starts)`, "$2 $1", FindOptions{Regex: true})
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, []hunk{{Line: 4, Before: "Apollo starts.", After: "starts Apollo."}}, hunks(changes[0]))
+	})
+
+	t.Run("groups matches spanning lines", func(t *testing.T) {
+		changes, err := n.PreviewReplace(`starts\.\nNothing`, "ends.", FindOptions{Regex: true})
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, []hunk{{Line: 4, Before: "Apollo starts.\nNothing here.", After: "Apollo ends. here."}}, hunks(changes[0]))
+	})
+
+	t.Run("rejects invalid regular expressions", func(t *testing.T) {
+		_, err := n.PreviewReplace("(", "", FindOptions{Regex: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("applies selected hunks and reindexes", func(t *testing.T) {
+		changes, err := n.PreviewReplace("Apollo", "Artemis", FindOptions{CaseSensitive: true})
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		changes[0].Hunks[0].Selected = false
+		require.NoError(t, os.Chmod(filepath.Join(dir, "work/todo.md"), 0600))
+
+		count, err := n.ApplyReplace(changes)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		for name, mode := range map[string]os.FileMode{"plan.md": 0644, "work/todo.md": 0600} {
+			info, err := os.Stat(filepath.Join(dir, name))
+			require.NoError(t, err)
+			assert.Equal(t, mode, info.Mode().Perm(), "keeps the mode of %s", name)
+		}
+
+		assert.Equal(t, "---\ntitle: Apollo\n---\nApollo starts.\nNothing here.\napollo and Artemis.\n", GetContent(filepath.Join(dir, "plan.md")))
+		assert.Equal(t, "Ask about Artemis", GetContent(filepath.Join(dir, "work/todo.md")))
+
+		results, err := n.Search("artemis")
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.NotContains(t, entry.Name(), ".tmp", "temporary files are removed")
+		}
+	})
+
+	t.Run("changes nothing if a note changed since the preview", func(t *testing.T) {
+		changes, err := n.PreviewReplace("Artemis", "Apollo", FindOptions{})
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		write("work/todo.md", "Ask about Artemis today")
+
+		_, err = n.ApplyReplace(changes)
+		assert.EqualError(t, err, "note changed since the preview: work/todo")
+		assert.Equal(t, "---\ntitle: Apollo\n---\nApollo starts.\nNothing here.\napollo and Artemis.\n", GetContent(filepath.Join(dir, "plan.md")))
+	})
+}
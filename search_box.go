@@ -2,8 +2,10 @@ package nve
 
 import (
 	"log"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 )
 
@@ -13,6 +15,8 @@ type SearchBox struct {
 	contentView      *ContentBox
 	notes            *Notes
 	updatingFromList bool
+	completer        *CompletionBox
+	completing       bool
 }
 
 // SetTextFromList updates the search box text from list selection without triggering search
@@ -29,40 +33,165 @@ func NewSearchBox(listView *ListBox, contentView *ContentBox, notes *Notes) *Sea
 		listView:    listView,
 		contentView: contentView,
 		notes:       notes,
+		completer:   NewCompletionBox(),
 	}
 
 	listView.searchView = &res
 
 	// input field attributes
-	res.SetFieldBackgroundColor(tcell.ColorBlack).
-		SetPlaceholderStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+	res.SetFieldBackgroundColor(Colors.Background).
+		SetPlaceholderStyle(tcell.StyleDefault.Background(Colors.Background).Foreground(Colors.Muted))
 
 	// other attributes
 	res.SetBorder(true).
 		SetTitle("Search Box").
-		SetBackgroundColor(tcell.ColorBlack).
-		SetTitleColor(tcell.ColorYellow).
-		SetBorderStyle(tcell.StyleDefault.Dim(true)).
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderStyle(Colors.BorderStyle()).
 		SetBorderPadding(0, 0, 1, 1).
 		SetTitleAlign(tview.AlignLeft)
 
+	res.SetBlurFunc(func() {
+		res.completing = false
+	})
+
 	res.SetDoneFunc(func(key tcell.Key) {
 		if key != tcell.KeyEnter || len(notes.LastSearchResults) > 0 {
 			return
 		}
 
-		newNote, err := notes.CreateNote(res.GetText())
-		if err != nil {
-			log.Println("Error creating new note:", err)
-			return
+		// a 'template: title' prefix picks the template for the new note
+		template, title := notes.SplitTemplatePrefix(res.GetText())
+
+		if template == "" {
+			template = DefaultTemplate
 		}
 
-		notes.Search(newNote.DisplayName())
+		res.CreateNote(template, title)
 	})
 
 	return &res
 }
 
+// CreateNote creates a new note from a template, then displays it with the
+// cursor placed where the template asks for it.
+func (sb *SearchBox) CreateNote(template, title string) {
+	// filtered searches never create notes
+	if parseQuery(title).hasFilters() {
+		return
+	}
+
+	// new notes are created within the folder searches are scoped to
+	if scope := sb.notes.Scope(); scope != "" {
+		title = scope + "/" + title
+	}
+
+	newNote, cursor, err := sb.notes.CreateNoteFromTemplate(title, template)
+	if err != nil {
+		log.Println("Error creating new note:", err)
+		return
+	}
+
+	name := newNote.DisplayName()
+
+	if sb.notes.Scope() == "" {
+		name = sb.notes.RelativeName(newNote)
+	}
+
+	sb.SetTextFromList(name)
+	sb.notes.Search(name)
+	sb.contentView.SetFile(newNote)
+	sb.contentView.Select(cursor, cursor)
+}
+
+// IsCompleting returns true while the tag completion popup is shown.
+func (sb *SearchBox) IsCompleting() bool {
+	return sb.completing
+}
+
+// completionStart returns the offset of the last word of text, if that word
+// is a 'tag:' filter being typed.
+func completionStart(text string) (int, bool) {
+	start := strings.LastIndex(text, " ") + 1
+
+	return start, strings.HasPrefix(strings.ToLower(text[start:]), "tag:")
+}
+
+// updateCompletion shows or hides tag completions for the last word typed.
+func (sb *SearchBox) updateCompletion() {
+	sb.completing = false
+
+	text := sb.GetText()
+	start, ok := completionStart(text)
+
+	if !ok {
+		return
+	}
+
+	names, err := sb.notes.TagNames(text[start+len("tag:"):])
+
+	if err != nil {
+		log.Printf("[ERROR] SearchBox: %v", err)
+		return
+	}
+
+	items := make([]string, 0, len(names))
+
+	for _, name := range names {
+		items = append(items, "tag:"+name)
+	}
+
+	if len(items) > 0 {
+		sb.completer.SetItems(items)
+		sb.completing = true
+	}
+}
+
+// handleCompletionKey handles navigation within the completion popup,
+// returning false if the event should be processed by the input field.
+func (sb *SearchBox) handleCompletionKey(event *tcell.EventKey, setFocus func(p tview.Primitive)) bool {
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyCtrlP:
+		if handler := sb.completer.InputHandler(); handler != nil {
+			handler(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), setFocus)
+		}
+	case tcell.KeyDown, tcell.KeyCtrlN:
+		if handler := sb.completer.InputHandler(); handler != nil {
+			handler(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), setFocus)
+		}
+	case tcell.KeyEnter, tcell.KeyTab:
+		sb.completing = false
+
+		if item, ok := sb.completer.Selected(); ok {
+			text := sb.GetText()
+			start, _ := completionStart(text)
+			sb.SetText(text[:start] + item + " ")
+			sb.notes.Search(sb.GetText())
+		}
+	case tcell.KeyEscape:
+		sb.completing = false
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Draw renders the input field and, if shown, the tag completion popup.
+func (sb *SearchBox) Draw(screen tcell.Screen) {
+	sb.InputField.Draw(screen)
+
+	if !sb.completing {
+		return
+	}
+
+	x, y, _, _ := sb.GetInnerRect()
+	width, height := screen.Size()
+	start, _ := completionStart(sb.GetText())
+
+	sb.completer.DrawAt(screen, x+runewidth.StringWidth(sb.GetText()[:start]), y, 0, 0, width, height)
+}
+
 // syncWithListSelection updates SearchBox text and ContentView with current selection
 func (sb *SearchBox) syncWithListSelection(keyAction string) {
 	currentItem := sb.listView.GetCurrentItem()
@@ -71,7 +200,7 @@ func (sb *SearchBox) syncWithListSelection(keyAction string) {
 		log.Printf("[DEBUG] SearchBox: %s, updating text to '%s'", keyAction, filename)
 		sb.SetTextFromList(filename)
 		result := sb.notes.LastSearchResults[currentItem]
-		sb.contentView.SetFile(result.FileRef)
+		sb.contentView.SetResult(result)
 	}
 }
 
@@ -118,25 +247,31 @@ func (sb *SearchBox) handleArrowKey(event *tcell.EventKey, setFocus func(p tview
 // InputHandler overrides default handling to switch focus away from search box when necessary.
 func (sb *SearchBox) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return sb.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if sb.completing && sb.handleCompletionKey(event, setFocus) {
+			return
+		}
+
 		// Handle special keys first
-		switch event.Key() {
-		case tcell.KeyEnter:
+		action, _ := Keys.Lookup(ContextSearch, event)
+
+		switch action {
+		case ActionOpenOrCreate:
 			if sb.GetText() == "" {
 				return
 			}
 			if len(sb.notes.LastSearchResults) == 0 {
 				// No matches — create a new note, then focus ContentBox
 				if handler := sb.InputField.InputHandler(); handler != nil {
-					handler(event, setFocus)
+					handler(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), setFocus)
 				}
 			}
 			setFocus(sb.contentView)
 			return
-		case tcell.KeyDown, tcell.KeyCtrlN:
-			sb.handleArrowKey(event, setFocus, true)
+		case ActionSelectNext:
+			sb.handleArrowKey(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), setFocus, true)
 			return
-		case tcell.KeyUp, tcell.KeyCtrlP:
-			sb.handleArrowKey(event, setFocus, false)
+		case ActionSelectPrevious:
+			sb.handleArrowKey(tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), setFocus, false)
 			return
 		}
 
@@ -151,8 +286,21 @@ func (sb *SearchBox) InputHandler() func(event *tcell.EventKey, setFocus func(p
 		if before != after && !sb.updatingFromList {
 			log.Printf("[DEBUG] SearchBox: Text changed from '%s' to '%s', triggering search", before, after)
 			sb.notes.Search(after)
+			sb.updateCompletion()
 		} else if before != after && sb.updatingFromList {
 			log.Printf("[DEBUG] SearchBox: Text changed from '%s' to '%s' (from list update, skipping search)", before, after)
 		}
 	})
 }
+
+// MouseHandler places the cursor in the search text, keeping the keys of
+// the search box.
+func (sb *SearchBox) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return sb.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		if action == tview.MouseLeftDown && sb.InRect(event.Position()) {
+			sb.completing = false
+		}
+
+		return keepFocus(sb, sb.InputField.MouseHandler(), nil)(action, event, setFocus)
+	})
+}
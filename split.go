@@ -0,0 +1,241 @@
+package nve
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// section is text of a note to be split off into a new note.
+type section struct {
+	name       string
+	start, end int
+}
+
+// SplitNote moves the text of a note after offset (in its content, front
+// matter included) into a new note in the same folder, leaving a [[link]]
+// to it in its place. The new note is named by the heading the text starts
+// with, or else after the note. Returns the new note. The split can be
+// undone.
+func (n *Notes) SplitNote(ref *FileRef, offset int) (*FileRef, error) {
+	var (
+		content = GetContent(ref.Filename)
+		_, body = parseFrontMatter(content)
+		start   = len(content) - len(body)
+	)
+
+	if offset < start {
+		offset = start
+	}
+
+	if offset > len(content) {
+		offset = len(content)
+	}
+
+	rest := strings.TrimSpace(content[offset:])
+
+	if rest == "" {
+		return nil, errors.New("no text after the cursor")
+	}
+
+	name := ref.DisplayName()
+
+	if m := headingPattern.FindStringSubmatch(strings.SplitN(rest, "\n", 2)[0]); m != nil && m[2] != "" {
+		name = m[2]
+	}
+
+	created, err := n.split(ref, content, []section{{name: name, start: offset, end: len(content)}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created[0], nil
+}
+
+// SplitNoteAtHeadings moves each section of a note into a new note named by
+// its heading, leaving a [[link]] to each in its place. Sections start at
+// the largest headings of the note, so that smaller headings stay within
+// them. Returns the new notes. The split can be undone.
+func (n *Notes) SplitNoteAtHeadings(ref *FileRef) ([]*FileRef, error) {
+	var (
+		content  = GetContent(ref.Filename)
+		_, body  = parseFrontMatter(content)
+		headings = findHeadings(body, len(content)-len(body))
+		sections []section
+		level    = 7
+	)
+
+	for _, h := range headings {
+		if h.level < level {
+			level = h.level
+		}
+	}
+
+	for _, h := range headings {
+		if h.level != level {
+			continue
+		}
+
+		if len(sections) > 0 {
+			sections[len(sections)-1].end = h.start
+		}
+
+		sections = append(sections, section{name: h.text, start: h.start, end: len(content)})
+	}
+
+	if len(sections) == 0 {
+		return nil, errors.New("no headings to split at")
+	}
+
+	return n.split(ref, content, sections)
+}
+
+// heading is a Markdown heading of a note, at an offset in its content.
+type heading struct {
+	level int
+	text  string
+	start int
+}
+
+// findHeadings returns the headings of text, leaving out lines of fenced
+// code blocks. Offsets are given from base.
+func findHeadings(text string, base int) []heading {
+	var (
+		headings []heading
+		fence    string
+		offset   = base
+	)
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimRight(line, "\n")
+
+		switch m := fencePattern.FindStringSubmatch(trimmed); {
+		case m != nil && fence == "":
+			fence = m[1]
+		case fence != "":
+			if strings.HasPrefix(strings.TrimSpace(trimmed), fence) {
+				fence = ""
+			}
+		default:
+			if m := headingPattern.FindStringSubmatch(trimmed); m != nil && m[2] != "" {
+				headings = append(headings, heading{level: len(m[1]), text: m[2], start: offset})
+			}
+		}
+
+		offset += len(line)
+	}
+
+	return headings
+}
+
+// headingName replaces the characters of a heading which cannot be part of
+// the name of a note linked to.
+var headingName = strings.NewReplacer("/", "-", "[", "(", "]", ")")
+
+// split creates a note in the folder of ref for each section of its
+// content, replacing the section by a [[link]] to the new note, then
+// refreshes the last search. Returns the new notes, in the order of their
+// sections.
+func (n *Notes) split(ref *FileRef, content string, sections []section) ([]*FileRef, error) {
+	var (
+		folder  = n.Folder(ref)
+		created = make([]*FileRef, len(sections))
+		c       = n.newChange("split")
+	)
+
+	c.record(ref.Filename)
+
+	// sections are split off from the last one, so that the offsets of the
+	// others stay the same
+	for i := len(sections) - 1; i >= 0; i-- {
+		s := sections[i]
+
+		// a slash in a heading is not a folder, and brackets would end the
+		// link to the note
+		name, filename, err := n.freeNoteName(path.Join(folder, headingName.Replace(s.name)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.created(filename)
+
+		note, err := n.CreateNote(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the new note keeps any front matter it was created with
+		text := GetContent(note.Filename)
+		_, body, _ := splitFrontMatter(text)
+		text = text[:len(text)-len(body)] + strings.TrimSpace(content[s.start:s.end]) + "\n"
+
+		if err := SaveContent(note.Filename, text); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := n.indexFile(note.Filename); err != nil {
+			return nil, err
+		}
+
+		link := "[[" + note.DisplayName() + "]]\n"
+
+		if s.start > 0 && content[s.start-1] != '\n' {
+			link = "\n" + link
+		}
+
+		if s.end < len(content) {
+			link += "\n"
+		}
+
+		content = content[:s.start] + link + content[s.end:]
+		created[i] = note
+	}
+
+	if err := SaveContent(ref.Filename, content); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := n.indexFile(ref.Filename); err != nil {
+		return nil, err
+	}
+
+	if _, err := n.Search(n.LastQuery); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// freeNoteName returns name, or else the first name numbered like 'name 2'
+// which is not the name of a note, along with the file of the note.
+func (n *Notes) freeNoteName(name string) (string, string, error) {
+	for i := 1; ; i++ {
+		numbered := name
+
+		if i > 1 {
+			numbered = fmt.Sprintf("%s %d", name, i)
+		}
+
+		filename, title, err := n.notePath(numbered)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		// as CreateNote does, sanitized names may be numbered
+		if strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)) != title {
+			filename = uniquePath(filename, title)
+		}
+
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return numbered, filename, nil
+		}
+	}
+}
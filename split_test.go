@@ -0,0 +1,132 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindHeadings(t *testing.T) {
+	text := "intro\n# One\ntext\n```\n# not a heading\n```\n## Two ##\n#tag\n"
+
+	assert.Equal(t, []heading{
+		{level: 1, text: "One", start: 16},
+		{level: 2, text: "Two", start: 51},
+	}, findHeadings(text, 10))
+}
+
+func TestSplitNote(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	write := func(name, text string) *FileRef {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(text), 0644))
+		_, err := n.Refresh()
+		require.NoError(t, err)
+
+		ref, err := n.db.FindByName(strings.TrimSuffix(filepath.Base(name), ".md"))
+		require.NoError(t, err)
+		return ref
+	}
+
+	read := func(name string) string {
+		return GetContent(filepath.Join(dir, name))
+	}
+
+	t.Run("at the cursor", func(t *testing.T) {
+		text := "---\ntitle: Trip\n---\nPacking list.\n\nThe itinerary follows.\n"
+		ref := write("work/trip.md", text)
+
+		created, err := n.SplitNote(ref, strings.Index(text, "The itinerary"))
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(dir, "work", "trip 2.md"), created.Filename)
+		assert.Equal(t, "The itinerary follows.\n", read("work/trip 2.md"))
+		assert.Equal(t, "---\ntitle: Trip\n---\nPacking list.\n\n[[trip 2]]\n", read("work/trip.md"))
+
+		_, err = n.SplitNote(ref, len(read("work/trip.md")))
+		assert.EqualError(t, err, "no text after the cursor")
+	})
+
+	t.Run("named by a heading at the cursor", func(t *testing.T) {
+		text := "Intro text\n## Next steps\nCall Bob"
+		ref := write("meeting.md", text)
+
+		_, err := n.SplitNote(ref, strings.Index(text, "\n## Next"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "## Next steps\nCall Bob\n", read("Next steps.md"))
+		assert.Equal(t, "Intro text\n[[Next steps]]\n", read("meeting.md"))
+	})
+
+	t.Run("at each heading", func(t *testing.T) {
+		text := "Intro\n\n## Apples\nRed\n### Kinds\nGala\n\n## Pears\n```\n## code\n```\n"
+		ref := write("fruit.md", text)
+		write("Pears.md", "taken")
+
+		created, err := n.SplitNoteAtHeadings(ref)
+		require.NoError(t, err)
+		require.Len(t, created, 2)
+
+		assert.Equal(t, "## Apples\nRed\n### Kinds\nGala\n", read("Apples.md"))
+		assert.Equal(t, "## Pears\n```\n## code\n```\n", read("Pears 2.md"))
+		assert.Equal(t, "Intro\n\n[[Apples]]\n\n[[Pears 2]]\n", read("fruit.md"))
+
+		n.Search("gala")
+		require.Len(t, n.LastSearchResults, 1)
+		assert.Equal(t, "Apples", n.LastSearchResults[0].DisplayName())
+	})
+
+	t.Run("undoes splits", func(t *testing.T) {
+		label, err := n.UndoChange()
+		require.NoError(t, err)
+		assert.Equal(t, "split", label)
+
+		assert.Equal(t, "Intro\n\n## Apples\nRed\n### Kinds\nGala\n\n## Pears\n```\n## code\n```\n", read("fruit.md"))
+		assert.NoFileExists(t, filepath.Join(dir, "Apples.md"))
+		assert.NoFileExists(t, filepath.Join(dir, "Pears 2.md"))
+		assert.Equal(t, "taken", read("Pears.md"))
+
+		n.Search("gala")
+		require.Len(t, n.LastSearchResults, 1)
+		assert.Equal(t, "fruit", n.LastSearchResults[0].DisplayName())
+
+		_, err = n.UndoChange()
+		require.NoError(t, err)
+		_, err = n.UndoChange()
+		require.NoError(t, err)
+
+		assert.Equal(t, "---\ntitle: Trip\n---\nPacking list.\n\nThe itinerary follows.\n", read("work/trip.md"))
+
+		label, err = n.UndoChange()
+		require.NoError(t, err)
+		assert.Empty(t, label, "nothing left to undo")
+	})
+
+	t.Run("named by a heading with link syntax", func(t *testing.T) {
+		text := "Intro\n## Q|A [[draft]]\nAnswers"
+		ref := write("faq.md", text)
+
+		created, err := n.SplitNoteAtHeadings(ref)
+		require.NoError(t, err)
+		require.Len(t, created, 1)
+
+		assert.Equal(t, "Q-A ((draft))", created[0].DisplayName())
+		assert.Contains(t, read("Q-A ((draft)).md"), "## Q|A [[draft]]\nAnswers\n")
+		assert.Equal(t, "Intro\n[[Q-A ((draft))]]\n", read("faq.md"))
+		assert.Equal(t, []string{"Q-A ((draft))"}, parseLinks(read("faq.md")))
+
+		linked, err := n.FollowLink("Q-A ((draft))")
+		require.NoError(t, err)
+		assert.Equal(t, created[0].Filename, linked.Filename)
+	})
+
+	t.Run("without headings", func(t *testing.T) {
+		_, err := n.SplitNoteAtHeadings(write("plain.md", "no headings\n#tag"))
+		assert.EqualError(t, err, "no headings to split at")
+	})
+}
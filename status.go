@@ -0,0 +1,118 @@
+package nve
+
+// SaveState is how far the changes made to a note are saved.
+type SaveState int
+
+const (
+	// SaveIdle means no note was edited yet.
+	SaveIdle SaveState = iota
+	// SavePending means a note was edited, and is saved once typing pauses.
+	SavePending
+	SaveInProgress
+	SaveDone
+	SaveFailed
+)
+
+// String returns a description of the state, e.g. 'saving'.
+func (s SaveState) String() string {
+	switch s {
+	case SavePending:
+		return "modified"
+	case SaveInProgress:
+		return "saving"
+	case SaveDone:
+		return "saved"
+	case SaveFailed:
+		return "save failed"
+	default:
+		return ""
+	}
+}
+
+// Status is what happens to notes in the background: saving the note being
+// edited, and watching the notes directory to keep the index up to date.
+type Status struct {
+	Save     SaveState
+	SaveErr  error
+	Watching bool
+	IndexErr error
+}
+
+// MarkModified records that a note was edited, and is about to be saved. It
+// is called on the UI's event loop, and changes the status at once.
+func (n *Notes) MarkModified(ref *FileRef) {
+	n.Status.Save, n.Status.SaveErr = SavePending, nil
+	n.NotifyStatus()
+}
+
+// Save writes the content of a note, recording whether it was saved. It may
+// be called from any goroutine.
+func (n *Notes) Save(ref *FileRef, content string) error {
+	n.updateStatus(func(s *Status) {
+		s.Save, s.SaveErr = SaveInProgress, nil
+	})
+
+	err := SaveContent(ref.Filename, content)
+
+	n.updateStatus(func(s *Status) {
+		if err != nil {
+			s.Save, s.SaveErr = SaveFailed, err
+		} else {
+			s.Save = SaveDone
+		}
+	})
+
+	return err
+}
+
+// updateStatus changes the status on the UI's event loop, if there is one,
+// then notifies observers. Changes are queued in order without waiting for
+// the event loop, so that they may also be made from the event loop itself.
+func (n *Notes) updateStatus(update func(s *Status)) {
+	n.statusLock.Lock()
+
+	if n.statusSignal == nil {
+		n.statusLock.Unlock()
+		update(&n.Status)
+		n.NotifyStatus()
+		return
+	}
+
+	n.statusQueue = append(n.statusQueue, update)
+	signal := n.statusSignal
+	n.statusLock.Unlock()
+
+	// a signal still waiting covers this change too
+	select {
+	case signal <- struct{}{}:
+	default:
+	}
+}
+
+// applyStatusUpdates makes the changes queued by updateStatus, in order, then
+// notifies observers. It is called on the UI's event loop.
+func (n *Notes) applyStatusUpdates() {
+	n.statusLock.Lock()
+	updates := n.statusQueue
+	n.statusQueue = nil
+	n.statusLock.Unlock()
+
+	if len(updates) == 0 {
+		return
+	}
+
+	for _, update := range updates {
+		update(&n.Status)
+	}
+
+	n.NotifyStatus()
+}
+
+// NotifyStatus tells observers interested in the status that it changed.
+func (n *Notes) NotifyStatus() {
+	for _, obj := range n.observers {
+		if obs, ok := obj.(StatusObserver); ok {
+			obs.StatusUpdate(n)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package nve
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// StatusBar is the line below all panes showing the current note's path,
+// whether its changes are saved, its size and the cursor position, along
+// with whether notes are watched and the number of search results.
+type StatusBar struct {
+	*tview.TextView
+	contentView *ContentBox
+	notes       *Notes
+	status      Status
+	results     int
+}
+
+// statusInfo is everything shown in the status bar.
+type statusInfo struct {
+	Path         string
+	Status       Status
+	Words, Chars int
+	Line, Column int
+	Results      int
+}
+
+func NewStatusBar(contentView *ContentBox, notes *Notes) *StatusBar {
+	bar := StatusBar{
+		TextView:    tview.NewTextView(),
+		contentView: contentView,
+		notes:       notes,
+	}
+
+	bar.SetDynamicColors(true).
+		SetWrap(false).
+		SetTextColor(Colors.Muted).
+		SetBackgroundColor(Colors.Background)
+
+	bar.SetBorderPadding(0, 0, 1, 1)
+
+	return &bar
+}
+
+// SearchResultsUpdate counts the results of the last search.
+func (b *StatusBar) SearchResultsUpdate(notes *Notes) {
+	b.results = len(notes.LastSearchResults)
+}
+
+// StatusUpdate keeps the status of saving and watching notes.
+func (b *StatusBar) StatusUpdate(notes *Notes) {
+	b.status = notes.Status
+}
+
+// Draw refreshes the status, as the cursor moves without notifying
+// observers.
+func (b *StatusBar) Draw(screen tcell.Screen) {
+	info := statusInfo{
+		Status:  b.status,
+		Results: b.results,
+	}
+
+	if ref := b.contentView.CurrentFile(); ref != nil {
+		text := b.contentView.GetText()
+		row, column, _, _ := b.contentView.GetCursor()
+
+		info.Path = b.notes.RelativePath(ref)
+		info.Words = len(strings.Fields(text))
+		info.Chars = utf8.RuneCountInString(text)
+		info.Line, info.Column = row+1, column+1
+	}
+
+	_, _, width, _ := b.GetInnerRect()
+
+	b.SetText(formatStatus(info, width))
+	b.TextView.Draw(screen)
+}
+
+// formatStatus lays out the status within width: the note and what happens
+// to it on the left, counts on the right.
+func formatStatus(info statusInfo, width int) string {
+	var left, right []string
+
+	if info.Path != "" {
+		left = append(left, tview.Escape(info.Path))
+	}
+
+	switch info.Status.Save {
+	case SaveIdle:
+	case SaveFailed:
+		left = append(left, colorTag(Colors.Error, "")+tview.Escape(fmt.Sprintf("%s: %v", info.Status.Save, info.Status.SaveErr))+"[-]")
+	default:
+		left = append(left, info.Status.Save.String())
+	}
+
+	if info.Status.IndexErr != nil {
+		left = append(left, colorTag(Colors.Error, "")+tview.Escape(fmt.Sprintf("index failed: %v", info.Status.IndexErr))+"[-]")
+	}
+
+	if info.Path != "" {
+		right = append(right,
+			plural(info.Words, "word"),
+			plural(info.Chars, "char"),
+			fmt.Sprintf("Ln %d, Col %d", info.Line, info.Column),
+		)
+	}
+
+	right = append(right, plural(info.Results, "result"))
+
+	if info.Status.Watching {
+		right = append(right, "watching")
+	} else {
+		right = append(right, "not watching")
+	}
+
+	l, r := strings.Join(left, "  "), strings.Join(right, "  ")
+	padding := width - tview.TaggedStringWidth(l) - tview.TaggedStringWidth(r)
+
+	if padding < 2 {
+		padding = 2
+	}
+
+	return l + strings.Repeat(" ", padding) + r
+}
+
+// plural returns a count along with a noun, e.g. '1 word' or '2 words'.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,72 @@
+package nve
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     statusInfo
+		width    int
+		expected string
+	}{
+		{
+			name:     "no note",
+			info:     statusInfo{Results: 3, Status: Status{Watching: true}},
+			width:    30,
+			expected: "           3 results  watching",
+		},
+		{
+			name: "saved note",
+			info: statusInfo{
+				Path:    "work/todo.md",
+				Status:  Status{Save: SaveDone, Watching: true},
+				Words:   1,
+				Chars:   5,
+				Line:    1,
+				Column:  6,
+				Results: 1,
+			},
+			width:    80,
+			expected: "work/todo.md  saved             1 word  5 chars  Ln 1, Col 6  1 result  watching",
+		},
+		{
+			name: "failures",
+			info: statusInfo{
+				Path:   "[x].md",
+				Status: Status{Save: SaveFailed, SaveErr: errors.New("disk full"), IndexErr: errors.New("locked")},
+				Line:   1,
+				Column: 1,
+			},
+			width:    20,
+			expected: "[x[].md  [red]save failed: disk full[-]  [red]index failed: locked[-]  0 words  0 chars  Ln 1, Col 1  0 results  not watching",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatStatus(tt.info, tt.width))
+		})
+	}
+}
+
+func TestStatusBarObservesNotes(t *testing.T) {
+	n, _ := setupWatcherTest(t)
+
+	ref, err := n.CreateNote("todo")
+	assert.NoError(t, err)
+
+	bar := NewStatusBar(NewContentBox(), n)
+	n.RegisterObservers(bar)
+
+	n.Search("")
+	n.MarkModified(ref)
+
+	assert.Equal(t, 1, bar.results)
+	assert.Equal(t, SavePending, bar.status.Save)
+	assert.Equal(t, "todo.md", n.RelativePath(ref))
+}
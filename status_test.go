@@ -0,0 +1,198 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusRecorder struct {
+	mockObserver
+	states []SaveState
+}
+
+func (r *statusRecorder) StatusUpdate(n *Notes) {
+	r.states = append(r.states, n.Status.Save)
+}
+
+func TestNotesSaveStatus(t *testing.T) {
+	n, dir := setupWatcherTest(t)
+
+	ref, err := n.CreateNote("todo")
+	require.NoError(t, err)
+
+	recorder := &statusRecorder{}
+	n.RegisterObservers(recorder)
+
+	n.MarkModified(ref)
+	require.NoError(t, n.Save(ref, "buy milk"))
+
+	assert.Equal(t, []SaveState{SavePending, SaveInProgress, SaveDone}, recorder.states)
+	assert.Equal(t, "buy milk", GetContent(filepath.Join(dir, "todo.md")))
+
+	// saving into a folder that is gone fails
+	missing := &FileRef{Filename: filepath.Join(dir, "gone", "note.md")}
+
+	assert.Error(t, n.Save(missing, "lost"))
+	assert.Equal(t, SaveFailed, n.Status.Save)
+	assert.Error(t, n.Status.SaveErr)
+
+	n.MarkModified(ref)
+	assert.NoError(t, n.Status.SaveErr, "editing again clears the error")
+}
+
+func TestContentBoxSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+
+	var (
+		modified = make(chan *FileRef, 1)
+		saved    = make(chan string, 1)
+		box      = NewContentBox()
+	)
+
+	box.SetModifiedFunc(func(f *FileRef) { modified <- f }).
+		SetSaveFunc(func(f *FileRef, content string) error {
+			saved <- content
+			return nil
+		})
+
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+
+	box.SetFile(&FileRef{Filename: path})
+	box.SetRect(0, 0, 80, 10)
+	box.Draw(screen)
+	box.InputHandler()(tcell.NewEventKey(tcell.KeyRune, '!', tcell.ModNone), func(p tview.Primitive) {})
+
+	assert.Equal(t, path, (<-modified).Filename)
+
+	select {
+	case content := <-saved:
+		assert.Equal(t, "!hello", content)
+	case <-time.After(2 * time.Second):
+		t.Fatal("changes were not saved")
+	}
+
+	// once saved, there is nothing left to save at once
+	require.Eventually(t, func() bool { return !box.isModified() }, time.Second, 10*time.Millisecond)
+	require.NoError(t, box.SaveNow())
+	assert.Empty(t, saved)
+
+	// saving at once goes through the save handler
+	box.InputHandler()(tcell.NewEventKey(tcell.KeyRune, '?', tcell.ModNone), func(p tview.Primitive) {})
+	<-modified
+
+	require.NoError(t, box.SaveNow())
+	assert.Equal(t, "!?hello", <-saved)
+	assert.False(t, box.isModified())
+
+	// and reports a failure to save
+	box.SetSaveFunc(func(f *FileRef, content string) error { return os.ErrPermission })
+	box.InputHandler()(tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModNone), func(p tview.Primitive) {})
+	<-modified
+
+	assert.ErrorIs(t, box.SaveNow(), os.ErrPermission)
+	assert.True(t, box.isModified(), "edits that failed to save are kept")
+}
+
+func TestNotesSaveFromEventLoop(t *testing.T) {
+	n, _ := setupWatcherTest(t)
+
+	ref, err := n.CreateNote("todo")
+	require.NoError(t, err)
+
+	// updates run on the UI's event loop, which waits for each of them as
+	// QueueUpdate does, and saving must not wait for the event loop
+	updates := make(chan func())
+	n.SetDrawFunc(func(f func()) { updates <- f })
+
+	recorder := &statusRecorder{}
+	n.RegisterObservers(recorder)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, n.Save(ref, "buy milk"))
+	}
+
+	select {
+	case apply := <-updates:
+		apply()
+	case <-time.After(2 * time.Second):
+		t.Fatal("status was not updated")
+	}
+
+	// changes made meanwhile are applied together, in order
+	assert.Equal(t, []SaveState{SaveDone}, recorder.states)
+
+	// without an event loop, changes are applied at once
+	n.SetDrawFunc(nil)
+	require.NoError(t, n.Save(ref, "buy eggs"))
+	assert.Equal(t, []SaveState{SaveDone, SaveInProgress, SaveDone}, recorder.states)
+}
+
+func TestContentBoxSavesBeforeChangingFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]*FileRef{}
+	for _, name := range []string{"a.md", "b.md"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+		files[name] = &FileRef{Filename: path}
+	}
+
+	var (
+		saved = make(chan string, 10)
+		box   = NewContentBox()
+	)
+
+	box.SetSaveFunc(func(f *FileRef, content string) error {
+		saved <- filepath.Base(f.Filename) + ": " + content
+		return nil
+	})
+
+	screen := tcell.NewSimulationScreen("")
+	require.NoError(t, screen.Init())
+
+	typeRune := func(r rune) {
+		box.SetRect(0, 0, 80, 10)
+		box.Draw(screen)
+		box.InputHandler()(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), func(p tview.Primitive) {})
+	}
+
+	nothingSaved := func(msg string) {
+		select {
+		case content := <-saved:
+			t.Fatalf("%s, but saved %q", msg, content)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	// edits still to be saved are saved before showing another file
+	box.SetFile(files["a.md"])
+	typeRune('x')
+	box.SetFile(files["b.md"])
+
+	require.Len(t, saved, 1)
+	assert.Equal(t, "a.md: xhello", <-saved)
+	nothingSaved("edits were saved once")
+
+	// or dropped when the file is read again
+	typeRune('y')
+	box.SetFile(files["b.md"])
+	nothingSaved("edits were dropped")
+
+	// saving at once saves the edits still to be saved, once
+	typeRune('z')
+	require.NoError(t, box.SaveNow())
+
+	require.Len(t, saved, 1)
+	assert.Equal(t, "b.md: zhello", <-saved)
+	nothingSaved("edits were saved once")
+}
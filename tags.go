@@ -0,0 +1,195 @@
+package nve
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TagCount is a tag along with the number of notes carrying it.
+type TagCount struct {
+	Tag   string `db:"tag"`
+	Count int    `db:"count"`
+}
+
+// hashtagPattern matches #tag tokens at the start of a line or after whitespace.
+var hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_\-/]+)`)
+
+// parseTags returns the distinct, lower-cased tags of a note, taken from
+// both its front matter 'tags' and any #tag tokens in the body.
+func parseTags(text string) []string {
+	var (
+		tags []string
+		seen = map[string]bool{}
+	)
+
+	add := func(tag string) {
+		tag = normalizeTag(tag)
+
+		if tag == "" || seen[tag] {
+			return
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	frontMatter, body := parseFrontMatter(text)
+
+	for _, tag := range frontMatter.Tags {
+		add(tag)
+	}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		add(match[1])
+	}
+
+	sort.Strings(tags)
+	return tags
+}
+
+// normalizeTag lower-cases a tag, strips any leading '#' and rejects tags
+// without letters (e.g. '#123' issue references).
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+		return ""
+	}
+
+	return tag
+}
+
+// addTag adds a tag to the front matter of content, creating the front
+// matter if needed, unless the note already has the tag.
+func addTag(content, tag string) string {
+	for _, t := range parseTags(content) {
+		if t == tag {
+			return content
+		}
+	}
+
+	fm, _ := parseFrontMatter(content)
+	return setTags(content, append(fm.Tags, tag))
+}
+
+// removeTag removes a tag from the front matter of content, and any #tag
+// tokens from its body.
+func removeTag(content, tag string) string {
+	var (
+		fm, _ = parseFrontMatter(content)
+		tags  []string
+	)
+
+	for _, t := range fm.Tags {
+		if normalizeTag(t) != tag {
+			tags = append(tags, t)
+		}
+	}
+
+	if len(tags) != len(fm.Tags) {
+		content = setTags(content, tags)
+	}
+
+	_, body, _ := splitFrontMatter(content)
+
+	return content[:len(content)-len(body)] + removeHashtags(body, tag)
+}
+
+// removeHashtags removes the #tag tokens of a tag from text, along with the
+// space before them.
+func removeHashtags(text, tag string) string {
+	matches := hashtagPattern.FindAllStringSubmatchIndex(text, -1)
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		start, end := matches[i][2]-1, matches[i][3]
+
+		if normalizeTag(text[start+1:end]) != tag {
+			continue
+		}
+
+		if start > 0 && (text[start-1] == ' ' || text[start-1] == '\t') {
+			start--
+		} else if end < len(text) && text[end] == ' ' {
+			end++
+		}
+
+		text = text[:start] + text[end:]
+	}
+
+	return text
+}
+
+// tagsEntry matches the line of the 'tags' entry of YAML or TOML front
+// matter.
+var tagsEntry = regexp.MustCompile(`^tags\s*[:=]`)
+
+// setTags replaces the tags of the front matter of content, creating the
+// front matter if needed. The 'tags' entry is removed if there are none.
+func setTags(content string, tags []string) string {
+	block, body, delim := splitFrontMatter(content)
+
+	if delim == "" {
+		if len(tags) == 0 {
+			return content
+		}
+		return "---\n" + formatTags(tags, "---") + "---\n" + content
+	}
+
+	var (
+		lines    = strings.SplitAfter(block, "\n")
+		res      strings.Builder
+		replaced bool
+	)
+
+	for i := 0; i < len(lines); i++ {
+		if replaced || !tagsEntry.MatchString(lines[i]) {
+			res.WriteString(lines[i])
+			continue
+		}
+
+		// the items of a YAML list follow on lines of their own
+		for delim == "---" && i+1 < len(lines) && isListItem(lines[i+1]) {
+			i++
+		}
+
+		if len(tags) > 0 {
+			res.WriteString(formatTags(tags, delim))
+		}
+
+		replaced = true
+	}
+
+	if !replaced && len(tags) > 0 {
+		res.WriteString(formatTags(tags, delim))
+	}
+
+	return delim + "\n" + res.String() + delim + "\n" + body
+}
+
+// isListItem returns true if a line of YAML is an item of a block list.
+func isListItem(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "- ") || strings.TrimSpace(line) == "-"
+}
+
+// formatTags formats the 'tags' entry of front matter as a single line.
+func formatTags(tags []string, delim string) string {
+	quoted := make([]string, len(tags))
+
+	for i, tag := range tags {
+		if delim == "+++" || strings.ContainsAny(tag, ",[]{}:#'\" ") {
+			quoted[i] = strconv.Quote(tag)
+		} else {
+			quoted[i] = tag
+		}
+	}
+
+	if delim == "+++" {
+		return fmt.Sprintf("tags = [%s]\n", strings.Join(quoted, ", "))
+	}
+
+	return fmt.Sprintf("tags: [%s]\n", strings.Join(quoted, ", "))
+}
@@ -0,0 +1,72 @@
+package nve
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/rivo/tview"
+)
+
+// TagsBox is a sidebar listing all tags along with their note counts.
+type TagsBox struct {
+	*tview.List
+	tags       []*TagCount
+	selectFunc func(tag string)
+}
+
+func NewTagsBox() *TagsBox {
+	box := TagsBox{
+		List: tview.NewList(),
+	}
+
+	box.ShowSecondaryText(false).
+		SetWrapAround(false).
+		SetHighlightFullLine(true).
+		SetSelectedFocusOnly(true).
+		SetSelectedStyle(Colors.SelectedStyle())
+
+	box.SetBorder(true).
+		SetTitle("Tags").
+		SetTitleColor(Colors.PaneTitle).
+		SetBorderStyle(Colors.BorderStyle()).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		if box.selectFunc != nil && index < len(box.tags) {
+			box.selectFunc(box.tags[index].Tag)
+		}
+	})
+
+	return &box
+}
+
+// SetSelectFunc sets a handler called when a tag is selected.
+func (b *TagsBox) SetSelectFunc(handler func(tag string)) *TagsBox {
+	b.selectFunc = handler
+	return b
+}
+
+// SearchResultsUpdate refreshes tag counts, which may change whenever the
+// index is refreshed.
+func (b *TagsBox) SearchResultsUpdate(notes *Notes) {
+	tags, err := notes.Tags()
+
+	if err != nil {
+		log.Printf("[ERROR] TagsBox: %v", err)
+		return
+	}
+
+	current := b.GetCurrentItem()
+
+	b.tags = tags
+	b.Clear()
+
+	for _, tag := range tags {
+		b.AddItem(tview.Escape(fmt.Sprintf("#%s (%d)", tag.Tag, tag.Count)), "", 0, nil)
+	}
+
+	if current < len(tags) {
+		b.SetCurrentItem(current)
+	}
+}
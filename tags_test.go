@@ -0,0 +1,157 @@
+package nve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "no tags",
+			input:    "plain text",
+			expected: nil,
+		},
+		{
+			name:     "hashtags are lower-cased and sorted",
+			input:    "#Work notes about #project/alpha",
+			expected: []string{"project/alpha", "work"},
+		},
+		{
+			name:     "headings, anchors and numbers are not tags",
+			input:    "# Heading\n## Sub\nsee page#anchor and issue #123",
+			expected: nil,
+		},
+		{
+			name:     "front matter list",
+			input:    "---\ntitle: Note\ntags: [one, Two]\n---\nbody #three",
+			expected: []string{"one", "three", "two"},
+		},
+		{
+			name:     "front matter string",
+			input:    "---\ntags: one, two\n---\n",
+			expected: []string{"one", "two"},
+		},
+		{
+			name:     "front matter comments are not tags",
+			input:    "---\n# comment\ntags:\n  - one\n---\nbody",
+			expected: []string{"one"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseTags(tc.input))
+		})
+	}
+}
+
+func TestAddRemoveTag(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		added   string
+		removed string
+	}{
+		{
+			name:    "no front matter",
+			input:   "body #work",
+			added:   "---\ntags: [plan]\n---\nbody #work",
+			removed: "body #work",
+		},
+		{
+			name:    "front matter list",
+			input:   "---\ntitle: Note\ntags: [plan, work]\n---\nbody",
+			added:   "---\ntitle: Note\ntags: [plan, work]\n---\nbody",
+			removed: "---\ntitle: Note\ntags: [work]\n---\nbody",
+		},
+		{
+			name:    "front matter without tags",
+			input:   "---\ntitle: Note\n---\nbody",
+			added:   "---\ntitle: Note\ntags: [plan]\n---\nbody",
+			removed: "---\ntitle: Note\n---\nbody",
+		},
+		{
+			name:    "block list",
+			input:   "---\ntags:\n  - Plan\n  - work\ntitle: Note\n---\nbody",
+			added:   "---\ntags:\n  - Plan\n  - work\ntitle: Note\n---\nbody",
+			removed: "---\ntags: [work]\ntitle: Note\n---\nbody",
+		},
+		{
+			name:    "last tag removed",
+			input:   "---\ntitle: Note\ntags: [plan]\n---\nbody",
+			added:   "---\ntitle: Note\ntags: [plan]\n---\nbody",
+			removed: "---\ntitle: Note\n---\nbody",
+		},
+		{
+			name:    "toml",
+			input:   "+++\ntags = [\"work\"]\n+++\nbody",
+			added:   "+++\ntags = [\"work\", \"plan\"]\n+++\nbody",
+			removed: "+++\ntags = [\"work\"]\n+++\nbody",
+		},
+		{
+			name:    "hashtags",
+			input:   "#plan for #work, more #Plan\n#plans stay",
+			added:   "#plan for #work, more #Plan\n#plans stay",
+			removed: "for #work, more\n#plans stay",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.added, addTag(tc.input, "plan"))
+			assert.Equal(t, tc.removed, removeTag(tc.input, "plan"))
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q := parseQuery("tag:Work  meeting tag: notes")
+
+	assert.Equal(t, "meeting notes", q.terms)
+	assert.Equal(t, []string{"work"}, q.tags)
+	assert.True(t, q.hasFilters())
+}
+
+func TestTagSearch(t *testing.T) {
+	withNewDB(func(db *DB) {
+		docs := map[string]string{
+			"/tmp/work.md":  "#work meeting notes",
+			"/tmp/home.md":  "#home meeting notes",
+			"/tmp/both.md":  "#work #home",
+			"/tmp/plain.md": "meeting",
+		}
+
+		for filename, text := range docs {
+			ref := &FileRef{Filename: filename, MD5: "abc", ModifiedAt: time.Now()}
+			require.NoError(t, db.Upsert(ref, []byte(text)))
+		}
+
+		tags, err := db.Tags()
+		require.NoError(t, err)
+		assert.Equal(t, []*TagCount{{Tag: "home", Count: 2}, {Tag: "work", Count: 2}}, tags)
+
+		filenames := func(results []*SearchResult) []string {
+			var res []string
+			for _, r := range results {
+				res = append(res, r.Filename)
+			}
+			return res
+		}
+
+		results, err := db.Search("tag:work meeting")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/tmp/work.md"}, filenames(results))
+
+		results, err = db.Search("tag:work tag:home")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/tmp/both.md"}, filenames(results))
+	})
+}
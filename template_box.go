@@ -0,0 +1,69 @@
+package nve
+
+import (
+	"github.com/rivo/tview"
+)
+
+// TemplateBox is a modal list used to pick the template for a new note.
+type TemplateBox struct {
+	*tview.List
+	templates []string
+	doneFunc  func(template string, picked bool)
+}
+
+func NewTemplateBox() *TemplateBox {
+	box := TemplateBox{
+		List: tview.NewList(),
+	}
+
+	box.ShowSecondaryText(false).
+		SetHighlightFullLine(true).
+		SetSelectedStyle(Colors.SelectedStyle())
+
+	box.SetBorder(true).
+		SetTitle("Templates").
+		SetBackgroundColor(Colors.Background).
+		SetTitleColor(Colors.Title).
+		SetBorderPadding(0, 0, 1, 1).
+		SetTitleAlign(tview.AlignLeft)
+
+	box.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		switch {
+		case index == 0:
+			box.finish("", true)
+		case index <= len(box.templates):
+			box.finish(box.templates[index-1], true)
+		}
+	})
+
+	box.SetDoneFunc(func() {
+		box.finish("", false)
+	})
+
+	return &box
+}
+
+// SetPickedFunc sets a handler called once a template is picked (an empty
+// template meaning an empty note) or picking was cancelled.
+func (b *TemplateBox) SetPickedFunc(handler func(template string, picked bool)) *TemplateBox {
+	b.doneFunc = handler
+	return b
+}
+
+// Show lists the given templates, with an empty note as the first choice.
+func (b *TemplateBox) Show(templates []string) {
+	b.templates = templates
+	b.Clear()
+
+	b.AddItem("(empty note)", "", 0, nil)
+
+	for _, template := range templates {
+		b.AddItem(tview.Escape(template), "", 0, nil)
+	}
+}
+
+func (b *TemplateBox) finish(template string, picked bool) {
+	if b.doneFunc != nil {
+		b.doneFunc(template, picked)
+	}
+}
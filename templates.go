@@ -0,0 +1,188 @@
+package nve
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configDirName is the per-vault directory holding nve's own files, such as
+// templates. It is never indexed as notes.
+const configDirName = ".nve"
+
+// DefaultTemplate is applied to new notes when no other template is chosen.
+const DefaultTemplate = "default"
+
+// now is the clock used when rendering templates.
+var now = time.Now
+
+// templateVarPattern matches '{{name}}' template variables.
+var templateVarPattern = regexp.MustCompile(`{{\s*(\w+)\s*}}`)
+
+// templatesDir returns the directory holding note templates.
+func (n *Notes) templatesDir() string {
+	return filepath.Join(n.config.Filepath, configDirName, "templates")
+}
+
+// Templates returns the names of all available templates, sorted.
+func (n *Notes) Templates() []string {
+	entries, err := os.ReadDir(n.templatesDir())
+
+	if err != nil {
+		return nil
+	}
+
+	var (
+		names []string
+		seen  = map[string]bool{}
+	)
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+
+		if !entry.IsDir() && SUPPORTED_FILETYPES[filepath.Ext(entry.Name())] && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// templateContent returns the raw text of the named template. Templates of
+// the same name are preferred with the default extension, then with the
+// other extensions in alphabetical order.
+func (n *Notes) templateContent(name string) (string, bool) {
+	for _, ext := range n.templateExtensions() {
+		if bytes, err := os.ReadFile(filepath.Join(n.templatesDir(), name+ext)); err == nil {
+			return string(bytes), true
+		}
+	}
+
+	return "", false
+}
+
+// templateExtensions returns the extensions of templates in the order they
+// are tried: the default extension first, then the others sorted.
+func (n *Notes) templateExtensions() []string {
+	exts := []string{n.config.Extension}
+
+	var others []string
+
+	for ext := range SUPPORTED_FILETYPES {
+		if ext != n.config.Extension {
+			others = append(others, ext)
+		}
+	}
+
+	sort.Strings(others)
+	return append(exts, others...)
+}
+
+// SplitTemplatePrefix splits a 'template: title' query into its template and
+// title. If the prefix does not name an existing template, the query is
+// returned unchanged as the title.
+func (n *Notes) SplitTemplatePrefix(query string) (string, string) {
+	prefix, title, ok := strings.Cut(query, ":")
+
+	if !ok {
+		return "", query
+	}
+
+	prefix, title = strings.TrimSpace(prefix), strings.TrimSpace(title)
+
+	if _, exists := n.templateContent(prefix); !exists || title == "" {
+		return "", query
+	}
+
+	return prefix, title
+}
+
+// renderTemplate expands the variables of a template for a note with the
+// given title. The returned offset is where the '{{cursor}}' marker was
+// (or the end of the text, if there is no marker). Within front matter, the
+// title is quoted so that it is parsed as text.
+//
+// Supported variables: {{title}}, {{date}}, {{time}}, {{uuid}}, {{cursor}}.
+func renderTemplate(tmpl, title string) (string, int) {
+	var (
+		t      = now()
+		cursor = -1
+		sb     strings.Builder
+		last   = 0
+	)
+
+	_, body, _ := splitFrontMatter(tmpl)
+	frontMatterEnd := len(tmpl) - len(body)
+
+	for _, loc := range templateVarPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		sb.WriteString(tmpl[last:loc[0]])
+		last = loc[1]
+
+		switch name := tmpl[loc[2]:loc[3]]; name {
+		case "title":
+			if loc[0] < frontMatterEnd {
+				sb.WriteString(quoteFrontMatterValue(title, tmpl[:loc[0]], tmpl[loc[1]:]))
+			} else {
+				sb.WriteString(title)
+			}
+		case "date":
+			sb.WriteString(t.Format("2006-01-02"))
+		case "time":
+			sb.WriteString(t.Format("15:04"))
+		case "uuid":
+			sb.WriteString(newUUID())
+		case "cursor":
+			if cursor < 0 {
+				cursor = sb.Len()
+			}
+		default:
+			// leave unknown variables untouched
+			sb.WriteString(tmpl[loc[0]:loc[1]])
+		}
+	}
+
+	sb.WriteString(tmpl[last:])
+
+	if cursor < 0 {
+		cursor = sb.Len()
+	}
+
+	return sb.String(), cursor
+}
+
+// quoteFrontMatterValue quotes a value placed into front matter between
+// before and after. A value the template already quotes is escaped instead.
+func quoteFrontMatterValue(value, before, after string) string {
+	switch {
+	case strings.HasSuffix(before, `"`) && strings.HasPrefix(after, `"`):
+		quoted := strconv.QuoteToGraphic(value)
+		return quoted[1 : len(quoted)-1]
+	case strings.HasSuffix(before, "'") && strings.HasPrefix(after, "'"):
+		// as in YAML, where quotes are doubled within single quotes
+		return strings.ReplaceAll(value, "'", "''")
+	default:
+		return strconv.QuoteToGraphic(value)
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
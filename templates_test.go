@@ -0,0 +1,202 @@
+package nve
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withFixedTime(t *testing.T, value string) {
+	t.Helper()
+
+	fixed, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestRenderTemplate(t *testing.T) {
+	withFixedTime(t, "2024-07-01T09:30:00Z")
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+		cursor   int
+	}{
+		{
+			name:     "expands variables",
+			template: "# {{title}}\n{{date}} {{ time }}\n",
+			expected: "# Q3 planning\n2024-07-01 09:30\n",
+			cursor:   len("# Q3 planning\n2024-07-01 09:30\n"),
+		},
+		{
+			name:     "places cursor at marker",
+			template: "# {{title}}\n\n{{cursor}}\n",
+			expected: "# Q3 planning\n\n\n",
+			cursor:   len("# Q3 planning\n\n"),
+		},
+		{
+			name:     "leaves unknown variables untouched",
+			template: "{{unknown}}",
+			expected: "{{unknown}}",
+			cursor:   len("{{unknown}}"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			text, cursor := renderTemplate(tc.template, "Q3 planning")
+			assert.Equal(t, tc.expected, text)
+			assert.Equal(t, tc.cursor, cursor)
+		})
+	}
+
+	text, _ := renderTemplate("{{uuid}}", "")
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), text)
+}
+
+func TestRenderTemplateFrontMatter(t *testing.T) {
+	title := `[Q3]: "plans" #1 it's`
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "quotes YAML values",
+			template: "---\ntitle: {{title}}\n---\n# {{title}}\n",
+			expected: "---\ntitle: \"[Q3]: \\\"plans\\\" #1 it's\"\n---\n# " + title + "\n",
+		},
+		{
+			name:     "escapes double-quoted values",
+			template: "---\ntitle: \"{{title}}\"\n---\n",
+			expected: "---\ntitle: \"[Q3]: \\\"plans\\\" #1 it's\"\n---\n",
+		},
+		{
+			name:     "escapes single-quoted values",
+			template: "---\ntitle: '{{title}}'\n---\n",
+			expected: "---\ntitle: '[Q3]: \"plans\" #1 it''s'\n---\n",
+		},
+		{
+			name:     "quotes TOML values",
+			template: "+++\ntitle = {{title}}\n+++\n",
+			expected: "+++\ntitle = \"[Q3]: \\\"plans\\\" #1 it's\"\n+++\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			text, _ := renderTemplate(tc.template, title)
+			assert.Equal(t, tc.expected, text)
+
+			fm, _ := parseFrontMatter(text)
+			assert.Equal(t, title, fm.Title)
+		})
+	}
+}
+
+func setupTemplates(t *testing.T, templates map[string]string) (*Notes, string) {
+	t.Helper()
+
+	n, dir := setupWatcherTest(t)
+	templatesDir := filepath.Join(dir, configDirName, "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, 0755))
+
+	for name, content := range templates {
+		require.NoError(t, os.WriteFile(filepath.Join(templatesDir, name), []byte(content), 0644))
+	}
+
+	return n, dir
+}
+
+func TestTemplateExtensions(t *testing.T) {
+	n, _ := setupTemplates(t, map[string]string{
+		"meeting.rb":  "rb",
+		"meeting.txt": "txt",
+		"meeting.md":  "md",
+		"notes.txt":   "notes",
+	})
+
+	assert.Equal(t, []string{"meeting", "notes"}, n.Templates())
+
+	for _, ext := range []string{".md", ".txt", ".rb"} {
+		n.config.Extension = ext
+
+		content, ok := n.templateContent("meeting")
+		assert.True(t, ok)
+		assert.Equal(t, ext[1:], content, "prefers the default extension")
+	}
+
+	n.config.Extension = ".mdown"
+
+	content, ok := n.templateContent("meeting")
+	assert.True(t, ok)
+	assert.Equal(t, "md", content, "then tries the others alphabetically")
+}
+
+func TestSplitTemplatePrefix(t *testing.T) {
+	n, _ := setupTemplates(t, map[string]string{"meeting.md": "# {{title}}"})
+
+	testCases := []struct {
+		input    string
+		template string
+		title    string
+	}{
+		{input: "meeting: Q3 planning", template: "meeting", title: "Q3 planning"},
+		{input: "unknown: Q3 planning", template: "", title: "unknown: Q3 planning"},
+		{input: "meeting:", template: "", title: "meeting:"},
+		{input: "plain title", template: "", title: "plain title"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			template, title := n.SplitTemplatePrefix(tc.input)
+			assert.Equal(t, tc.template, template)
+			assert.Equal(t, tc.title, title)
+		})
+	}
+
+	assert.Equal(t, []string{"meeting"}, n.Templates())
+}
+
+func TestCreateNoteFromTemplate(t *testing.T) {
+	withFixedTime(t, "2024-07-01T09:30:00Z")
+
+	n, dir := setupTemplates(t, map[string]string{
+		"default.md": "---\ncreated: {{date}}\n---\n{{cursor}}",
+		"meeting.md": "# {{title}}\n\n{{cursor}}\n",
+	})
+
+	ref, cursor, err := n.CreateNoteFromTemplate("Q3 planning", "meeting")
+	require.NoError(t, err)
+	assert.Equal(t, "# Q3 planning\n\n\n", GetContent(ref.Filename))
+	assert.Equal(t, len("# Q3 planning\n\n"), cursor)
+
+	// CreateNote applies the default template
+	ref, err = n.CreateNote("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ncreated: 2024-07-01\n---\n", GetContent(ref.Filename))
+
+	// existing notes are left untouched
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.md"), []byte("keep me"), 0644))
+	ref, cursor, err = n.CreateNoteFromTemplate("existing", "meeting")
+	require.NoError(t, err)
+	assert.Equal(t, "keep me", GetContent(ref.Filename))
+	assert.Equal(t, 0, cursor)
+
+	// templates are never indexed as notes
+	_, err = n.Refresh()
+	require.NoError(t, err)
+
+	results, err := n.db.Search("cursor")
+	require.NoError(t, err)
+	assert.Len(t, results, 0)
+}
@@ -0,0 +1,287 @@
+package nve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
+	"github.com/rivo/tview"
+)
+
+// Names of the built-in themes, as set by 'theme' in the config file.
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+)
+
+// Theme is the set of colors the UI is drawn with. Colors left as
+// tcell.ColorDefault use the terminal's colors, and selections and search
+// matches are then shown in reverse video instead.
+type Theme struct {
+	Background tcell.Color
+	Text       tcell.Color
+	Border     tcell.Color
+
+	// Title is the title of the search box and of overlays, PaneTitle that
+	// of the other panes.
+	Title     tcell.Color
+	PaneTitle tcell.Color
+
+	// Accent marks keys in the help overlay and days with notes in the
+	// journal, Muted marks secondary text such as key hints, and Error
+	// failures such as a note that could not be saved.
+	Accent tcell.Color
+	Muted  tcell.Color
+	Error  tcell.Color
+
+	SelectedBackground  tcell.Color
+	SelectedText        tcell.Color
+	HighlightBackground tcell.Color
+	HighlightText       tcell.Color
+}
+
+// Colors is the theme all widgets are drawn with.
+var Colors = DarkTheme()
+
+// DarkTheme returns the default theme, for terminals with a dark background.
+func DarkTheme() *Theme {
+	return &Theme{
+		Background:          tcell.ColorBlack,
+		Text:                tcell.ColorWhite,
+		Border:              tcell.ColorDefault,
+		Title:               tcell.ColorYellow,
+		PaneTitle:           tcell.ColorOrange,
+		Accent:              tcell.ColorOrange,
+		Muted:               tcell.ColorGray,
+		Error:               tcell.ColorRed,
+		SelectedBackground:  tcell.ColorDarkBlue,
+		SelectedText:        tcell.ColorLightSkyBlue,
+		HighlightBackground: tcell.ColorYellow,
+		HighlightText:       tcell.ColorBlack,
+	}
+}
+
+// LightTheme returns a theme for terminals with a light background.
+func LightTheme() *Theme {
+	return &Theme{
+		Background:          tcell.ColorWhite,
+		Text:                tcell.ColorBlack,
+		Border:              tcell.ColorDefault,
+		Title:               tcell.ColorNavy,
+		PaneTitle:           tcell.ColorDarkOrange,
+		Accent:              tcell.ColorTeal,
+		Muted:               tcell.ColorGray,
+		Error:               tcell.ColorMaroon,
+		SelectedBackground:  tcell.ColorLightSkyBlue,
+		SelectedText:        tcell.ColorNavy,
+		HighlightBackground: tcell.ColorYellow,
+		HighlightText:       tcell.ColorBlack,
+	}
+}
+
+// HighContrastTheme returns a theme using only black, white and a few
+// bright colors.
+func HighContrastTheme() *Theme {
+	return &Theme{
+		Background:          tcell.ColorBlack,
+		Text:                tcell.ColorWhite,
+		Border:              tcell.ColorWhite,
+		Title:               tcell.ColorYellow,
+		PaneTitle:           tcell.ColorAqua,
+		Accent:              tcell.ColorYellow,
+		Muted:               tcell.ColorWhite,
+		Error:               tcell.ColorRed,
+		SelectedBackground:  tcell.ColorWhite,
+		SelectedText:        tcell.ColorBlack,
+		HighlightBackground: tcell.ColorYellow,
+		HighlightText:       tcell.ColorBlack,
+	}
+}
+
+// NoColorTheme returns a theme using the terminal's colors only, as asked
+// for by setting NO_COLOR (see https://no-color.org).
+func NoColorTheme() *Theme {
+	return &Theme{}
+}
+
+// NewTheme returns a built-in theme.
+func NewTheme(name string) (*Theme, error) {
+	switch name {
+	case "", ThemeDark:
+		return DarkTheme(), nil
+	case ThemeLight:
+		return LightTheme(), nil
+	case ThemeHighContrast:
+		return HighContrastTheme(), nil
+	default:
+		return nil, errors.Errorf("unknown theme: %s", name)
+	}
+}
+
+// colors returns the colors of the theme by the names used in config files.
+func (t *Theme) colors() map[string]*tcell.Color {
+	return map[string]*tcell.Color{
+		"background":           &t.Background,
+		"text":                 &t.Text,
+		"border":               &t.Border,
+		"title":                &t.Title,
+		"pane-title":           &t.PaneTitle,
+		"accent":               &t.Accent,
+		"muted":                &t.Muted,
+		"error":                &t.Error,
+		"selected-background":  &t.SelectedBackground,
+		"selected-text":        &t.SelectedText,
+		"highlight-background": &t.HighlightBackground,
+		"highlight-text":       &t.HighlightText,
+	}
+}
+
+// SetColor changes a color of the theme given by name, e.g. 'pane-title',
+// to a color name ('orange'), a hex value ('#ff8800') or 'default'.
+func (t *Theme) SetColor(name, value string) error {
+	color, ok := t.colors()[name]
+
+	if !ok {
+		names := make([]string, 0, len(t.colors()))
+		for name := range t.colors() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return errors.Errorf("unknown theme color: %s (expected one of %s)", name, strings.Join(names, ", "))
+	}
+
+	parsed, err := parseColor(value)
+
+	if err != nil {
+		return err
+	}
+
+	*color = parsed
+	return nil
+}
+
+// parseColor parses a color name or hex value. 'default' is the terminal's
+// color.
+func parseColor(value string) (tcell.Color, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if value == "default" {
+		return tcell.ColorDefault, nil
+	}
+
+	if color := tcell.GetColor(value); color != tcell.ColorDefault {
+		return color, nil
+	}
+
+	return tcell.ColorDefault, errors.Errorf("unknown color: %s", value)
+}
+
+// Apply makes the theme the default of tview widgets, such as buttons of
+// dialogs. Widgets created earlier are unaffected.
+func (t *Theme) Apply() {
+	tview.Styles.PrimitiveBackgroundColor = t.Background
+	tview.Styles.ContrastBackgroundColor = t.SelectedBackground
+	tview.Styles.MoreContrastBackgroundColor = t.SelectedBackground
+	tview.Styles.BorderColor = t.Border
+	tview.Styles.TitleColor = t.Title
+	tview.Styles.GraphicsColor = t.Border
+	tview.Styles.PrimaryTextColor = t.Text
+	tview.Styles.SecondaryTextColor = t.Accent
+	tview.Styles.TertiaryTextColor = t.Muted
+	tview.Styles.InverseTextColor = t.SelectedText
+	tview.Styles.ContrastSecondaryTextColor = t.Muted
+}
+
+// BorderStyle returns the style of pane borders, which are dimmed unless
+// the theme sets their color.
+func (t *Theme) BorderStyle() tcell.Style {
+	style := tcell.StyleDefault.Background(t.Background)
+
+	if t.Border == tcell.ColorDefault {
+		return style.Dim(true)
+	}
+
+	return style.Foreground(t.Border)
+}
+
+// SelectedStyle returns the style of the selected item of lists.
+func (t *Theme) SelectedStyle() tcell.Style {
+	return t.selected(tcell.StyleDefault)
+}
+
+// selected returns a style marked as selected, keeping its attributes.
+func (t *Theme) selected(style tcell.Style) tcell.Style {
+	if t.SelectedBackground == tcell.ColorDefault {
+		return style.Reverse(true)
+	}
+
+	return style.
+		Background(t.SelectedBackground).
+		Foreground(t.SelectedText)
+}
+
+// HighlightStyle returns the style of search matches in the content box.
+func (t *Theme) HighlightStyle() tcell.Style {
+	if t.HighlightBackground == tcell.ColorDefault {
+		return tcell.StyleDefault.Reverse(true).Bold(true)
+	}
+
+	return tcell.StyleDefault.
+		Background(t.HighlightBackground).
+		Foreground(t.HighlightText).
+		Bold(true)
+}
+
+// highlightTag returns the tview color tag of HighlightStyle, to highlight
+// matches in text with dynamic colors.
+func (t *Theme) highlightTag() string {
+	if t.HighlightBackground == tcell.ColorDefault {
+		return "[::rb]"
+	}
+
+	text := "-"
+	if t.HighlightText != tcell.ColorDefault {
+		text = colorName(t.HighlightText)
+	}
+
+	return "[" + text + ":" + colorName(t.HighlightBackground) + ":b]"
+}
+
+// colorTag returns the tview color tag of a color, e.g. '[orange]', to be
+// used in text with dynamic colors.
+func colorTag(color tcell.Color, attributes string) string {
+	name := "-"
+
+	if color != tcell.ColorDefault {
+		name = colorName(color)
+	}
+
+	if attributes != "" {
+		return "[" + name + "::" + attributes + "]"
+	}
+
+	return "[" + name + "]"
+}
+
+// colorName returns the name of a color, such as 'orange', or its hex value
+// if it has no name.
+func colorName(color tcell.Color) string {
+	name := ""
+
+	// some colors have several names, e.g. 'gray' and 'grey'
+	for n, c := range tcell.ColorNames {
+		if c == color && (name == "" || n < name) {
+			name = n
+		}
+	}
+
+	if name == "" {
+		return fmt.Sprintf("#%06x", color.Hex())
+	}
+
+	return name
+}
@@ -0,0 +1,80 @@
+package nve
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTheme(t *testing.T) {
+	for name, expected := range map[string]*Theme{
+		"":                DarkTheme(),
+		ThemeDark:         DarkTheme(),
+		ThemeLight:        LightTheme(),
+		ThemeHighContrast: HighContrastTheme(),
+	} {
+		theme, err := NewTheme(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, theme, name)
+	}
+
+	_, err := NewTheme("solarized")
+	assert.Error(t, err)
+}
+
+func TestThemeSetColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected tcell.Color
+		err      bool
+	}{
+		{name: "pane-title", value: "red", expected: tcell.ColorRed},
+		{name: "pane-title", value: " Orange ", expected: tcell.ColorOrange},
+		{name: "pane-title", value: "#fdf6e3", expected: tcell.NewHexColor(0xfdf6e3)},
+		{name: "pane-title", value: "default", expected: tcell.ColorDefault},
+		{name: "pane-title", value: "blurple", err: true},
+		{name: "sidebar", value: "red", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.value, func(t *testing.T) {
+			theme := DarkTheme()
+			err := theme.SetColor(tt.name, tt.value)
+
+			if tt.err {
+				assert.Error(t, err)
+				assert.Equal(t, DarkTheme(), theme)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, theme.PaneTitle)
+		})
+	}
+}
+
+func TestThemeStyles(t *testing.T) {
+	dark := DarkTheme()
+
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.ColorDarkBlue).Foreground(tcell.ColorLightSkyBlue), dark.SelectedStyle())
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.ColorYellow).Foreground(tcell.ColorBlack).Bold(true), dark.HighlightStyle())
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.ColorBlack).Dim(true), dark.BorderStyle())
+	assert.Equal(t, tcell.StyleDefault.Background(tcell.ColorBlack).Foreground(tcell.ColorWhite), HighContrastTheme().BorderStyle())
+
+	// without colors, selections and matches are shown in reverse video
+	plain := NoColorTheme()
+
+	assert.Equal(t, tcell.StyleDefault.Reverse(true), plain.SelectedStyle())
+	assert.Equal(t, tcell.StyleDefault.Reverse(true).Bold(true), plain.HighlightStyle())
+	assert.Equal(t, tcell.StyleDefault.Bold(true).Reverse(true), plain.selected(tcell.StyleDefault.Bold(true)))
+}
+
+func TestColorTag(t *testing.T) {
+	assert.Equal(t, "[orange]", colorTag(tcell.ColorOrange, ""))
+	assert.Equal(t, "[gray::b]", colorTag(tcell.ColorGray, "b"))
+	assert.Equal(t, "[#fdf6e3]", colorTag(tcell.NewHexColor(0xfdf6e3), ""))
+	assert.Equal(t, "[-::b]", colorTag(tcell.ColorDefault, "b"))
+}
@@ -26,7 +26,7 @@ func TestMain(m *testing.M) {
 	defer os.RemoveAll(tmp)
 
 	binaryPath = filepath.Join(tmp, "nve")
-	cmd := exec.Command("go", "build", "--tags=fts5", "-o", binaryPath, "./cmd/main.go")
+	cmd := exec.Command("go", "build", "--tags=fts5", "-o", binaryPath, "./cmd")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -82,6 +82,11 @@ func NewTUIHarness(t *testing.T, seedFiles map[string]string) *TUIHarness {
 		t.Fatalf("failed to start tmux session: %v\n%s", err, out)
 	}
 
+	// Keep text the app copies with OSC 52 in a tmux buffer (see Clipboard)
+	if out, err := exec.Command("tmux", "set-option", "-s", "set-clipboard", "on").CombinedOutput(); err != nil {
+		t.Fatalf("failed to enable clipboard: %v\n%s", err, out)
+	}
+
 	// Wait for app to be ready (Search Box title visible)
 	h.WaitFor(func(screen string) bool {
 		return strings.Contains(screen, "Search Box")
@@ -104,6 +109,75 @@ func (h *TUIHarness) SendKeys(keys ...string) {
 	}
 }
 
+// mouse sends a mouse event in SGR encoding at a cell of the pane, counted
+// from 0: a press (or a move, with button 32 added) or a release.
+func (h *TUIHarness) mouse(button, x, y int, pressed bool) {
+	h.t.Helper()
+
+	final := "M"
+	if !pressed {
+		final = "m"
+	}
+
+	seq := fmt.Sprintf("\x1b[<%d;%d;%d%s", button, x+1, y+1, final)
+	cmd := exec.Command("tmux", "send-keys", "-t", h.session, "-l", seq)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		h.t.Fatalf("mouse(%q) failed: %v\n%s", seq, err, out)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
+// Click clicks the left button at a cell of the pane, counted from 0.
+func (h *TUIHarness) Click(x, y int) {
+	h.t.Helper()
+	h.mouse(0, x, y, true)
+	h.mouse(0, x, y, false)
+	time.Sleep(300 * time.Millisecond)
+}
+
+// DoubleClick clicks the left button twice in a row at a cell of the pane.
+func (h *TUIHarness) DoubleClick(x, y int) {
+	h.t.Helper()
+	for i := 0; i < 2; i++ {
+		h.mouse(0, x, y, true)
+		h.mouse(0, x, y, false)
+	}
+	time.Sleep(300 * time.Millisecond)
+}
+
+// Scroll turns the mouse wheel once at a cell of the pane, down or up.
+func (h *TUIHarness) Scroll(x, y int, down bool) {
+	h.t.Helper()
+	button := 64
+	if down {
+		button = 65
+	}
+	h.mouse(button, x, y, true)
+	time.Sleep(250 * time.Millisecond)
+}
+
+// Drag presses the left button at a cell of the pane and releases it at
+// another one, moving there a row or column at a time.
+func (h *TUIHarness) Drag(fromX, fromY, toX, toY int) {
+	h.t.Helper()
+	h.mouse(0, fromX, fromY, true)
+	for x, y := fromX, fromY; x != toX || y != toY; {
+		if x < toX {
+			x++
+		} else if x > toX {
+			x--
+		}
+		if y < toY {
+			y++
+		} else if y > toY {
+			y--
+		}
+		h.mouse(32, x, y, true)
+	}
+	h.mouse(0, toX, toY, false)
+	time.Sleep(300 * time.Millisecond)
+}
+
 // Capture returns the current tmux pane content.
 func (h *TUIHarness) Capture() string {
 	h.t.Helper()
@@ -132,6 +206,32 @@ func (h *TUIHarness) CaptureWithColors() string {
 	return string(out)
 }
 
+// Clipboard returns the text last copied with OSC 52, as kept by tmux in
+// its most recent buffer.
+func (h *TUIHarness) Clipboard() string {
+	h.t.Helper()
+
+	out, err := exec.Command("tmux", "show-buffer").Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// WaitForClipboard polls the clipboard every 200ms until it holds the expected text or timeout is reached.
+func (h *TUIHarness) WaitForClipboard(expected string, timeout time.Duration) {
+	h.t.Helper()
+	deadline := time.Now().Add(timeout)
+	var text string
+	for time.Now().Before(deadline) {
+		if text = h.Clipboard(); text == expected {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	h.t.Fatalf("WaitForClipboard timed out after %v — clipboard: %q, expected: %q", timeout, text, expected)
+}
+
 // WaitForWithColors polls the screen every 200ms (with ANSI colors) until predicate returns true or timeout is reached.
 func (h *TUIHarness) WaitForWithColors(predicate func(screen string) bool, timeout time.Duration) string {
 	h.t.Helper()
@@ -4,6 +4,8 @@ package nve
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -220,11 +222,11 @@ func TestTUI_SearchHighlightsContent(t *testing.T) {
 		return ""
 	}
 
-	// Derive the ANSI SGR background escape from the HighlightBackground constant.
+	// Derive the ANSI SGR background escape from the highlight color of the default theme.
 	// tcell's first 16 named colors (ColorBlack..ColorWhite) map to SGR codes:
 	//   indices 0-7  → bg 40-47
 	//   indices 8-15 → bg 100-107
-	colorIndex := int(HighlightBackground - tcell.ColorBlack)
+	colorIndex := int(DarkTheme().HighlightBackground - tcell.ColorBlack)
 	var sgrBg int
 	if colorIndex < 8 {
 		sgrBg = 40 + colorIndex
@@ -270,3 +272,698 @@ func TestTUI_SearchHighlightsContent(t *testing.T) {
 		return line == "" || !strings.Contains(line, highlightBgEsc)
 	}, 3*time.Second)
 }
+
+func TestTUI_FollowLink(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"index.md":  "[[target]] is linked",
+		"target.md": "reached the target",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "index") && strings.Contains(s, "target")
+	}, 5*time.Second)
+
+	// Open index.md in ContentBox (cursor starts inside the link)
+	h.SendKeys("i", "n", "d", "e", "x", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "is linked")
+	}, 3*time.Second)
+
+	// Follow the link
+	h.SendKeys("C-]")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "reached the target") && strings.Contains(s, "Backlinks")
+	}, 3*time.Second)
+}
+
+func TestTUI_LinkCompletion(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"index.md":        "start here",
+		"zebra in zoo.md": "stripes",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "index")
+	}, 5*time.Second)
+
+	h.SendKeys("i", "n", "d", "e", "x", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "start here")
+	}, 3*time.Second)
+
+	// Typing "[[zz" pops up matching note names
+	h.SendKeys("[", "[", "z", "z")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "│ zebra in zoo │")
+	}, 3*time.Second)
+
+	// Enter inserts the selected name and closes the link
+	h.SendKeys("Enter")
+	time.Sleep(1 * time.Second)
+
+	content := h.ReadFile("index.md")
+	if !strings.HasPrefix(content, "[[zebra in zoo]]start here") {
+		t.Errorf("expected completed link in saved file, got: %s", content)
+	}
+}
+
+func TestTUI_TagCompletion(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"tagged.md":   "#work stuff",
+		"untagged.md": "other stuff",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "tagged") && strings.Contains(s, "untagged")
+	}, 5*time.Second)
+
+	h.SendKeys("t", "a", "g", ":", "w")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "│ tag:work │")
+	}, 3*time.Second)
+
+	// Selecting the completion filters the list by tag
+	h.SendKeys("Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "tag:work") && strings.Contains(s, "tagged") && !strings.Contains(s, "untagged")
+	}, 3*time.Second)
+}
+
+func TestTUI_CreateNoteFromTemplate(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"existing.md":               "some content",
+		".nve/templates/meeting.md": "# {{title}}\n\n{{cursor}}\n\nend",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "existing")
+	}, 5*time.Second)
+
+	// A 'template: title' prefix creates the note from that template
+	h.SendKeys("m", "e", "e", "t", "i", "n", "g", ":", "Space", "s", "y", "n", "c", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "# sync")
+	}, 5*time.Second)
+
+	// The cursor lands at the {{cursor}} marker
+	h.SendKeys("x")
+	time.Sleep(1 * time.Second)
+
+	content := h.ReadFile("sync.md")
+	if content != "# sync\n\nx\n\nend" {
+		t.Errorf("expected template content with typed text at cursor, got: %q", content)
+	}
+}
+
+func TestTUI_NotesInFolders(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"todo.md":      "top level list",
+		"work/todo.md": "work list",
+	})
+
+	// Same-named notes are shown with their folder
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "work/todo")
+	}, 5*time.Second)
+
+	// Typing a folder-qualified name creates the note within that folder
+	h.SendKeys("w", "o", "r", "k", "/", "p", "l", "a", "n", "s", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "work/plans")
+	}, 5*time.Second)
+
+	h.SendKeys("n", "e", "x", "t")
+	time.Sleep(1 * time.Second)
+
+	content := h.ReadFile("work/plans.md")
+	if !strings.Contains(content, "next") {
+		t.Errorf("expected 'next' in new file, got: %s", content)
+	}
+}
+
+func TestTUI_PinNote(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"alpha.md": "first",
+		"beta.md":  "second",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "alpha") && strings.Contains(s, "beta")
+	}, 5*time.Second)
+
+	// Select "alpha" in the list and pin it
+	h.SendKeys("a", "l", "p", "h", "a", "Tab", "C-s")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "* alpha")
+	}, 3*time.Second)
+
+	// Pinned notes are listed first in the recent notes
+	h.SendKeys("Escape")
+	h.WaitFor(func(s string) bool {
+		alpha, beta := strings.Index(s, "* alpha"), strings.Index(s, "beta  ")
+		return alpha >= 0 && beta >= 0 && alpha < beta
+	}, 3*time.Second)
+}
+
+func TestTUI_HelpOverlay(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"note.md": "content",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "note")
+	}, 5*time.Second)
+
+	// F1 lists the keybindings
+	h.SendKeys("F1")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Keys (Esc to close)") && strings.Contains(s, "Show or hide tags")
+	}, 3*time.Second)
+
+	h.SendKeys("Escape")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "Keys (Esc to close)")
+	}, 3*time.Second)
+
+	// '?' shows help from the list
+	h.SendKeys("Down", "?")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Keys (Esc to close)")
+	}, 3*time.Second)
+}
+
+func TestTUI_ViKeys(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		".nve/config.yaml": "keys:\n  preset: vi\n",
+		"notes.md":         "first line\nsecond line",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "notes")
+	}, 5*time.Second)
+
+	// Notes open in normal mode, where typing does not edit
+	h.SendKeys("Down", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Content (normal)") && strings.Contains(s, "first line")
+	}, 3*time.Second)
+
+	h.SendKeys("z", "d", "d")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "first line") && strings.Contains(s, "second line")
+	}, 3*time.Second)
+
+	// Esc returns from insert mode to normal mode, staying in the note
+	h.SendKeys("i", "x")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Content (insert)") && strings.Contains(s, "xsecond line")
+	}, 3*time.Second)
+
+	h.SendKeys("Escape")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Content (normal)")
+	}, 3*time.Second)
+
+	time.Sleep(1 * time.Second)
+
+	if content := h.ReadFile("notes.md"); content != "xsecond line" {
+		t.Errorf("expected 'xsecond line' in saved file, got: %q", content)
+	}
+}
+
+func TestTUI_CommandPalette(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"alpha.md": "first",
+		"beta.md":  "second",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "alpha") && strings.Contains(s, "beta")
+	}, 5*time.Second)
+
+	// F8 lists commands along with their keys
+	h.SendKeys("b", "e", "t", "a", "Tab", "Enter", "F8")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Commands") && strings.Contains(s, "Rename note") && strings.Contains(s, "F2")
+	}, 3*time.Second)
+
+	// Typing filters the commands, Enter runs the selected one
+	h.SendKeys("d", "e", "l", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Delete 'beta'?")
+	}, 3*time.Second)
+
+	h.SendKeys("Enter")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "beta") && strings.Contains(s, "alpha")
+	}, 3*time.Second)
+
+	if _, err := os.Stat(filepath.Join(h.dir, "beta.md")); !os.IsNotExist(err) {
+		t.Errorf("expected beta.md to be deleted, got: %v", err)
+	}
+}
+
+func TestTUI_Theme(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		".nve/config.yaml": "theme: mine\nthemes:\n  mine:\n    highlight-background: red\n",
+		"alpha.md":         "The food fight was fantastic",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "alpha")
+	}, 5*time.Second)
+
+	// Matches are highlighted with the color of the configured theme ('red' is
+	// bright red, SGR 101)
+	h.SendKeys("f", "o", "o")
+	h.WaitForWithColors(func(s string) bool {
+		return regexp.MustCompile(`\x1b\[101m[^\x1b]*foo`).MatchString(s)
+	}, 5*time.Second)
+}
+
+func TestTUI_StatusBar(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"notes/todo.md": "buy milk",
+		"other.md":      "something else",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "2 results") && strings.Contains(s, "watching")
+	}, 5*time.Second)
+
+	// The status bar follows the note being edited, and its saves
+	h.SendKeys("t", "o", "d", "o", "Tab", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "notes/todo.md") && strings.Contains(s, "2 words") && strings.Contains(s, "Ln 1, Col 1")
+	}, 3*time.Second)
+
+	h.SendKeys("!")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "saved") && strings.Contains(s, "9 chars") && strings.Contains(s, "Ln 1, Col 2")
+	}, 3*time.Second)
+}
+
+func TestTUI_Layouts(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		".nve/layout.yaml": "mode: side-by-side\nlist-size: 30\n",
+		"alpha.md":         "first",
+	})
+
+	// besides returns true if the list is shown left of the content
+	besides := func(s string) bool {
+		for _, line := range strings.Split(s, "\n") {
+			if strings.Contains(line, "List Box") && strings.Contains(line, "Content") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The remembered layout is restored
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "alpha") && besides(s)
+	}, 5*time.Second)
+
+	// F7 switches layouts, and remembers the new one
+	h.SendKeys("F7")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "List Box") && !besides(s)
+	}, 3*time.Second)
+
+	if layout := h.ReadFile(".nve/layout.yaml"); !strings.Contains(layout, "mode: stacked") {
+		t.Errorf("expected stacked layout to be saved, got: %s", layout)
+	}
+
+	// Zen mode shows only the note, until returning to search
+	h.SendKeys("Down", "Enter", "M-z")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "first") && !strings.Contains(s, "Search Box") && !strings.Contains(s, "List Box")
+	}, 3*time.Second)
+
+	h.SendKeys("Escape")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Search Box") && strings.Contains(s, "List Box")
+	}, 3*time.Second)
+}
+
+func TestTUI_MarkdownPreview(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"plans.md": "# Plans\n\n- **first** item\n- second item\n",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "plans")
+	}, 5*time.Second)
+
+	// Alt-p renders the note, and typing leaves it unchanged
+	h.SendKeys("Down", "Enter", "M-p")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Preview") && strings.Contains(s, "• first item") && strings.Contains(s, "═════")
+	}, 3*time.Second)
+
+	h.SendKeys("x")
+	time.Sleep(500 * time.Millisecond)
+
+	if content := h.ReadFile("plans.md"); strings.Contains(content, "x") {
+		t.Errorf("expected preview to be read-only, got: %s", content)
+	}
+
+	// Alt-p again returns to editing the Markdown
+	h.SendKeys("M-p")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "# Plans") && strings.Contains(s, "- **first** item")
+	}, 3*time.Second)
+}
+
+func TestTUI_FindReplace(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"pets.md": "cats and dogs\nmore cats",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "pets")
+	}, 5*time.Second)
+
+	// Ctrl-F finds within the note, counting matches
+	h.SendKeys("Down", "Enter", "C-f", "c", "a", "t", "s")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Find: cats") && strings.Contains(s, "1 of 2")
+	}, 3*time.Second)
+
+	h.SendKeys("Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "2 of 2")
+	}, 3*time.Second)
+
+	// Replacing all saves the note
+	h.SendKeys("Tab", "b", "i", "r", "d", "s", "M-a")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "2 replaced") && strings.Contains(s, "birds and dogs")
+	}, 3*time.Second)
+
+	time.Sleep(1 * time.Second)
+
+	if content := h.ReadFile("pets.md"); content != "birds and dogs\nmore birds" {
+		t.Errorf("expected replacements to be saved, got: %q", content)
+	}
+
+	// Esc closes the find bar
+	h.SendKeys("Escape")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "Find:")
+	}, 3*time.Second)
+}
+
+func TestTUI_ReplaceInNotes(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"plan.md":     "Apollo starts monday\nthe Apollo team",
+		"work/log.md": "Apollo shipped",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "plan")
+	}, 5*time.Second)
+
+	// the command palette opens the replace box
+	h.SendKeys("F8", "r", "e", "p", "l", "a", "c", "e", " ", "a", "l", "l", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Replace in all notes")
+	}, 3*time.Second)
+
+	// Enter previews the lines changed in each note
+	h.SendKeys("A", "p", "o", "l", "l", "o", "Tab", "A", "r", "t", "e", "m", "i", "s", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "3 of 3 lines in 2 notes") && strings.Contains(s, "+ the Artemis team")
+	}, 3*time.Second)
+
+	// Space deselects a line, Enter applies the rest
+	h.SendKeys("Down", "Down", " ")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "2 of 3 lines in 2 notes")
+	}, 3*time.Second)
+
+	h.SendKeys("Enter")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "Replace in all notes")
+	}, 3*time.Second)
+
+	if content := h.ReadFile("plan.md"); content != "Artemis starts monday\nthe Apollo team" {
+		t.Errorf("expected selected lines to be replaced, got: %q", content)
+	}
+
+	if content := h.ReadFile("work/log.md"); content != "Artemis shipped" {
+		t.Errorf("expected selected lines to be replaced, got: %q", content)
+	}
+}
+
+func TestTUI_Passages(t *testing.T) {
+	filler := strings.Repeat("more filler words here. ", 10)
+
+	h := NewTUIHarness(t, map[string]string{
+		"plan.md":  "The budget meeting is on monday.\n" + filler + "\nWe cut the budget in half.\n" + filler + "\nThe final budget was approved.",
+		"other.md": "nothing to see",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "plan")
+	}, 5*time.Second)
+
+	h.SendKeys("b", "u", "d", "g", "e", "t")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "budget meeting") && !strings.Contains(s, "other")
+	}, 3*time.Second)
+
+	// Right expands the selected note to show its best passages
+	h.SendKeys("Tab", "Right")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "...cut the budget in half.") && strings.Contains(s, "words here. The final budget was approved.")
+	}, 3*time.Second)
+
+	// and Right again hides them
+	h.SendKeys("Right")
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "...cut the budget in half.")
+	}, 3*time.Second)
+}
+
+func TestTUI_BulkActions(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"apple.md":  "A fruit that is red",
+		"banana.md": "A fruit that is yellow",
+		"carrot.md": "A vegetable",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "carrot")
+	}, 5*time.Second)
+
+	h.SendKeys("f", "r", "u", "i", "t")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "banana") && !strings.Contains(s, "carrot")
+	}, 3*time.Second)
+
+	// Space marks the selected note and moves on to the next one
+	h.SendKeys("Tab", "Space", "Space")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "List Box (2 marked)")
+	}, 3*time.Second)
+
+	// actions from the command palette apply to all marked notes
+	h.SendKeys("F8", "a", "d", "d", " ", "t", "a", "g", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Add tag to 2 notes")
+	}, 3*time.Second)
+
+	h.SendKeys("s", "n", "a", "c", "k", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "List Box") && !strings.Contains(s, "marked")
+	}, 3*time.Second)
+
+	for _, name := range []string{"apple.md", "banana.md"} {
+		if content := h.ReadFile(name); !strings.HasPrefix(content, "---\ntags: [snack]\n---\n") {
+			t.Errorf("expected tag in %s, got: %s", name, content)
+		}
+	}
+
+	if content := h.ReadFile("carrot.md"); content != "A vegetable" {
+		t.Errorf("expected unmarked note unchanged, got: %s", content)
+	}
+}
+
+func TestTUI_SplitAndUndo(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"fruit.md": "Intro\n\n# Apples\nRed\n\n# Pears\nGreen\n",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "fruit")
+	}, 5*time.Second)
+
+	h.SendKeys("f", "r", "u", "i", "t", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "# Pears")
+	}, 3*time.Second)
+
+	// each heading is split off into a note, linked from the note split
+	h.SendKeys("F8", "s", "p", "l", "i", "t", " ", "h", "e", "a", "d", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Split into 2 notes")
+	}, 3*time.Second)
+
+	h.SendKeys("Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "[[Apples]]") && strings.Contains(s, "[[Pears]]")
+	}, 3*time.Second)
+
+	if content := h.ReadFile("Pears.md"); content != "# Pears\nGreen\n" {
+		t.Errorf("expected section in new note, got: %s", content)
+	}
+
+	// undoing the split restores the note and removes the new ones
+	h.SendKeys("F8", "u", "n", "d", "o", " ", "m", "e", "r", "g", "e", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "# Pears") && !strings.Contains(s, "[[Pears]]")
+	}, 3*time.Second)
+
+	if content := h.ReadFile("fruit.md"); content != "Intro\n\n# Apples\nRed\n\n# Pears\nGreen\n" {
+		t.Errorf("expected note restored, got: %s", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(h.dir, "Pears.md")); !os.IsNotExist(err) {
+		t.Errorf("expected split note removed, got: %v", err)
+	}
+}
+
+func TestTUI_Clipboard(t *testing.T) {
+	h := NewTUIHarness(t, map[string]string{
+		"recipe.md": "Flour and water",
+	})
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "recipe")
+	}, 5*time.Second)
+
+	h.SendKeys("r", "e", "c", "i", "p", "e", "Enter")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Flour and water")
+	}, 3*time.Second)
+
+	// copies go through the terminal with OSC 52, which tmux keeps
+	h.SendKeys("F8", "c", "o", "p", "y", " ", "l", "i", "n", "k", "Enter")
+	h.WaitForClipboard("[[recipe]]", 3*time.Second)
+
+	h.SendKeys("F8", "c", "o", "p", "y", " ", "t", "e", "x", "t", "Enter")
+	h.WaitForClipboard("Flour and water", 3*time.Second)
+
+	// text cut in the note is copied too, and pasted back
+	h.SendKeys("C-l", "C-x")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "0 words")
+	}, 3*time.Second)
+	h.WaitForClipboard("Flour and water", 3*time.Second)
+
+	h.SendKeys("C-v", "C-v")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "Flour and waterFlour and water")
+	}, 3*time.Second)
+}
+
+// rowOf returns the row of the screen on which text first appears, or -1.
+func rowOf(screen, text string) int {
+	for row, line := range strings.Split(screen, "\n") {
+		if strings.Contains(line, text) {
+			return row
+		}
+	}
+	return -1
+}
+
+func TestTUI_Mouse(t *testing.T) {
+	files := map[string]string{
+		"alpha.md": "Alpha text",
+		"beta.md":  "Beta text",
+		"gamma.md": "Gamma text",
+	}
+	for i := 1; i <= 9; i++ {
+		files[fmt.Sprintf("note%d.md", i)] = fmt.Sprintf("Filler %d", i)
+	}
+
+	var lines []string
+	for i := 1; i <= 40; i++ {
+		lines = append(lines, fmt.Sprintf("Line %d of a long note", i))
+	}
+	files["long.md"] = strings.Join(lines, "\n")
+
+	h := NewTUIHarness(t, files)
+
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "13 results")
+	}, 5*time.Second)
+
+	h.SendKeys("t", "e", "x", "t")
+	screen := h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "│ beta ") && strings.Contains(s, "│ gamma ")
+	}, 3*time.Second)
+
+	// a click selects the note, syncing the search box and the content
+	h.Click(10, rowOf(screen, "│ beta "))
+	screen = h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "│ beta ") && strings.Contains(s, "│ Beta text")
+	}, 3*time.Second)
+	if row := rowOf(screen, "│ beta "); row != 1 {
+		t.Errorf("expected the search box to show the note, found it on row %d", row)
+	}
+
+	// a double click opens it for editing, at the first match
+	time.Sleep(500 * time.Millisecond)
+	h.DoubleClick(10, rowOf(screen, " gamma "))
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "║ Gamma text")
+	}, 3*time.Second)
+	h.SendKeys("X")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "║ Gamma Xtext")
+	}, 3*time.Second)
+
+	// the wheel scrolls the content and the list
+	h.SendKeys("Escape", "C-u")
+	h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "13 results")
+	}, 3*time.Second)
+	h.SendKeys("l", "o", "n", "g", "Enter")
+	screen = h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "║ Line 1 of")
+	}, 3*time.Second)
+
+	content := rowOf(screen, "║ Line 1 of")
+	for i := 0; i < 3; i++ {
+		h.Scroll(10, content+2, true)
+	}
+	h.WaitFor(func(s string) bool {
+		return !strings.Contains(s, "║ Line 1 of") && strings.Contains(s, "║ Line 4 of")
+	}, 3*time.Second)
+
+	// a click in the search box focuses it
+	h.Click(10, 1)
+	h.SendKeys("C-u")
+	screen = h.WaitFor(func(s string) bool {
+		return strings.Contains(s, "13 results") && !strings.Contains(s, "Line 4 of")
+	}, 3*time.Second)
+
+	second := strings.Split(screen, "\n")[5]
+	h.Scroll(10, 5, true)
+	h.WaitFor(func(s string) bool {
+		return rowOf(s, second) == 4
+	}, 3*time.Second)
+
+	// dragging the border of the list resizes it, and is remembered
+	border := rowOf(screen, "Content")
+	h.Drag(50, border-1, 50, border+5)
+	h.WaitFor(func(s string) bool {
+		return rowOf(s, "Content") >= border+5
+	}, 3*time.Second)
+
+	if layout := h.ReadFile(".nve/layout.yaml"); !strings.Contains(layout, "list-size: 46") {
+		t.Errorf("expected the list size to be saved, got:\n%s", layout)
+	}
+}
@@ -28,6 +28,9 @@ func (n *Notes) StartWatching(drawFunc func(func())) error {
 		if err != nil {
 			return nil
 		}
+		if info.IsDir() && info.Name() == configDirName {
+			return filepath.SkipDir
+		}
 		if info.IsDir() {
 			if watchErr := watcher.Add(path); watchErr != nil {
 				log.Printf("[WARN] watcher: could not watch %s: %v", path, watchErr)
@@ -42,6 +45,9 @@ func (n *Notes) StartWatching(drawFunc func(func())) error {
 
 	go n.watchLoop(watcher)
 
+	n.Status.Watching = true
+	n.NotifyStatus()
+
 	log.Printf("[INFO] watcher: started monitoring %s", n.config.Filepath)
 	return nil
 }
@@ -51,6 +57,7 @@ func (n *Notes) StopWatching() {
 	if n.watcher != nil {
 		n.watcher.Close()
 		n.watcher = nil
+		n.Status.Watching = false
 		log.Printf("[INFO] watcher: stopped")
 	}
 }
@@ -94,6 +101,15 @@ func (n *Notes) watchLoop(watcher *fsnotify.Watcher) {
 
 func (n *Notes) handleWatcherRefresh() {
 	changed, err := n.Refresh()
+
+	// only failing, or recovering from a failure, changes the status
+	if failed := err != nil; failed != n.refreshFailed {
+		n.refreshFailed = failed
+		n.updateStatus(func(s *Status) {
+			s.IndexErr = err
+		})
+	}
+
 	if err != nil {
 		log.Printf("[ERROR] watcher: refresh failed: %v", err)
 		return